@@ -0,0 +1,149 @@
+// Package cache is a simple on-disk cache for scan results. Entries are JSON files named by the
+// sha256 of their key and are pruned by age (the modification time is updated on every hit).
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sbom-observer/observer-cli/pkg/log"
+)
+
+const entryExtension = ".json"
+
+type Cache struct {
+	dir string
+}
+
+func New(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// DefaultDir returns the directory for cached scan results in the user cache directory
+// (i.e. ~/.cache/sbom-observer/scans or ~/Library/Caches/sbom-observer/scans)
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "sbom-observer", "scans"), nil
+}
+
+// Key creates a cache key from all parts that affect the cached value
+func Key(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+func (c *Cache) filename(key string) string {
+	return filepath.Join(c.dir, key+entryExtension)
+}
+
+// Get reads the entry for key into value and returns false if there is no entry
+func (c *Cache) Get(key string, value any) (bool, error) {
+	filename := c.filename(key)
+
+	bs, err := os.ReadFile(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if err := json.Unmarshal(bs, value); err != nil {
+		return false, fmt.Errorf("failed to read cache entry %s: %w", filename, err)
+	}
+
+	// keep recently used entries from being pruned
+	now := time.Now()
+	if err := os.Chtimes(filename, now, now); err != nil {
+		log.Debug("failed to update cache entry time", "filename", filename, "err", err)
+	}
+
+	return true, nil
+}
+
+// Put stores value for key
+func (c *Cache) Put(key string, value any) error {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+
+	bs, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first so that concurrent readers never see partial entries
+	f, err := os.CreateTemp(c.dir, key+"-*.tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(bs); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+
+	return os.Rename(f.Name(), c.filename(key))
+}
+
+// Prune removes entries that have not been used for maxAge (all entries if maxAge is 0) and
+// returns the number of removed entries
+func (c *Cache) Prune(maxAge time.Duration) (int, error) {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+
+	removed := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !(strings.HasSuffix(entry.Name(), entryExtension) || strings.HasSuffix(entry.Name(), ".tmp")) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return removed, err
+		}
+
+		if maxAge > 0 && info.ModTime().After(cutoff) {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(c.dir, entry.Name())); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, Key("a", "b"), Key("a", "b"))
+	assert.NotEqual(t, Key("a", "b"), Key("b", "a"))
+	assert.NotEqual(t, Key("ab", ""), Key("a", "b"))
+}
+
+func TestCache_GetPut(t *testing.T) {
+	c := New(filepath.Join(t.TempDir(), "scans"))
+
+	type entry struct {
+		Name string
+	}
+
+	var value entry
+	found, err := c.Get(Key("missing"), &value)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	require.NoError(t, c.Put(Key("key"), entry{Name: "test"}))
+
+	found, err = c.Get(Key("key"), &value)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "test", value.Name)
+}
+
+func TestCache_Prune(t *testing.T) {
+	c := New(t.TempDir())
+
+	require.NoError(t, c.Put(Key("old"), "old"))
+	require.NoError(t, c.Put(Key("new"), "new"))
+
+	old := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, os.Chtimes(c.filename(Key("old")), old, old))
+
+	removed, err := c.Prune(24 * time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	var value string
+	found, _ := c.Get(Key("old"), &value)
+	assert.False(t, found)
+	found, _ = c.Get(Key("new"), &value)
+	assert.True(t, found)
+
+	removed, err = c.Prune(0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	// pruning a missing cache directory is not an error
+	removed, err = New(filepath.Join(t.TempDir(), "missing")).Prune(0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, removed)
+}
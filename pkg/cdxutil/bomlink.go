@@ -0,0 +1,107 @@
+package cdxutil
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/google/uuid"
+)
+
+const bomLinkPrefix = "urn:cdx:"
+
+// BOMLink is a CycloneDX BOM-Link (https://cyclonedx.org/capabilities/bomlink/) to a BOM, or to an element of a BOM
+// if Ref is set: urn:cdx:serial-number/version#bom-ref
+type BOMLink struct {
+	// SerialNumber is the UUID of the linked BOM (without the urn:uuid: prefix)
+	SerialNumber string
+	Version      int
+	Ref          string
+}
+
+// NewBOMLink returns a link to the element with the BOMRef ref in the BOM (or to the BOM itself if ref is empty)
+func NewBOMLink(bom *cdx.BOM, ref string) (BOMLink, error) {
+	serial, found := strings.CutPrefix(bom.SerialNumber, "urn:uuid:")
+	if !found || uuid.Validate(serial) != nil {
+		return BOMLink{}, fmt.Errorf("BOM-Link requires a urn:uuid serial number, got '%s'", bom.SerialNumber)
+	}
+
+	version := bom.Version
+	if version < 1 {
+		version = 1
+	}
+
+	return BOMLink{SerialNumber: serial, Version: version, Ref: ref}, nil
+}
+
+// ParseBOMLink parses a BOM-Link in the form urn:cdx:serial-number/version[#bom-ref]
+func ParseBOMLink(s string) (BOMLink, error) {
+	rest, found := strings.CutPrefix(s, bomLinkPrefix)
+	if !found {
+		return BOMLink{}, fmt.Errorf("not a BOM-Link: '%s'", s)
+	}
+
+	rest, fragment, _ := strings.Cut(rest, "#")
+
+	serial, version, found := strings.Cut(rest, "/")
+	if !found || uuid.Validate(serial) != nil {
+		return BOMLink{}, fmt.Errorf("invalid BOM-Link serial number in '%s'", s)
+	}
+
+	v, err := strconv.Atoi(version)
+	if err != nil || v < 1 {
+		return BOMLink{}, fmt.Errorf("invalid BOM-Link version in '%s'", s)
+	}
+
+	ref, err := url.PathUnescape(fragment)
+	if err != nil {
+		return BOMLink{}, fmt.Errorf("invalid BOM-Link bom-ref in '%s': %w", s, err)
+	}
+
+	return BOMLink{SerialNumber: strings.ToLower(serial), Version: v, Ref: ref}, nil
+}
+
+// IsBOMLink returns true if s is a BOM-Link
+func IsBOMLink(s string) bool {
+	_, err := ParseBOMLink(s)
+	return err == nil
+}
+
+func (l BOMLink) String() string {
+	s := fmt.Sprintf("%s%s/%d", bomLinkPrefix, l.SerialNumber, l.Version)
+	if l.Ref != "" {
+		s += "#" + url.PathEscape(l.Ref)
+	}
+	return s
+}
+
+// BOM returns the link to the BOM (without the bom-ref)
+func (l BOMLink) BOM() BOMLink {
+	return BOMLink{SerialNumber: l.SerialNumber, Version: l.Version}
+}
+
+// StableSerialNumber returns a serial number derived from name, BOMs with the same name get the same serial number
+// (e.g. SBOMs of the same target in every scan, so BOM-Links to them stay valid)
+func StableSerialNumber(name string) string {
+	return "urn:uuid:" + uuid.NewSHA1(reproducibleNamespace, []byte("serial:"+name)).String()
+}
+
+// BOMLinks returns the BOM-Links (external references of type bom) of the component
+func BOMLinks(component cdx.Component) []BOMLink {
+	if component.ExternalReferences == nil {
+		return nil
+	}
+
+	var links []BOMLink
+	for _, reference := range *component.ExternalReferences {
+		if reference.Type != cdx.ERTypeBOM {
+			continue
+		}
+		if link, err := ParseBOMLink(reference.URL); err == nil {
+			links = append(links, link)
+		}
+	}
+	return links
+}
@@ -0,0 +1,40 @@
+package cdxutil
+
+import (
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBOMLink(t *testing.T) {
+	bom := &cdx.BOM{SerialNumber: "urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79", Version: 2}
+
+	link, err := NewBOMLink(bom, "pkg:npm/@acme/web@1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, "urn:cdx:3e671687-395b-41f5-a30f-a58921a69b79/2#pkg:npm%2F@acme%2Fweb@1.0.0", link.String())
+
+	parsed, err := ParseBOMLink(link.String())
+	require.NoError(t, err)
+	assert.Equal(t, link, parsed)
+	assert.Equal(t, "urn:cdx:3e671687-395b-41f5-a30f-a58921a69b79/2", parsed.BOM().String())
+
+	_, err = NewBOMLink(&cdx.BOM{SerialNumber: "123"}, "")
+	assert.ErrorContains(t, err, "requires a urn:uuid serial number")
+
+	for _, invalid := range []string{
+		"https://example.com/bom.json",
+		"urn:cdx:not-a-uuid/1",
+		"urn:cdx:3e671687-395b-41f5-a30f-a58921a69b79",
+		"urn:cdx:3e671687-395b-41f5-a30f-a58921a69b79/0",
+	} {
+		assert.False(t, IsBOMLink(invalid), invalid)
+	}
+}
+
+func TestStableSerialNumber(t *testing.T) {
+	assert.Equal(t, StableSerialNumber("shop:services/api"), StableSerialNumber("shop:services/api"))
+	assert.NotEqual(t, StableSerialNumber("shop:services/api"), StableSerialNumber("shop:services/web"))
+	assert.Regexp(t, `^urn:uuid:[0-9a-f-]{36}$`, StableSerialNumber("shop:."))
+}
@@ -0,0 +1,107 @@
+package cdxutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+)
+
+// SpecVersions are the CycloneDX spec versions that BOMs can be converted to
+var SpecVersions = []cdx.SpecVersion{cdx.SpecVersion1_4, cdx.SpecVersion1_5, cdx.SpecVersion1_6}
+
+// ParseSpecVersion parses a CycloneDX spec version (e.g. 1.5)
+func ParseSpecVersion(version string) (cdx.SpecVersion, error) {
+	var names []string
+	for _, specVersion := range SpecVersions {
+		if specVersion.String() == version {
+			return specVersion, nil
+		}
+		names = append(names, specVersion.String())
+	}
+
+	return 0, fmt.Errorf("unsupported CycloneDX spec version '%s' (supported: %s)", version, strings.Join(names, ", "))
+}
+
+// DroppedField is a field of a BOM that can not be represented in an older spec version
+type DroppedField struct {
+	// Path is the JSON path of the field, with array indexes removed (e.g. components[].evidence.identity)
+	Path string
+	// Count is the number of occurrences in the BOM
+	Count int
+}
+
+// DroppedFields returns the fields that are lost when the BOM is converted to specVersion. Fields that are converted
+// to a different representation (e.g. metadata.tools in 1.4) are not reported.
+func DroppedFields(bom *cdx.BOM, specVersion cdx.SpecVersion) ([]DroppedField, error) {
+	if specVersion >= bom.SpecVersion {
+		return nil, nil
+	}
+
+	var original, converted bytes.Buffer
+	if err := cdx.NewBOMEncoder(&original, cdx.BOMFileFormatJSON).Encode(bom); err != nil {
+		return nil, err
+	}
+	if err := cdx.NewBOMEncoder(&converted, cdx.BOMFileFormatJSON).EncodeVersion(bom, specVersion); err != nil {
+		return nil, err
+	}
+
+	var a, b any
+	if err := json.Unmarshal(original.Bytes(), &a); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(converted.Bytes(), &b); err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int{}
+	collectDroppedFields("", a, b, counts)
+
+	var dropped []DroppedField
+	for path, count := range counts {
+		dropped = append(dropped, DroppedField{Path: path, Count: count})
+	}
+
+	sort.Slice(dropped, func(i, j int) bool {
+		return dropped[i].Path < dropped[j].Path
+	})
+
+	return dropped, nil
+}
+
+// collectDroppedFields counts the fields in original that are missing in converted
+func collectDroppedFields(path string, original any, converted any, counts map[string]int) {
+	switch o := original.(type) {
+	case map[string]any:
+		c, ok := converted.(map[string]any)
+		if !ok {
+			return
+		}
+
+		for key, value := range o {
+			fieldPath := key
+			if path != "" {
+				fieldPath = path + "." + key
+			}
+
+			if convertedValue, found := c[key]; found {
+				collectDroppedFields(fieldPath, value, convertedValue, counts)
+			} else {
+				counts[fieldPath]++
+			}
+		}
+
+	case []any:
+		c, ok := converted.([]any)
+		if !ok || len(c) != len(o) {
+			return
+		}
+
+		for i := range o {
+			collectDroppedFields(path+"[]", o[i], c[i], counts)
+		}
+	}
+}
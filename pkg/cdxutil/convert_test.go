@@ -0,0 +1,56 @@
+package cdxutil
+
+import (
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSpecVersion(t *testing.T) {
+	version, err := ParseSpecVersion("1.5")
+	require.NoError(t, err)
+	assert.Equal(t, cdx.SpecVersion1_5, version)
+
+	_, err = ParseSpecVersion("1.3")
+	assert.ErrorContains(t, err, "supported: 1.4, 1.5, 1.6")
+}
+
+func TestDroppedFields(t *testing.T) {
+	bom := testBOM()
+	bom.Metadata.Tools = &cdx.ToolsChoice{
+		Components: &[]cdx.Component{{Type: cdx.ComponentTypeApplication, Name: "observer", Version: "1.0.0"}},
+	}
+	bom.Metadata.Lifecycles = &[]cdx.Lifecycle{{Phase: cdx.LifecyclePhaseBuild}}
+	bom.Metadata.Manufacturer = &cdx.OrganizationalEntity{Name: "Acme"}
+	confidence := float32(0.9)
+	(*bom.Components)[0].Evidence = &cdx.Evidence{
+		Identity: &[]cdx.EvidenceIdentity{{Field: cdx.EvidenceIdentityFieldTypePURL, Confidence: &confidence}},
+	}
+
+	t.Run("downgrade to 1.5", func(t *testing.T) {
+		dropped, err := DroppedFields(bom, cdx.SpecVersion1_5)
+		require.NoError(t, err)
+		assert.Equal(t, []DroppedField{{Path: "metadata.manufacturer", Count: 1}}, dropped)
+	})
+
+	t.Run("downgrade to 1.4", func(t *testing.T) {
+		dropped, err := DroppedFields(bom, cdx.SpecVersion1_4)
+		require.NoError(t, err)
+		assert.Equal(t, []DroppedField{
+			{Path: "components[].evidence.identity", Count: 1},
+			{Path: "metadata.lifecycles", Count: 1},
+			{Path: "metadata.manufacturer", Count: 1},
+		}, dropped)
+	})
+
+	t.Run("upgrade", func(t *testing.T) {
+		bom := testBOM()
+		bom.SpecVersion = cdx.SpecVersion1_4
+
+		dropped, err := DroppedFields(bom, cdx.SpecVersion1_6)
+		require.NoError(t, err)
+		assert.Empty(t, dropped)
+	})
+}
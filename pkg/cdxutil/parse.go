@@ -1,27 +1,19 @@
 package cdxutil
 
 import (
-	"fmt"
+	"io"
+
 	cdx "github.com/CycloneDX/cyclonedx-go"
-	"os"
 )
 
+// ParseCycloneDX reads an SBOM in any of the supported formats from a file (see ReadBOM)
 func ParseCycloneDX(filename string) (*cdx.BOM, error) {
-	var bom cdx.BOM
-
-	bomFormat := cdx.BOMFileFormatJSON
-
-	f, err := os.Open(filename)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-
-	decoder := cdx.NewBOMDecoder(f, bomFormat)
-	err = decoder.Decode(&bom)
-	if err != nil {
-		return nil, fmt.Errorf("cdx.Decode: %w", err)
-	}
+	bom, _, err := ReadBOMFile(filename)
+	return bom, err
+}
 
-	return &bom, nil
+// DecodeCycloneDX reads an SBOM in any of the supported formats from r (see ReadBOM)
+func DecodeCycloneDX(r io.Reader) (*cdx.BOM, error) {
+	bom, _, err := ReadBOM(r)
+	return bom, err
 }
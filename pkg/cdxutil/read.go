@@ -0,0 +1,176 @@
+package cdxutil
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/sbom-observer/observer-cli/pkg/spdxutil"
+	spdxjson "github.com/spdx/tools-golang/json"
+	"github.com/spdx/tools-golang/tagvalue"
+)
+
+// Format is the format of an SBOM file
+type Format string
+
+// formats detected by ReadBOM (the names match the output formats of --format)
+const (
+	FormatCycloneDXJSON Format = "cyclonedx-json"
+	FormatCycloneDXXML  Format = "cyclonedx-xml"
+	FormatSPDXJSON      Format = "spdx-json"
+	FormatSPDXTagValue  Format = "spdx-tv"
+)
+
+// Input describes how an SBOM was read
+type Input struct {
+	Format     Format
+	Compressed bool
+}
+
+// IsCycloneDX returns true if the input was an uncompressed CycloneDX BOM (i.e. it can be used without conversion)
+func (i Input) IsCycloneDX() bool {
+	return !i.Compressed && (i.Format == FormatCycloneDXJSON || i.Format == FormatCycloneDXXML)
+}
+
+// ReadBOMFile reads an SBOM from a file, see ReadBOM
+func ReadBOMFile(filename string) (*cdx.BOM, Input, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, Input{}, err
+	}
+	defer f.Close()
+
+	return ReadBOM(f)
+}
+
+// ReadBOM reads an SBOM in any of the supported formats from r. The format is detected from the content: CycloneDX
+// JSON or XML and SPDX JSON or tag-value, optionally gzip compressed. SPDX documents are converted to CycloneDX.
+func ReadBOM(r io.Reader) (*cdx.BOM, Input, error) {
+	var input Input
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, input, err
+	}
+
+	if isGzip(data) {
+		input.Compressed = true
+
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, input, fmt.Errorf("gzip: %w", err)
+		}
+		defer gz.Close()
+
+		data, err = io.ReadAll(gz)
+		if err != nil {
+			return nil, input, fmt.Errorf("gzip: %w", err)
+		}
+	}
+
+	input.Format, err = DetectFormat(data)
+	if err != nil {
+		return nil, input, err
+	}
+
+	switch input.Format {
+	case FormatCycloneDXJSON, FormatCycloneDXXML:
+		fileFormat := cdx.BOMFileFormatJSON
+		if input.Format == FormatCycloneDXXML {
+			fileFormat = cdx.BOMFileFormatXML
+		}
+
+		var bom cdx.BOM
+		if err := cdx.NewBOMDecoder(bytes.NewReader(data), fileFormat).Decode(&bom); err != nil {
+			return nil, input, fmt.Errorf("cdx.Decode: %w", err)
+		}
+
+		return &bom, input, nil
+
+	case FormatSPDXJSON:
+		doc, err := spdxjson.Read(bytes.NewReader(data))
+		if err != nil {
+			return nil, input, fmt.Errorf("spdx json: %w", err)
+		}
+
+		bom, err := spdxutil.ToCycloneDX(doc)
+		return bom, input, err
+
+	case FormatSPDXTagValue:
+		doc, err := tagvalue.Read(bytes.NewReader(data))
+		if err != nil {
+			return nil, input, fmt.Errorf("spdx tag-value: %w", err)
+		}
+
+		bom, err := spdxutil.ToCycloneDX(doc)
+		return bom, input, err
+	}
+
+	return nil, input, fmt.Errorf("unsupported SBOM format %s", input.Format)
+}
+
+// DetectFormat detects the SBOM format from the (uncompressed) content
+func DetectFormat(data []byte) (Format, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+
+	if len(trimmed) == 0 {
+		return "", fmt.Errorf("empty SBOM")
+	}
+
+	switch trimmed[0] {
+	case '{':
+		var header struct {
+			BOMFormat   string `json:"bomFormat"`
+			SPDXVersion string `json:"spdxVersion"`
+			Context     any    `json:"@context"`
+		}
+		if err := json.Unmarshal(trimmed, &header); err != nil {
+			return "", fmt.Errorf("invalid JSON: %w", err)
+		}
+
+		switch {
+		case header.BOMFormat == "CycloneDX":
+			return FormatCycloneDXJSON, nil
+		case strings.HasPrefix(header.SPDXVersion, "SPDX-2."):
+			return FormatSPDXJSON, nil
+		case header.Context != nil && strings.Contains(fmt.Sprint(header.Context), "spdx.org/rdf/3"):
+			return "", fmt.Errorf("SPDX 3.0 input is not supported")
+		}
+
+		return "", fmt.Errorf("unknown JSON document (not CycloneDX or SPDX)")
+
+	case '<':
+		if bytes.Contains(trimmed, []byte("cyclonedx.org/schema/bom")) {
+			return FormatCycloneDXXML, nil
+		}
+
+		return "", fmt.Errorf("unknown XML document (not CycloneDX)")
+	}
+
+	// tag-value documents start with SPDXVersion (optionally preceded by comments)
+	scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "SPDXVersion:") {
+			return FormatSPDXTagValue, nil
+		}
+		break
+	}
+
+	return "", fmt.Errorf("unknown SBOM format")
+}
+
+func isGzip(data []byte) bool {
+	return len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b
+}
@@ -0,0 +1,110 @@
+package cdxutil
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/sbom-observer/observer-cli/pkg/spdxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testBOM() *cdx.BOM {
+	bom := cdx.NewBOM()
+	bom.SerialNumber = "urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79"
+	bom.Metadata = &cdx.Metadata{
+		Component: &cdx.Component{BOMRef: "root", Type: cdx.ComponentTypeApplication, Name: "app", Version: "1.0.0"},
+	}
+	bom.Components = &[]cdx.Component{
+		{BOMRef: "pkg:npm/left-pad@1.3.0", Type: cdx.ComponentTypeLibrary, Name: "left-pad", Version: "1.3.0", PackageURL: "pkg:npm/left-pad@1.3.0"},
+	}
+	bom.Dependencies = &[]cdx.Dependency{
+		{Ref: "root", Dependencies: &[]string{"pkg:npm/left-pad@1.3.0"}},
+	}
+	return bom
+}
+
+func encode(t *testing.T, format Format) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case FormatCycloneDXJSON:
+		err = cdx.NewBOMEncoder(&buf, cdx.BOMFileFormatJSON).Encode(testBOM())
+	case FormatCycloneDXXML:
+		err = cdx.NewBOMEncoder(&buf, cdx.BOMFileFormatXML).Encode(testBOM())
+	case FormatSPDXJSON:
+		err = spdxutil.EncodeJSON(&buf, testBOM(), false)
+	case FormatSPDXTagValue:
+		err = spdxutil.EncodeTagValue(&buf, testBOM())
+	}
+	require.NoError(t, err)
+
+	return buf.Bytes()
+}
+
+func TestReadBOM(t *testing.T) {
+	for _, format := range []Format{FormatCycloneDXJSON, FormatCycloneDXXML, FormatSPDXJSON, FormatSPDXTagValue} {
+		t.Run(string(format), func(t *testing.T) {
+			data := encode(t, format)
+
+			bom, input, err := ReadBOM(bytes.NewReader(data))
+			require.NoError(t, err)
+			assert.Equal(t, Input{Format: format}, input)
+			assert.Equal(t, "app", bom.Metadata.Component.Name)
+			require.Len(t, *bom.Components, 1)
+			assert.Equal(t, "pkg:npm/left-pad@1.3.0", (*bom.Components)[0].PackageURL)
+		})
+
+		t.Run(string(format)+"+gzip", func(t *testing.T) {
+			var compressed bytes.Buffer
+			gz := gzip.NewWriter(&compressed)
+			_, err := gz.Write(encode(t, format))
+			require.NoError(t, err)
+			require.NoError(t, gz.Close())
+
+			bom, input, err := ReadBOM(&compressed)
+			require.NoError(t, err)
+			assert.Equal(t, Input{Format: format, Compressed: true}, input)
+			assert.False(t, input.IsCycloneDX())
+			assert.Equal(t, "app", bom.Metadata.Component.Name)
+		})
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected Format
+		err      string
+	}{
+		{name: "cyclonedx json with byte order mark", content: "\xef\xbb\xbf\n {\"bomFormat\": \"CycloneDX\", \"specVersion\": \"1.6\"}", expected: FormatCycloneDXJSON},
+		{name: "cyclonedx xml", content: `<?xml version="1.0"?><bom xmlns="http://cyclonedx.org/schema/bom/1.5"></bom>`, expected: FormatCycloneDXXML},
+		{name: "spdx json", content: `{"spdxVersion": "SPDX-2.2", "SPDXID": "SPDXRef-DOCUMENT"}`, expected: FormatSPDXJSON},
+		{name: "spdx tag-value with comments", content: "# generated\n\nSPDXVersion: SPDX-2.3\nDataLicense: CC0-1.0\n", expected: FormatSPDXTagValue},
+		{name: "spdx 3", content: `{"@context": "https://spdx.org/rdf/3.0.1/spdx-context.jsonld", "@graph": []}`, err: "SPDX 3.0 input is not supported"},
+		{name: "other json", content: `{"name": "package.json"}`, err: "unknown JSON document"},
+		{name: "other xml", content: `<project></project>`, err: "unknown XML document"},
+		{name: "empty", content: " \n", err: "empty SBOM"},
+		{name: "text", content: "hello world", err: "unknown SBOM format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, err := DetectFormat([]byte(tt.content))
+			if tt.err != "" {
+				require.Error(t, err)
+				assert.True(t, strings.Contains(err.Error(), tt.err), err.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, format)
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package cdxutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/google/uuid"
+)
+
+// reproducibleNamespace is the namespace of content derived (version 5) UUIDs
+var reproducibleNamespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("https://sbom.observer/reproducible"))
+
+var uuidPattern = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+
+// MakeReproducible rewrites the BOM so that the same content always results in the same output:
+//   - random (UUID) BOMRefs of components, services, vulnerabilities, compositions and annotations are replaced
+//     with UUIDs derived from their content, and every reference to them is updated
+//   - the timestamp is set to timestamp (or removed if timestamp is zero)
+//   - components, services, dependencies, vulnerabilities, compositions, annotations and properties are sorted
+//   - the serial number is derived from the content
+func MakeReproducible(bom *cdx.BOM, timestamp time.Time) error {
+	if bom.Metadata != nil {
+		bom.Metadata.Timestamp = ""
+		if !timestamp.IsZero() {
+			bom.Metadata.Timestamp = timestamp.UTC().Format(time.RFC3339)
+		}
+	}
+
+	// BOMRefs
+	r := &refRewriter{refs: map[string]string{}, used: map[string]bool{}}
+
+	var rewrite func(component *cdx.Component)
+	rewrite = func(component *cdx.Component) {
+		if isUUID(component.BOMRef) {
+			if content, err := componentContent(*component); err == nil {
+				component.BOMRef = r.rewrite(component.BOMRef, content)
+			}
+		}
+		r.used[component.BOMRef] = true
+
+		if component.Components != nil {
+			for i := range *component.Components {
+				rewrite(&(*component.Components)[i])
+			}
+		}
+	}
+
+	// sort first, so duplicate content gets the same refs in every run
+	if bom.Components != nil {
+		sortComponents(*bom.Components)
+	}
+
+	if bom.Metadata != nil && bom.Metadata.Component != nil {
+		rewrite(bom.Metadata.Component)
+	}
+	if bom.Components != nil {
+		for i := range *bom.Components {
+			rewrite(&(*bom.Components)[i])
+		}
+	}
+
+	// services (nested services first, their refs are part of the content of the parent)
+	var rewriteServices func(services *[]cdx.Service)
+	rewriteServices = func(services *[]cdx.Service) {
+		if services == nil {
+			return
+		}
+		for i := range *services {
+			sortProperties((*services)[i].Properties)
+			rewriteServices((*services)[i].Services)
+		}
+		rewriteSorted(r, *services, func(service *cdx.Service) *string { return &service.BOMRef })
+	}
+	rewriteServices(bom.Services)
+
+	// vulnerabilities
+	if bom.Vulnerabilities != nil {
+		vulnerabilities := *bom.Vulnerabilities
+		for i := range vulnerabilities {
+			sortProperties(vulnerabilities[i].Properties)
+			if vulnerabilities[i].Affects != nil {
+				affects := *vulnerabilities[i].Affects
+				for j := range affects {
+					affects[j].Ref = r.replace(affects[j].Ref)
+				}
+				sort.SliceStable(affects, func(i, j int) bool {
+					return affects[i].Ref < affects[j].Ref
+				})
+			}
+		}
+		rewriteSorted(r, vulnerabilities, func(vulnerability *cdx.Vulnerability) *string { return &vulnerability.BOMRef })
+	}
+
+	// compositions
+	if bom.Compositions != nil {
+		compositions := *bom.Compositions
+		for i := range compositions {
+			r.replaceAll(compositions[i].Assemblies)
+			r.replaceAll(compositions[i].Dependencies)
+			r.replaceAll(compositions[i].Vulnerabilities)
+		}
+		rewriteSorted(r, compositions, func(composition *cdx.Composition) *string { return &composition.BOMRef })
+	}
+
+	// annotations
+	if bom.Annotations != nil {
+		annotations := *bom.Annotations
+		for i := range annotations {
+			r.replaceAll(annotations[i].Subjects)
+		}
+		rewriteSorted(r, annotations, func(annotation *cdx.Annotation) *string { return &annotation.BOMRef })
+	}
+
+	// dependencies
+	if bom.Dependencies != nil {
+		dependencies := *bom.Dependencies
+		for i := range dependencies {
+			dependencies[i].Ref = r.replace(dependencies[i].Ref)
+			if dependencies[i].Dependencies != nil {
+				for j, ref := range *dependencies[i].Dependencies {
+					(*dependencies[i].Dependencies)[j] = r.replace(ref)
+				}
+				sort.Strings(*dependencies[i].Dependencies)
+			}
+		}
+
+		sort.SliceStable(dependencies, func(i, j int) bool {
+			return dependencies[i].Ref < dependencies[j].Ref
+		})
+	}
+
+	// components are sorted again, the refs used as a tie breaker have changed
+	if bom.Metadata != nil {
+		sortProperties(bom.Metadata.Properties)
+		if bom.Metadata.Component != nil {
+			sortComponentTree(bom.Metadata.Component)
+		}
+	}
+	if bom.Components != nil {
+		sortComponents(*bom.Components)
+		for i := range *bom.Components {
+			sortComponentTree(&(*bom.Components)[i])
+		}
+	}
+	sortProperties(bom.Properties)
+
+	// serial number
+	bom.SerialNumber = ""
+
+	var buf bytes.Buffer
+	if err := cdx.NewBOMEncoder(&buf, cdx.BOMFileFormatJSON).Encode(bom); err != nil {
+		return fmt.Errorf("failed to encode BOM: %w", err)
+	}
+	bom.SerialNumber = "urn:uuid:" + uuid.NewSHA1(reproducibleNamespace, buf.Bytes()).String()
+
+	return nil
+}
+
+// refRewriter replaces random BOMRefs with content derived refs and keeps track of the replaced refs
+type refRewriter struct {
+	refs map[string]string
+	used map[string]bool
+}
+
+// rewrite returns the content derived ref for the random ref (other refs are kept)
+func (r *refRewriter) rewrite(ref string, content []byte) string {
+	if isUUID(ref) {
+		newRef := uuid.NewSHA1(reproducibleNamespace, content).String()
+		for i := 2; r.used[newRef]; i++ {
+			newRef = uuid.NewSHA1(reproducibleNamespace, append(content, fmt.Sprintf("#%d", i)...)).String()
+		}
+		r.refs[ref] = newRef
+		ref = newRef
+	}
+	r.used[ref] = true
+	return ref
+}
+
+// replace returns the new ref for a rewritten ref
+func (r *refRewriter) replace(ref string) string {
+	if replacement, found := r.refs[ref]; found {
+		return replacement
+	}
+	return ref
+}
+
+// replaceAll replaces and sorts the refs
+func (r *refRewriter) replaceAll(refs *[]cdx.BOMReference) {
+	if refs == nil {
+		return
+	}
+	for i, ref := range *refs {
+		(*refs)[i] = cdx.BOMReference(r.replace(string(ref)))
+	}
+	slices.Sort(*refs)
+}
+
+// rewriteSorted sorts the items by their content and rewrites their random BOMRefs. The references in the items
+// must already be rewritten, the content only ignores the random BOMRef of the item itself.
+func rewriteSorted[T any](r *refRewriter, items []T, bomRef func(*T) *string) {
+	type entry struct {
+		item    T
+		content []byte
+	}
+
+	entries := make([]entry, len(items))
+	for i, item := range items {
+		if ref := bomRef(&item); isUUID(*ref) {
+			*ref = ""
+		}
+		content, _ := json.Marshal(item)
+		entries[i] = entry{item: items[i], content: content}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].content, entries[j].content) < 0
+	})
+
+	for i := range entries {
+		items[i] = entries[i].item
+		ref := bomRef(&items[i])
+		*ref = r.rewrite(*ref, entries[i].content)
+	}
+}
+
+// componentContent returns the content of the component, without random BOMRefs
+func componentContent(component cdx.Component) ([]byte, error) {
+	content, err := json.Marshal(component)
+	if err != nil {
+		return nil, err
+	}
+
+	return uuidPattern.ReplaceAll(content, nil), nil
+}
+
+// componentSortKey orders components by identity (BOMRef is the tie breaker)
+func componentSortKey(component cdx.Component) string {
+	return strings.Join([]string{component.Group, component.Name, component.Version, component.PackageURL, string(component.Type), component.BOMRef}, "\x00")
+}
+
+func sortComponents(components []cdx.Component) {
+	sort.SliceStable(components, func(i, j int) bool {
+		return componentSortKey(components[i]) < componentSortKey(components[j])
+	})
+}
+
+// sortComponentTree sorts the properties and nested components of the component
+func sortComponentTree(component *cdx.Component) {
+	sortProperties(component.Properties)
+
+	if component.Components != nil {
+		sortComponents(*component.Components)
+		for i := range *component.Components {
+			sortComponentTree(&(*component.Components)[i])
+		}
+	}
+}
+
+func sortProperties(properties *[]cdx.Property) {
+	if properties == nil {
+		return
+	}
+
+	sort.SliceStable(*properties, func(i, j int) bool {
+		a, b := (*properties)[i], (*properties)[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Value < b.Value
+	})
+}
+
+func isUUID(s string) bool {
+	return len(s) == 36 && uuidPattern.MatchString(s)
+}
@@ -0,0 +1,84 @@
+package cdxutil
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// randomBOM returns the same content with random BOMRefs, serial number and component order
+func randomBOM(reverse bool) *cdx.BOM {
+	rootRef, libRef, fileRef := uuid.NewString(), uuid.NewString(), uuid.NewString()
+
+	bom := cdx.NewBOM()
+	bom.SerialNumber = "urn:uuid:" + uuid.NewString()
+	bom.Metadata = &cdx.Metadata{
+		Timestamp: time.Now().Format(time.RFC3339),
+		Component: &cdx.Component{BOMRef: rootRef, Type: cdx.ComponentTypeApplication, Name: "app"},
+	}
+
+	components := []cdx.Component{
+		{
+			BOMRef: libRef, Type: cdx.ComponentTypeLibrary, Name: "lib", Version: "1.0",
+			Properties: &[]cdx.Property{{Name: "b", Value: "2"}, {Name: "a", Value: "1"}},
+		},
+		{BOMRef: "pkg:npm/left-pad@1.3.0", Type: cdx.ComponentTypeLibrary, Name: "left-pad", Version: "1.3.0", PackageURL: "pkg:npm/left-pad@1.3.0"},
+		{BOMRef: fileRef, Type: cdx.ComponentTypeFile, Name: "app.bin"},
+	}
+	dependencies := []cdx.Dependency{
+		{Ref: rootRef, Dependencies: &[]string{libRef, "pkg:npm/left-pad@1.3.0"}},
+		{Ref: libRef, Dependencies: &[]string{"pkg:npm/left-pad@1.3.0"}},
+	}
+
+	if reverse {
+		components[0], components[2] = components[2], components[0]
+		dependencies[0], dependencies[1] = dependencies[1], dependencies[0]
+		(*dependencies[1].Dependencies)[0], (*dependencies[1].Dependencies)[1] = (*dependencies[1].Dependencies)[1], (*dependencies[1].Dependencies)[0]
+	}
+
+	bom.Components = &components
+	bom.Dependencies = &dependencies
+
+	return bom
+}
+
+func TestMakeReproducible(t *testing.T) {
+	timestamp := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	encode := func(bom *cdx.BOM) string {
+		require.NoError(t, MakeReproducible(bom, timestamp))
+
+		var buf bytes.Buffer
+		require.NoError(t, cdx.NewBOMEncoder(&buf, cdx.BOMFileFormatJSON).Encode(bom))
+		return buf.String()
+	}
+
+	one := randomBOM(false)
+	assert.Equal(t, encode(one), encode(randomBOM(true)))
+
+	assert.Equal(t, "2025-01-02T03:04:05Z", one.Metadata.Timestamp)
+
+	// components are sorted and purl refs are kept
+	components := *one.Components
+	assert.Equal(t, []string{"app.bin", "left-pad", "lib"}, []string{components[0].Name, components[1].Name, components[2].Name})
+	assert.Equal(t, "pkg:npm/left-pad@1.3.0", components[1].BOMRef)
+	assert.Equal(t, []cdx.Property{{Name: "a", Value: "1"}, {Name: "b", Value: "2"}}, *components[2].Properties)
+
+	// dependencies point to the new refs
+	libRef := components[2].BOMRef
+	var refs []string
+	for _, dependency := range *one.Dependencies {
+		refs = append(refs, dependency.Ref)
+	}
+	assert.ElementsMatch(t, []string{one.Metadata.Component.BOMRef, libRef}, refs)
+
+	// without a timestamp
+	bom := randomBOM(false)
+	require.NoError(t, MakeReproducible(bom, time.Time{}))
+	assert.Empty(t, bom.Metadata.Timestamp)
+}
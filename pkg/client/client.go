@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"compress/gzip"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -127,7 +128,14 @@ func isAlreadyCompressed(filename string) bool {
 	return ext == ".gz" || ext == ".zip"
 }
 
+// ErrOffline is returned when a request to https://sbom.observer is attempted in offline mode
+var ErrOffline = errors.New("network access is disabled (--offline)")
+
 func (c *ObserverClient) uploadSource(url string, filename string, source FileSource, fields map[string]string) ([]byte, error) {
+	if types.Offline {
+		return nil, ErrOffline
+	}
+
 	// gzip the file unless it's already compressed
 	shouldGzip := !isAlreadyCompressed(filename)
 	if shouldGzip {
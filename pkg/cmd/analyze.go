@@ -13,6 +13,7 @@ import (
 
 	"github.com/aquasecurity/table"
 	"github.com/liamg/tml"
+	"github.com/sbom-observer/observer-cli/pkg/cdxutil"
 	"github.com/sbom-observer/observer-cli/pkg/client"
 	"github.com/sbom-observer/observer-cli/pkg/log"
 	"github.com/spf13/cobra"
@@ -50,9 +51,30 @@ func AnalyzeCommand(cmd *cobra.Command, args []string) {
 		log.Fatal("missing required argument <file>")
 	}
 
+	filename := args[0]
+
+	// the analyzer expects CycloneDX, upload a converted copy of other formats (unreadable files are uploaded as is)
+	if bom, input, err := cdxutil.ReadBOMFile(filename); err != nil {
+		log.Debug("failed to read SBOM, uploading as is", "file", filename, "err", err)
+	} else if !input.IsCycloneDX() {
+		converted, err := os.CreateTemp("", "observer-analyze-*.cdx.json")
+		if err != nil {
+			log.Fatal("failed to create temporary file", "err", err)
+		}
+		_ = converted.Close()
+		defer os.Remove(converted.Name())
+
+		if err := writeBOMFile(converted.Name(), bom, FormatCycloneDXJSON); err != nil {
+			log.Fatal("failed to convert SBOM", "file", filename, "err", err)
+		}
+
+		log.Debug("converted SBOM to CycloneDX", "file", filename, "format", input.Format, "compressed", input.Compressed)
+		filename = converted.Name()
+	}
+
 	c := client.NewObserverClient()
 
-	result, err := c.AnalyzeSBOM(args[0])
+	result, err := c.AnalyzeSBOM(filename)
 	if err != nil {
 		log.Error("error analyzing", "file", args[0], "err", err)
 		os.Exit(1)
@@ -9,7 +9,6 @@ import (
 	"strings"
 	"syscall"
 
-	cdx "github.com/CycloneDX/cyclonedx-go"
 	"github.com/sbom-observer/build-observer/pkg/traceopens"
 	"github.com/sbom-observer/observer-cli/pkg/builds"
 	"github.com/sbom-observer/observer-cli/pkg/log"
@@ -33,6 +32,7 @@ func init() {
 
 	buildCmd.Flags().StringP("output", "o", "build-observations.json", "Output filename for build observations")
 	buildCmd.Flags().StringP("sbom", "b", "", "Output filename for CycloneDX SBOM")
+	buildCmd.Flags().String("format", FormatCycloneDXJSON, formatFlagUsage+" (for --sbom)")
 	buildCmd.Flags().StringP("user", "u", "", "Run command as user")
 	buildCmd.Flags().StringP("config", "c", "", "Config file (i.e. observer.yaml)")
 	buildCmd.Flags().StringSliceP("exclude", "e", []string{".", "..", "*.so", "*.so.6", "*.so.2", "*.a", "/etc/ld.so.cache"}, "Exclude files from output")
@@ -46,6 +46,11 @@ func RunBuildCommand(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	flagFormat, _ := cmd.Flags().GetString("format")
+	if err := validateOutputFormat(flagFormat); err != nil {
+		log.Fatal("invalid --format", "err", err)
+	}
+
 	if len(args) == 0 {
 		fmt.Println("Please provide a command to trace as an argument (i.e. build-observer -u ci '/usr/bin/make').")
 		os.Exit(1)
@@ -128,22 +133,10 @@ func RunBuildCommand(cmd *cobra.Command, args []string) {
 			log.Fatalf("failed to scan build observations: %v", err)
 		}
 
-		// write bom to output file as json
 		log.Debugf("writing SBOM to %s", sbomFilename)
 
-		out, err := os.Create(sbomFilename)
-		if err != nil {
-			log.Fatal("failed to create output file", "filename", sbomFilename, "err", err)
-		}
-
-		// use cdx provided encoder
-		encoder := cdx.NewBOMEncoder(out, cdx.BOMFileFormatJSON)
-		encoder.SetPretty(true)
-		err = encoder.Encode(bom)
-		if err != nil {
+		if err := writeBOMFile(sbomFilename, bom, flagFormat); err != nil {
 			log.Fatal("failed to write output file", "filename", sbomFilename, "err", err)
 		}
-
-		_ = out.Close()
 	}
 }
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/sbom-observer/observer-cli/pkg/cache"
+	"github.com/sbom-observer/observer-cli/pkg/log"
+	"github.com/spf13/cobra"
+)
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the cache of scan results",
+	Long:  `Manage the cache of scan results. Scan results are reused by 'observer fs' when the files of a scan target haven't changed.`,
+}
+
+// cachePruneCmd represents the cache prune command
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove cached scan results",
+	Long:  `Remove cached scan results that haven't been used for --max-age (or all results with --all)`,
+	Args:  cobra.NoArgs,
+	Run:   RunCachePruneCommand,
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cachePruneCmd)
+
+	cachePruneCmd.Flags().Duration("max-age", 30*24*time.Hour, "Remove results that haven't been used for this long")
+	cachePruneCmd.Flags().Bool("all", false, "Remove all cached results")
+}
+
+func RunCachePruneCommand(cmd *cobra.Command, args []string) {
+	flagMaxAge, _ := cmd.Flags().GetDuration("max-age")
+	flagAll, _ := cmd.Flags().GetBool("all")
+
+	if flagAll {
+		flagMaxAge = 0
+	} else if flagMaxAge <= 0 {
+		log.Fatal("--max-age must be positive (use --all to remove all results)")
+	}
+
+	dir, err := cache.DefaultDir()
+	if err != nil {
+		log.Fatal("failed to find the user cache directory", "err", err)
+	}
+
+	removed, err := cache.New(dir).Prune(flagMaxAge)
+	if err != nil {
+		log.Fatal("failed to prune cache", "dir", dir, "err", err)
+	}
+
+	log.Printf("Removed %d cached scan result(s) from %s", removed, dir)
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/sbom-observer/observer-cli/pkg/cdxutil"
+	"github.com/sbom-observer/observer-cli/pkg/log"
+	"github.com/spf13/cobra"
+)
+
+// convertCmd represents the convert command
+var convertCmd = &cobra.Command{
+	Use:   "convert [flags] <sbom>",
+	Short: "Convert an SBOM to another format or CycloneDX spec version",
+	Long: `Convert an SBOM between CycloneDX JSON/XML and SPDX 2.3 JSON/tag-value, and upgrade or downgrade
+the CycloneDX spec version (1.4 - 1.6).
+
+The input format is auto-detected from the content (optionally gzip compressed). The output format
+defaults to the input format.
+
+Downgrading to an older spec version drops fields that the version can not represent. The dropped
+fields are reported as warnings, use --fail-on-drop to fail instead.`,
+	Example: `observer sbom convert --format cyclonedx-json --spec-version 1.4 -o sbom-1.4.cdx.json sbom.cdx.json
+observer sbom convert --format spdx-json -o sbom.spdx.json sbom.cdx.json`,
+	Args: cobra.ExactArgs(1),
+	Run:  RunConvertCommand,
+}
+
+func init() {
+	convertCmd.Flags().StringP("output", "o", "", "Output file path (default: stdout)")
+	convertCmd.Flags().Bool("pretty", true, "Pretty print output")
+	convertCmd.Flags().String("format", "", formatFlagUsage+" (default: format of the input)")
+	convertCmd.Flags().String("spec-version", "", "CycloneDX spec version of the output [1.4,1.5,1.6] (default: spec version of the input)")
+	convertCmd.Flags().Bool("fail-on-drop", false, "Fail if fields would be dropped when downgrading the spec version")
+}
+
+func RunConvertCommand(cmd *cobra.Command, args []string) {
+	flagOutput, _ := cmd.Flags().GetString("output")
+	flagPretty, _ := cmd.Flags().GetBool("pretty")
+	flagFormat, _ := cmd.Flags().GetString("format")
+	flagSpecVersion, _ := cmd.Flags().GetString("spec-version")
+	flagFailOnDrop, _ := cmd.Flags().GetBool("fail-on-drop")
+
+	if flagFormat != "" {
+		if err := validateOutputFormat(flagFormat); err != nil {
+			log.Fatal("invalid --format", "err", err)
+		}
+	}
+
+	bom, input, err := cdxutil.ReadBOMFile(args[0])
+	if err != nil {
+		log.Fatal("failed to read SBOM", "file", args[0], "err", err)
+	}
+
+	format := flagFormat
+	if format == "" {
+		format = string(input.Format)
+	}
+
+	var specVersion cdx.SpecVersion
+	if flagSpecVersion != "" {
+		if format != FormatCycloneDXJSON && format != FormatCycloneDXXML {
+			log.Fatal("--spec-version is only supported for CycloneDX output", "format", format)
+		}
+
+		specVersion, err = cdxutil.ParseSpecVersion(flagSpecVersion)
+		if err != nil {
+			log.Fatal("invalid --spec-version", "err", err)
+		}
+
+		dropped, err := cdxutil.DroppedFields(bom, specVersion)
+		if err != nil {
+			log.Fatal("failed to convert SBOM", "err", err)
+		}
+
+		for _, field := range dropped {
+			log.Warn(fmt.Sprintf("field dropped when converting to CycloneDX %s", specVersion), "field", field.Path, "count", field.Count)
+		}
+
+		if len(dropped) > 0 && flagFailOnDrop {
+			log.Fatal(fmt.Sprintf("converting from CycloneDX %s to %s would drop %d fields", bom.SpecVersion, specVersion, len(dropped)))
+		}
+	}
+
+	log.Debug("converting SBOM", "file", args[0], "from", input.Format, "to", format, "specVersion", flagSpecVersion)
+
+	out := os.Stdout
+	if flagOutput != "" {
+		out, err = os.Create(flagOutput)
+		if err != nil {
+			log.Fatal("failed to create output file", "filename", flagOutput, "err", err)
+		}
+		defer out.Close()
+	}
+
+	if err := encodeBOMVersion(out, bom, format, specVersion, flagPretty); err != nil {
+		log.Fatal("failed to write SBOM", "format", format, "err", err)
+	}
+
+	if flagOutput != "" {
+		log.Printf("Converted SBOM written to: %s", flagOutput)
+	}
+}
@@ -21,7 +21,7 @@ import (
 var diffCmd = &cobra.Command{
 	Use:   "diff",
 	Short: "Compare two SBOMs and show the differences",
-	Long:  `Compare two SBOMs and show the differences. Only JSON CycloneDX SBOMs are currently supported.`,
+	Long:  `Compare two SBOMs and show the differences. SBOMs can be CycloneDX JSON/XML or SPDX 2.x JSON/tag-value, optionally gzip compressed.`,
 	Run:   RunDiffCommand,
 	Args:  cobra.MinimumNArgs(2),
 }
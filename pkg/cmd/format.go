@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/sbom-observer/observer-cli/pkg/spdxutil"
+)
+
+// output formats for --format
+const (
+	FormatCycloneDXJSON = "cyclonedx-json"
+	FormatCycloneDXXML  = "cyclonedx-xml"
+	FormatSPDXJSON      = "spdx-json"
+	FormatSPDXTagValue  = "spdx-tv"
+	FormatSPDX3JSON     = "spdx3-json"
+)
+
+var outputFormats = []string{FormatCycloneDXJSON, FormatCycloneDXXML, FormatSPDXJSON, FormatSPDXTagValue, FormatSPDX3JSON}
+
+// formatFlagUsage is the usage text of the --format flag
+var formatFlagUsage = fmt.Sprintf("Output format [%s]", strings.Join(outputFormats, ","))
+
+func validateOutputFormat(format string) error {
+	for _, f := range outputFormats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown output format '%s' (available: %s)", format, strings.Join(outputFormats, ", "))
+}
+
+// outputFileExtension returns the conventional file extension for the format
+func outputFileExtension(format string) string {
+	switch format {
+	case FormatCycloneDXXML:
+		return ".cdx.xml"
+	case FormatSPDXJSON:
+		return ".spdx.json"
+	case FormatSPDXTagValue:
+		return ".spdx"
+	case FormatSPDX3JSON:
+		return ".spdx3.json"
+	default:
+		return ".cdx.json"
+	}
+}
+
+// encodeBOM writes the BOM to w in the output format
+func encodeBOM(w io.Writer, bom *cdx.BOM, format string, pretty bool) error {
+	return encodeBOMVersion(w, bom, format, 0, pretty)
+}
+
+// encodeBOMVersion writes the BOM to w in the output format. CycloneDX output is converted to specVersion (0 keeps
+// the spec version of the BOM).
+func encodeBOMVersion(w io.Writer, bom *cdx.BOM, format string, specVersion cdx.SpecVersion, pretty bool) error {
+	switch format {
+	case FormatSPDXJSON:
+		return spdxutil.EncodeJSON(w, bom, pretty)
+	case FormatSPDXTagValue:
+		return spdxutil.EncodeTagValue(w, bom)
+	case FormatSPDX3JSON:
+		return spdxutil.EncodeSPDX3JSON(w, bom, pretty)
+	}
+
+	fileFormat := cdx.BOMFileFormatJSON
+	if format == FormatCycloneDXXML {
+		fileFormat = cdx.BOMFileFormatXML
+	}
+
+	encoder := cdx.NewBOMEncoder(w, fileFormat)
+	encoder.SetPretty(pretty)
+
+	// For JSON, disable HTML escaping to make the output more readable
+	if fileFormat == cdx.BOMFileFormatJSON {
+		encoder.SetEscapeHTML(false)
+	}
+
+	if specVersion != 0 {
+		return encoder.EncodeVersion(bom, specVersion)
+	}
+
+	return encoder.Encode(bom)
+}
+
+// writeBOMFile writes the BOM to a file in the output format
+func writeBOMFile(filename string, bom *cdx.BOM, format string) error {
+	out, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if err := encodeBOM(out, bom, format, true); err != nil {
+		return err
+	}
+
+	return out.Close()
+}
@@ -2,20 +2,30 @@ package cmd
 
 import (
 	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"github.com/sbom-observer/observer-cli/pkg/tasks"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strings"
+	"syscall"
 	"text/template"
 	"time"
 
 	cdx "github.com/CycloneDX/cyclonedx-go"
 	"github.com/sbom-observer/observer-cli/pkg/log"
+	"github.com/sbom-observer/observer-cli/pkg/scanner"
+	"github.com/sbom-observer/observer-cli/pkg/types"
 	"github.com/spf13/cobra"
 )
 
+// ExitCodePartialResults is the exit code used when SBOMs were written but one or more scanners failed (--keep-going)
+const ExitCodePartialResults = 3
+
 // filesystemCmd represents the repo command
 var filesystemCmd = &cobra.Command{
 	Aliases: []string{"filesystem", "repo"},
@@ -34,6 +44,16 @@ func init() {
 
 	filesystemCmd.Flags().BoolP("recursive", "r", false, "Recursively scan subdirectories (short for --depth=1)")
 	filesystemCmd.Flags().Uint("depth", 1, "Recursively scan subdirectories down to max tree depth (e.g. monorepos)")
+	filesystemCmd.Flags().Int("parallel", 1, "Number of scan targets to scan in parallel")
+	filesystemCmd.Flags().Bool("include-dev", false, "Include development and test dependencies (with scope 'excluded')")
+	filesystemCmd.Flags().Bool("keep-going", false, fmt.Sprintf("Continue when a scanner fails, write partial SBOMs and exit with code %d", ExitCodePartialResults))
+	filesystemCmd.Flags().Duration("timeout", 0, "Stop the scan after this long, e.g. 30m (default: no limit)")
+	filesystemCmd.Flags().StringArray("scanner-timeout", []string{}, "Timeout for each scanner run, e.g. 5m, or for a specific scanner, e.g. trivy=10m. Targets where a scanner times out are reported as incomplete")
+	filesystemCmd.Flags().Bool("no-cache", false, "Don't reuse or store scan results (see 'observer cache')")
+	filesystemCmd.Flags().Bool("reproducible", false, "Create the same output for the same content (content derived BOMRefs and serial number, timestamp from SOURCE_DATE_EPOCH or the git commit time, sorted components)")
+	filesystemCmd.Flags().Bool("allow-external-scanners", false, "Run the external scanners configured in observer.yml in the scanned directory (not in subdirectories) and --external-scanners-config. External scanners run arbitrary commands, only use with trusted code")
+	filesystemCmd.Flags().String("external-scanners-config", "", "Read external scanners from this config file (observer.yml format, requires --allow-external-scanners)")
+	filesystemCmd.Flags().StringArray("scanners", []string{}, fmt.Sprintf("Override the scanners for an ecosystem, e.g. npm=scalibr or npm=moduleName,trivy (available: %s)", strings.Join(scanner.ScannerNames(), ",")))
 
 	// artifacts
 	filesystemCmd.Flags().StringArrayP("artifacts", "a", []string{}, "Artifacts that makes up the software described by the SBOM")
@@ -43,8 +63,12 @@ func init() {
 
 	// output
 	filesystemCmd.Flags().StringP("output", "o", "", "Output filename or directory for the results (default: stdout)")
+	filesystemCmd.Flags().String("format", FormatCycloneDXJSON, formatFlagUsage)
 	filesystemCmd.Flags().BoolP("merge", "m", true, "Merge the results into a single BOM")
-	//repoCmd.Flags().Bool("super", false, "Merge the results with a super BOM")
+	filesystemCmd.Flags().String("report", "", "Write a JSON report of the scan (targets, files, scanners, durations and unresolved files) to this file")
+	filesystemCmd.Flags().Bool("summary", false, "Print a summary table of the scan to stderr")
+	filesystemCmd.Flags().Bool("link", false, "Write one SBOM per target and a product SBOM (the root target) that references them with CycloneDX BOM-Links (use with --output <dir>)")
+	filesystemCmd.Flags().Bool("super", false, "Nest each target (and its components) under the root component when merging, instead of adding it as a dependency")
 }
 
 func RunFilesystemCommand(cmd *cobra.Command, args []string) {
@@ -55,21 +79,171 @@ func RunFilesystemCommand(cmd *cobra.Command, args []string) {
 	flagDepth, _ := cmd.Flags().GetUint("depth")
 
 	flagOutput, _ := cmd.Flags().GetString("output")
+	flagFormat, _ := cmd.Flags().GetString("format")
 	flagMerge, _ := cmd.Flags().GetBool("merge")
 	flagArtifacts, _ := cmd.Flags().GetStringArray("artifacts")
 	flagVendorPaths, _ := cmd.Flags().GetStringArray("vendor")
+	flagParallel, _ := cmd.Flags().GetInt("parallel")
+	flagKeepGoing, _ := cmd.Flags().GetBool("keep-going")
+	flagIncludeDev, _ := cmd.Flags().GetBool("include-dev")
+	flagNoCache, _ := cmd.Flags().GetBool("no-cache")
+	flagReport, _ := cmd.Flags().GetString("report")
+	flagSummary, _ := cmd.Flags().GetBool("summary")
+	flagReproducible, _ := cmd.Flags().GetBool("reproducible")
+	flagSuper, _ := cmd.Flags().GetBool("super")
+	flagLink, _ := cmd.Flags().GetBool("link")
 	// TODO: load config from args[0]
 
-	RunFilesystemScanner(args, flagVendorPaths, flagDepth, flagOutput, flagMerge, flagArtifacts, flagUpload, flagSilent)
+	flagTimeout, _ := cmd.Flags().GetDuration("timeout")
+
+	flagScannerTimeouts, _ := cmd.Flags().GetStringArray("scanner-timeout")
+	scannerTimeout, scannerTimeouts, err := parseScannerTimeouts(flagScannerTimeouts)
+	if err != nil {
+		log.Fatal("invalid --scanner-timeout", "err", err)
+	}
+
+	if err := validateOutputFormat(flagFormat); err != nil {
+		log.Fatal("invalid --format", "err", err)
+	}
+
+	if flagLink && (!flagMerge || flagSuper) {
+		log.Fatal("--link can not be combined with --merge=false or --super")
+	}
+
+	flagScanners, _ := cmd.Flags().GetStringArray("scanners")
+	scannerOverrides, err := parseScannerOverrides(flagScanners)
+	if err != nil {
+		log.Fatal("invalid --scanners", "err", err)
+	}
+
+	flagAllowExternalScanners, _ := cmd.Flags().GetBool("allow-external-scanners")
+	flagExternalScannersConfig, _ := cmd.Flags().GetString("external-scanners-config")
+	var externalScanners []types.ExternalScannerConfig
+	if flagExternalScannersConfig != "" {
+		if !flagAllowExternalScanners {
+			log.Fatal("--external-scanners-config requires --allow-external-scanners")
+		}
+
+		externalScanners, err = scanner.ReadExternalScanners(flagExternalScannersConfig)
+		if err != nil {
+			log.Fatal("invalid --external-scanners-config", "err", err)
+		}
+	}
+
+	options := tasks.FilesystemOptions{
+		Depth:      flagDepth,
+		Merge:      flagMerge,
+		Artifacts:  flagArtifacts,
+		Parallel:   flagParallel,
+		IncludeDev: flagIncludeDev,
+		KeepGoing:  flagKeepGoing,
+		Scanners:   scannerOverrides,
+		NoCache:    flagNoCache,
+
+		Reproducible: flagReproducible,
+		Super:        flagSuper,
+		Link:         flagLink,
+
+		ScannerTimeout:  scannerTimeout,
+		ScannerTimeouts: scannerTimeouts,
+
+		AllowExternalScanners: flagAllowExternalScanners,
+		ExternalScanners:      externalScanners,
+	}
+
+	// stop scanners (and child processes) on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if flagTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, flagTimeout)
+		defer cancel()
+	}
+
+	RunFilesystemScanner(ctx, args, flagVendorPaths, options, flagOutput, flagFormat, flagReport, flagSummary, flagUpload, flagSilent)
+}
+
+// parseScannerOverrides parses scanner chain overrides in the form ecosystem=scanner[,scanner...]
+func parseScannerOverrides(values []string) (map[string][]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	overrides := map[string][]string{}
+	for _, value := range values {
+		ecosystem, names, found := strings.Cut(value, "=")
+		if !found || ecosystem == "" {
+			return nil, fmt.Errorf("expected ecosystem=scanner[,scanner...], got '%s'", value)
+		}
+
+		overrides[ecosystem] = nil
+		for _, name := range strings.Split(names, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				overrides[ecosystem] = append(overrides[ecosystem], name)
+			}
+		}
+	}
+
+	return overrides, scanner.ValidateScannerOverrides(overrides)
 }
 
-func RunFilesystemScanner(paths []string, vendorPaths []string, flagDepth uint, flagOutput string, flagMerge bool, flagArtifacts []string, flagUpload bool, flagSilent bool) {
+// parseScannerTimeouts parses timeouts in the form duration (for all scanners) or scanner=duration
+func parseScannerTimeouts(values []string) (time.Duration, map[string]time.Duration, error) {
+	var timeout time.Duration
+	timeouts := map[string]time.Duration{}
+
+	for _, value := range values {
+		name, duration, found := strings.Cut(value, "=")
+		if !found {
+			name, duration = "", value
+		}
+
+		d, err := time.ParseDuration(duration)
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid timeout '%s': %w", value, err)
+		}
+
+		if name == "" {
+			timeout = d
+			continue
+		}
+
+		if !slices.Contains(scanner.ScannerNames(), name) && !strings.HasPrefix(name, "external:") {
+			return 0, nil, fmt.Errorf("unknown scanner '%s' (available: %s)", name, strings.Join(scanner.ScannerNames(), ", "))
+		}
+
+		timeouts[name] = d
+	}
+
+	return timeout, timeouts, nil
+}
+
+func RunFilesystemScanner(ctx context.Context, paths []string, vendorPaths []string, options tasks.FilesystemOptions, flagOutput string, flagFormat string, flagReport string, flagSummary bool, flagUpload bool, flagSilent bool) {
 	if len(paths) < 1 {
 		log.Fatal("the path to a source repository is required as an argument")
 	}
 
-	results, err := tasks.CreateFilesystemSBOM(paths, vendorPaths, flagDepth, flagMerge, flagArtifacts)
-	if err != nil {
+	results, report, err := tasks.CreateFilesystemSBOM(ctx, paths, vendorPaths, options)
+	partial := errors.Is(err, tasks.ErrPartialResults)
+
+	// write the report before handling errors, it describes failed scans as well
+	if report != nil {
+		if flagReport != "" {
+			log.Debugf("writing scan report to %s", flagReport)
+			if reportErr := report.WriteJSON(flagReport); reportErr != nil {
+				log.Error("failed to write scan report", "filename", flagReport, "err", reportErr)
+			}
+		}
+
+		if flagSummary {
+			_ = report.WriteSummary(os.Stderr)
+		}
+	}
+	if err != nil && !partial {
+		if ctx.Err() != nil {
+			log.Fatal("scan was stopped", "err", err)
+		}
 		log.Fatal("failed to create filesystem SBOM", "err", err)
 	}
 
@@ -82,21 +256,10 @@ func RunFilesystemScanner(paths []string, vendorPaths []string, flagDepth uint,
 
 			log.Debugf("writing SBOM to %s", outputFilename)
 
-			out, err := os.Create(flagOutput)
-			if err != nil {
-				log.Fatal("failed to create output file", "filename", outputFilename, "err", err)
-			}
-
-			// use cdx provided encoder
-			encoder := cdx.NewBOMEncoder(out, cdx.BOMFileFormatJSON)
-			encoder.SetPretty(true)
-			err = encoder.Encode(results[0])
+			err := writeBOMFile(outputFilename, results[0], flagFormat)
 			if err != nil {
 				log.Fatal("failed to write output file", "filename", outputFilename, "err", err)
 			}
-
-			_ = out.Close()
-
 		}
 
 		if len(results) > 1 {
@@ -104,18 +267,39 @@ func RunFilesystemScanner(paths []string, vendorPaths []string, flagDepth uint,
 				log.Fatalf("output destination %s is not a directory. Did you mean --merge?", flagOutput)
 			}
 
+			written := map[string]bool{}
 			for _, merged := range results {
 
-				outputTemplate := "sbom-{{.Name}}-{{.Module}}-{{.Timestamp}}.cdx.json"
+				outputTemplate := "sbom-{{.Name}}-{{.Module}}-{{.Timestamp}}" + outputFileExtension(flagFormat)
 				// if target.Config.OutputTemplate != "" {
 				// outputTemplate = target.Config.OutputTemplate
 				// }
 
-				outputFilename, err := generateFilename(outputTemplate, "", merged.Metadata.Component)
+				// reproducible BOMs are named after their (source date) timestamp
+				timestamp := time.Now()
+				if options.Reproducible {
+					if t, err := time.Parse(time.RFC3339, merged.Metadata.Timestamp); err == nil {
+						timestamp = t
+					}
+				}
+
+				// linked BOMs are named after their serial number, which is derived from the target path
+				module := ""
+				if options.Link {
+					outputTemplate = "sbom-{{.Name}}-{{.Module}}" + outputFileExtension(flagFormat)
+					module = strings.TrimPrefix(merged.SerialNumber, "urn:uuid:")
+				}
+
+				outputFilename, err := generateFilename(outputTemplate, module, merged.Metadata.Component, timestamp)
 				if err != nil {
 					log.Fatal("failed to generate output filename", "err", err)
 				}
 
+				if options.Link && written[outputFilename] {
+					log.Fatal("two linked SBOMs have the same output filename", "filename", outputFilename)
+				}
+				written[outputFilename] = true
+
 				outputFilename, err = filepath.Abs(filepath.Join(flagOutput, outputFilename))
 				if err != nil {
 					log.Fatal("failed to get absolute path for output filename", "err", err)
@@ -123,20 +307,10 @@ func RunFilesystemScanner(paths []string, vendorPaths []string, flagDepth uint,
 
 				log.Debugf("writing SBOM to %s", outputFilename)
 
-				out, err := os.Create(outputFilename)
-				if err != nil {
-					log.Fatal("failed to create output file", "filename", outputFilename, "err", err)
-				}
-
-				// use cdx provided encoder
-				encoder := cdx.NewBOMEncoder(out, cdx.BOMFileFormatJSON)
-				encoder.SetPretty(true)
-				err = encoder.Encode(merged)
+				err = writeBOMFile(outputFilename, merged, flagFormat)
 				if err != nil {
 					log.Fatal("failed to write output file", "filename", outputFilename, "err", err)
 				}
-
-				_ = out.Close()
 			}
 		}
 	}
@@ -180,20 +354,25 @@ func RunFilesystemScanner(paths []string, vendorPaths []string, flagDepth uint,
 	// output to stdout
 	if flagOutput == "" {
 		for _, merged := range results {
-			enc := json.NewEncoder(os.Stdout)
-			enc.SetIndent("", "  ")
-			_ = enc.Encode(merged)
+			if err := encodeBOM(os.Stdout, merged, flagFormat, true); err != nil {
+				log.Fatal("failed to write SBOM", "err", err)
+			}
 		}
 	}
+
+	if partial {
+		log.Error("SBOM is incomplete, one or more scanners failed", "err", err)
+		os.Exit(ExitCodePartialResults)
+	}
 }
 
-func generateFilename(templateString string, module string, component *cdx.Component) (string, error) {
+func generateFilename(templateString string, module string, component *cdx.Component, createdAt time.Time) (string, error) {
 	t, err := template.New("filename").Parse(templateString)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse filename template: %w", err)
 	}
 
-	timestamp := time.Now().Format("20060102-150405")
+	timestamp := createdAt.Format("20060102-150405")
 
 	var buf bytes.Buffer
 	err = t.Execute(&buf, struct {
@@ -7,9 +7,11 @@ import (
 	"path"
 	"time"
 
+	"github.com/sbom-observer/observer-cli/pkg/cdxutil"
 	"github.com/sbom-observer/observer-cli/pkg/client"
 	"github.com/sbom-observer/observer-cli/pkg/execx"
 	"github.com/sbom-observer/observer-cli/pkg/log"
+	"github.com/sbom-observer/observer-cli/pkg/types"
 	"github.com/spf13/cobra"
 )
 
@@ -36,6 +38,7 @@ func init() {
 
 	// output
 	imageCmd.Flags().StringP("output", "o", "", "Output file for the results (default: stdout)")
+	imageCmd.Flags().String("format", FormatCycloneDXJSON, formatFlagUsage)
 }
 
 func ImageCommand(cmd *cobra.Command, args []string) {
@@ -46,6 +49,11 @@ func ImageCommand(cmd *cobra.Command, args []string) {
 
 	scannerEngine, _ := cmd.Flags().GetString("scanner")
 	flagOutput, _ := cmd.Flags().GetString("output")
+	flagFormat, _ := cmd.Flags().GetString("format")
+
+	if err := validateOutputFormat(flagFormat); err != nil {
+		log.Fatal("invalid --format", "err", err)
+	}
 
 	if len(args) != 1 {
 		log.Fatal("an container image reference is required as an argument")
@@ -54,20 +62,23 @@ func ImageCommand(cmd *cobra.Command, args []string) {
 	// TODO: validate output (parse, sanity check)
 
 	// create output filename
+	// the scanners create CycloneDX JSON, other formats are converted from a temporary file
 	output := path.Join(os.TempDir(), fmt.Sprintf("sbom-%s.cdx.json", time.Now().Format("20060102-150405")))
-	if flagOutput != "" {
+	if flagOutput != "" && flagFormat == FormatCycloneDXJSON {
 		output = flagOutput
 	}
 
 	// update Trivy Java DB
-	err := execx.TrivyUpdateJavaDb()
-	if err != nil {
-		log.Debug("failed to update Trivy Java DB ", "err", err)
+	if !types.Offline {
+		err := execx.TrivyUpdateJavaDb()
+		if err != nil {
+			log.Debug("failed to update Trivy Java DB ", "err", err)
+		}
 	}
 
 	log.Printf("Creating SBOM for '%s'", args[0])
 
-	err = CreateImageSbom(scannerEngine, args[0], output)
+	err := CreateImageSbom(scannerEngine, args[0], output)
 	if err != nil {
 		os.Exit(1)
 	}
@@ -96,6 +107,26 @@ func ImageCommand(cmd *cobra.Command, args []string) {
 		log.Printf("Uploaded %d BOM(s)", len(filesToUpload))
 	}
 
+	if flagFormat != FormatCycloneDXJSON {
+		defer os.Remove(output)
+
+		bom, err := cdxutil.ParseCycloneDX(output)
+		if err != nil {
+			log.Fatal("failed to parse SBOM", "file", output, "err", err)
+		}
+
+		if flagOutput != "" {
+			err = writeBOMFile(flagOutput, bom, flagFormat)
+		} else if !flagUpload {
+			err = encodeBOM(os.Stdout, bom, flagFormat, true)
+		}
+		if err != nil {
+			log.Fatal("failed to write SBOM", "format", flagFormat, "err", err)
+		}
+
+		return
+	}
+
 	if !flagUpload && flagOutput == "" {
 		f, err := os.Open(output)
 		if err != nil {
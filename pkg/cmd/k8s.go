@@ -12,6 +12,7 @@ import (
 	"github.com/sbom-observer/observer-cli/pkg/execx"
 	"github.com/sbom-observer/observer-cli/pkg/k8s"
 	"github.com/sbom-observer/observer-cli/pkg/log"
+	"github.com/sbom-observer/observer-cli/pkg/types"
 	"github.com/spf13/cobra"
 )
 
@@ -184,9 +185,11 @@ func createImageSboms(engine string, images map[string]k8s.Image, tempDir string
 	var sboms []string
 
 	// update Trivy Java DB
-	err := execx.TrivyUpdateJavaDb()
-	if err != nil {
-		return nil, err
+	if !types.Offline {
+		err := execx.TrivyUpdateJavaDb()
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// create sboms
@@ -236,7 +239,16 @@ func CreateImageSbom(engine string, image string, output string) error {
 }
 
 func CreateImageSbomTrivy(image string, output string) error {
-	output, err := execx.Trivy("image", "--skip-db-update", "--skip-java-db-update", "--format", "cyclonedx", "--output", output, image)
+	args := []string{"image", "--skip-db-update", "--skip-java-db-update", "--format", "cyclonedx", "--output", output}
+
+	// only use local images (never pull from a registry) and don't issue API requests
+	if types.Offline {
+		args = append(args, "--offline-scan", "--image-src", "docker,containerd,podman")
+	}
+
+	args = append(args, image)
+
+	output, err := execx.Trivy(args...)
 	if err != nil {
 		var extCmdErr *execx.ExternalCommandError
 		if errors.As(err, &extCmdErr) {
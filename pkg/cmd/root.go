@@ -19,6 +19,12 @@ var rootCmd = &cobra.Command{
 		if debug, _ := cmd.Flags().GetBool("debug"); debug {
 			log.Logger.SetLevel(log.DebugLevel)
 		}
+
+		// disable network access
+		if offline, _ := cmd.Flags().GetBool("offline"); offline {
+			log.Debug("running in offline mode")
+			types.Offline = true
+		}
 	},
 }
 
@@ -35,6 +41,7 @@ func Execute() {
 func init() {
 	rootCmd.PersistentFlags().Bool("debug", false, "Enable debug logging (implies silent mode)")
 	rootCmd.PersistentFlags().Bool("silent", false, "Silent mode (no progress bars)")
+	rootCmd.PersistentFlags().Bool("offline", false, "Offline mode (never access registries or remote APIs, for air-gapped environments)")
 }
 
 func NotImplemented(cmd *cobra.Command, args []string) {
@@ -2,11 +2,17 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/sbom-observer/observer-cli/pkg/cdxutil"
 	"github.com/sbom-observer/observer-cli/pkg/log"
 	"github.com/sbom-observer/observer-cli/pkg/mergex"
 	"github.com/spf13/cobra"
@@ -16,7 +22,7 @@ import (
 var sbomCmd = &cobra.Command{
 	Use:   "sbom",
 	Short: "SBOM utilities for CycloneDX Software Bill of Materials",
-	Long:  `SBOM utilities for CycloneDX Software Bill of Materials including merging multiple BOMs`,
+	Long:  `SBOM utilities for CycloneDX Software Bill of Materials including merging multiple BOMs and converting between formats`,
 }
 
 // mergeCmd represents the merge command
@@ -28,11 +34,40 @@ The merge follows these rules:
 - First input takes precedence for non-empty simple fields
 - Array fields are combined with proper deduplication where applicable
 - Entities with the same BOMRef are merged intelligently
+- Components with the same normalized purl (or name+group+version if there is no purl) are
+  merged, and references to them are rewritten to the BOMRef of the first one
 - Original input files are never modified
 
-Supported formats: JSON and XML (auto-detected by file extension)
-Output format matches the first input file format unless overridden.`,
-	Args: cobra.MinimumNArgs(2),
+Use --report-conflicts to list fields where the inputs disagree (e.g. version, licenses or supplier
+of the same component) and --strict to fail the merge if there are any.
+
+The default strategies (prefer-non-empty for simple fields, union for arrays) can be changed per
+component field and section with --strategy key=strategy (repeatable), where key is a field
+(e.g. licenses), a section (metadata.component or components) or both (components.supplier).
+Strategies: first-wins, last-wins, union, prefer-non-empty and prefer-highest-confidence.
+Use --prefer to merge a curated BOM first, regardless of the order of the arguments:
+
+  observer sbom merge --prefer curated.json --strategy supplier=first-wins --strategy licenses=union \
+    scan1.json scan2.json curated.json
+
+Use --super to create a "super BOM": every input after the first one is nested, with its components,
+under the root component of the first input (e.g. a product BOM) and keeps its own dependency graph.
+
+Use --repair to check the dependency graph of the merged BOM and repair it: duplicate BOMRefs are
+merged or renamed, refs to missing components are re-pointed (same purl) or removed and components
+that the root component does not reach are attached to it. The issues, including cycles, are listed
+on stderr.
+
+Use --resolve-links DIR to inline the BOMs that are referenced with CycloneDX BOM-Links
+(urn:cdx:serial/version#ref, e.g. from 'observer fs --link') from the BOM files in DIR:
+
+  observer sbom merge --resolve-links sboms/ sboms/product.cdx.json
+
+Supported input formats: CycloneDX JSON/XML and SPDX 2.x JSON/tag-value, optionally
+gzip compressed (auto-detected from the content).
+Output format matches the first input file format unless overridden with --format
+(CycloneDX JSON/XML or SPDX 2.3 JSON/tag-value).`,
+	Args: cobra.MinimumNArgs(1),
 	Run:  runMerge,
 }
 
@@ -40,30 +75,62 @@ func init() {
 	rootCmd.AddCommand(sbomCmd)
 	sbomCmd.AddCommand(mergeCmd)
 	sbomCmd.AddCommand(diffCmd)
+	sbomCmd.AddCommand(convertCmd)
 
 	mergeCmd.Flags().StringP("output", "o", "", "Output file path (default: stdout)")
 	mergeCmd.Flags().Bool("pretty", true, "Pretty print output")
+	mergeCmd.Flags().String("format", "", formatFlagUsage+" (default: format of the first input)")
+	mergeCmd.Flags().Bool("report-conflicts", false, "List fields where the inputs have different values for the same component (to stderr)")
+	mergeCmd.Flags().Bool("strict", false, "Fail if the inputs have conflicting values for the same component")
+	mergeCmd.Flags().StringArray("strategy", nil, "Merge strategy for a component field or section as key=strategy (e.g. supplier=first-wins, repeatable)")
+	mergeCmd.Flags().Bool("super", false, "Nest every input after the first one (with its components) under the root component of the first input")
+	mergeCmd.Flags().String("resolve-links", "", "Directory with the BOMs referenced by BOM-Links in the inputs, linked BOMs are merged into the output")
+	mergeCmd.Flags().Bool("repair", false, "Repair the dependency graph of the output (duplicate BOMRefs, dangling refs and orphans) and list the issues (to stderr)")
+	mergeCmd.Flags().String("prefer", "", "Input file to merge first (its values win with first-wins and prefer-non-empty)")
 }
 
 func runMerge(cmd *cobra.Command, args []string) {
 	outputPath, _ := cmd.Flags().GetString("output")
 	prettyPrint, _ := cmd.Flags().GetBool("pretty")
+	flagFormat, _ := cmd.Flags().GetString("format")
+	flagReportConflicts, _ := cmd.Flags().GetBool("report-conflicts")
+	flagStrict, _ := cmd.Flags().GetBool("strict")
+	flagStrategies, _ := cmd.Flags().GetStringArray("strategy")
+	flagPrefer, _ := cmd.Flags().GetString("prefer")
+	flagSuper, _ := cmd.Flags().GetBool("super")
+	flagResolveLinks, _ := cmd.Flags().GetString("resolve-links")
+	flagRepair, _ := cmd.Flags().GetBool("repair")
+
+	if len(args) < 2 && flagResolveLinks == "" {
+		log.Fatal("at least two input files are required (or one with --resolve-links)")
+	}
+
+	if flagFormat != "" {
+		if err := validateOutputFormat(flagFormat); err != nil {
+			log.Fatal("invalid --format", "err", err)
+		}
+	}
+
+	strategies, err := mergex.ParseStrategies(flagStrategies)
+	if err != nil {
+		log.Fatal("invalid --strategy", "err", err)
+	}
 
 	// Parse all input BOMs
 	var boms []*cyclonedx.BOM
-	var outputFormat cyclonedx.BOMFileFormat
+	outputFormat := flagFormat
 
 	for i, filePath := range args {
 		log.Debugf("Parsing BOM file: %s", filePath)
 
-		bom, format, err := parseBOMFile(filePath)
+		bom, input, err := cdxutil.ReadBOMFile(filePath)
 		if err != nil {
 			log.Fatalf("Failed to parse BOM file %s: %v", filePath, err)
 		}
 
 		// Use the format of the first file as the output format
-		if i == 0 {
-			outputFormat = format
+		if i == 0 && outputFormat == "" {
+			outputFormat = string(input.Format)
 		}
 
 		boms = append(boms, bom)
@@ -73,17 +140,108 @@ func runMerge(cmd *cobra.Command, args []string) {
 		log.Fatal("No valid BOM files found")
 	}
 
+	// move the preferred input first
+	inputs := slices.Clone(args)
+	if flagPrefer != "" {
+		i := slices.IndexFunc(inputs, func(input string) bool {
+			return filepath.Clean(input) == filepath.Clean(flagPrefer)
+		})
+		if i < 0 {
+			log.Fatalf("--prefer %s is not one of the input files", flagPrefer)
+		}
+
+		preferred, input := boms[i], inputs[i]
+		boms = slices.Insert(slices.Delete(boms, i, i+1), 0, preferred)
+		inputs = slices.Insert(slices.Delete(inputs, i, i+1), 0, input)
+	}
+
 	log.Debugf("Merging %d BOM files", len(boms))
 
 	// Perform the merge
-	merged := boms[0]
-	for i := 1; i < len(boms); i++ {
-		log.Debugf("Merging BOM %d/%d", i+1, len(boms))
-		merged = mergex.MergeBom(merged, boms[i])
+	opts := []mergex.Option{mergex.WithStrategies(strategies)}
+	var graphIssues []mergex.GraphIssue
+	if flagRepair {
+		opts = append(opts, mergex.WithAttachOrphans(), mergex.WithGraphIssues(&graphIssues))
+	}
+
+	var inputConflicts [][]mergex.Conflict
+	var merged *cyclonedx.BOM
+	if flagSuper {
+		// super BOMs are merged pairwise, the conflicts are found in a separate pass
+		if flagReportConflicts || flagStrict {
+			inputConflicts = mergex.FindMergeConflicts(boms, mergex.WithStrategies(strategies))
+		}
+		merged = mergex.MergeBomsAsSubtree(boms, opts...)
+	} else {
+		// the conflicts are found while merging (in a single pass over the inputs)
+		if flagReportConflicts || flagStrict {
+			opts = append(opts, mergex.WithConflicts(&inputConflicts))
+		}
+		merged = mergex.MergeBoms(boms, opts...)
+	}
+
+	var conflicts []mergeConflict
+	for i, found := range inputConflicts {
+		for _, conflict := range found {
+			// with --super the root components are nested, not merged
+			if flagSuper && boms[0].Metadata != nil && boms[0].Metadata.Component != nil && conflict.BOMRef == boms[0].Metadata.Component.BOMRef {
+				continue
+			}
+			conflicts = append(conflicts, mergeConflict{Conflict: conflict, Input: inputs[i]})
+		}
+	}
+
+	if flagResolveLinks != "" {
+		linked, err := readLinkedBOMs(flagResolveLinks)
+		if err != nil {
+			log.Fatalf("Failed to read linked BOMs: %v", err)
+		}
+
+		merge := func(a, b *cyclonedx.BOM) *cyclonedx.BOM {
+			if flagSuper {
+				return mergex.MergeBomAsSubtree(a, b, mergex.WithStrategies(strategies))
+			}
+			return mergex.MergeBomAsDependency(a, b, mergex.WithStrategies(strategies))
+		}
+
+		var unresolved []cdxutil.BOMLink
+		merged, unresolved = mergex.ResolveLinks(merged, func(link cdxutil.BOMLink) *cyclonedx.BOM {
+			return linked[link.BOM()]
+		}, merge)
+
+		for _, link := range unresolved {
+			log.Warn("BOM-Link not found in "+flagResolveLinks, "link", link.String())
+		}
+
+		// the linked BOMs are merged after the graph of the inputs was repaired
+		if flagRepair {
+			merged, _ = mergex.RepairGraph(merged, opts...)
+		}
+	}
+
+	// the merge directives are only hints for mergex
+	mergex.StripDirectives(merged)
+
+	if flagRepair {
+		if issues := uniqueGraphIssues(graphIssues); len(issues) > 0 {
+			_ = writeGraphIssues(os.Stderr, issues)
+		} else {
+			log.Printf("No dependency graph issues found")
+		}
 	}
 
 	log.Debugf("Merge completed successfully")
 
+	if len(conflicts) > 0 {
+		_ = writeConflicts(os.Stderr, conflicts)
+	} else if flagReportConflicts {
+		log.Printf("No conflicts found")
+	}
+
+	if flagStrict && len(conflicts) > 0 {
+		log.Fatalf("Merge failed: %d conflict(s) found (--strict)", len(conflicts))
+	}
+
 	// Write output
 	if err := writeBOM(merged, outputPath, outputFormat, prettyPrint); err != nil {
 		log.Fatalf("Failed to write merged BOM: %v", err)
@@ -94,41 +252,96 @@ func runMerge(cmd *cobra.Command, args []string) {
 	}
 }
 
-func parseBOMFile(filePath string) (*cyclonedx.BOM, cyclonedx.BOMFileFormat, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, cyclonedx.BOMFileFormatJSON, fmt.Errorf("failed to open file: %w", err)
-	}
-	defer file.Close()
+// readLinkedBOMs reads the BOM files in dir (and its subdirectories) by BOM-Link. Files that are not BOMs, or BOMs
+// without a urn:uuid serial number, are skipped.
+func readLinkedBOMs(dir string) (map[cdxutil.BOMLink]*cyclonedx.BOM, error) {
+	boms := map[cdxutil.BOMLink]*cyclonedx.BOM{}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		bom, _, err := cdxutil.ReadBOMFile(path)
+		if err != nil {
+			log.Debugf("skipping %s: %v", path, err)
+			return nil
+		}
+
+		link, err := cdxutil.NewBOMLink(bom, "")
+		if err != nil {
+			log.Debugf("skipping %s: %v", path, err)
+			return nil
+		}
 
-	// Detect format by file extension
-	format := detectBOMFormat(filePath)
+		log.Debugf("found %s in %s", link, path)
+		boms[link] = bom
+		return nil
+	})
 
-	// Create decoder and parse BOM
-	decoder := cyclonedx.NewBOMDecoder(file, format)
-	var bom cyclonedx.BOM
-	if err := decoder.Decode(&bom); err != nil {
-		return nil, format, fmt.Errorf("failed to decode BOM: %w", err)
+	return boms, err
+}
+
+// mergeConflict is a conflict found when merging Input into the BOMs before it
+type mergeConflict struct {
+	mergex.Conflict
+	Input string
+}
+
+// writeConflicts writes the conflicts as a table
+func writeConflicts(w io.Writer, conflicts []mergeConflict) error {
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"BOMRef", "Field", "First value", "Second value", "Second input"})
+
+	for _, conflict := range conflicts {
+		t.AppendRow(table.Row{conflict.BOMRef, conflict.Field, conflict.First, conflict.Second, conflict.Input})
 	}
 
-	return &bom, format, nil
+	t.AppendFooter(table.Row{fmt.Sprintf("%d conflicts", len(conflicts)), "", "", "", ""})
+	t.Style().Format.Footer = text.FormatDefault
+
+	_, err := fmt.Fprintln(w, t.Render())
+	return err
 }
 
-func detectBOMFormat(filePath string) cyclonedx.BOMFileFormat {
-	ext := strings.ToLower(filepath.Ext(filePath))
-	switch ext {
-	case ".xml":
-		return cyclonedx.BOMFileFormatXML
-	case ".json":
-		return cyclonedx.BOMFileFormatJSON
-	default:
-		// Default to JSON for unknown extensions
-		log.Debugf("Unknown file extension %s, defaulting to JSON format", ext)
-		return cyclonedx.BOMFileFormatJSON
+// uniqueGraphIssues removes the issues that were found again by a later repair (i.e. cycles are not repaired)
+func uniqueGraphIssues(issues []mergex.GraphIssue) []mergex.GraphIssue {
+	var result []mergex.GraphIssue
+	seen := map[string]bool{}
+	for _, issue := range issues {
+		key := strings.Join(append([]string{string(issue.Type), issue.Ref}, issue.Refs...), "\x00")
+		if !seen[key] {
+			seen[key] = true
+			result = append(result, issue)
+		}
 	}
+	return result
 }
 
-func writeBOM(bom *cyclonedx.BOM, outputPath string, format cyclonedx.BOMFileFormat, pretty bool) error {
+// writeGraphIssues writes the dependency graph issues as a table
+func writeGraphIssues(w io.Writer, issues []mergex.GraphIssue) error {
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Issue", "Ref", "Refs", "Repair"})
+
+	for _, issue := range issues {
+		separator := ", "
+		if issue.Type == mergex.IssueCycle {
+			separator = " -> "
+		}
+		t.AppendRow(table.Row{issue.Type, issue.Ref, strings.Join(issue.Refs, separator), issue.Repair})
+	}
+
+	t.AppendFooter(table.Row{fmt.Sprintf("%d issues", len(issues)), "", "", ""})
+	t.Style().Format.Footer = text.FormatDefault
+
+	_, err := fmt.Fprintln(w, t.Render())
+	return err
+}
+
+func writeBOM(bom *cyclonedx.BOM, outputPath string, format string, pretty bool) error {
 	var writer *os.File
 	var err error
 
@@ -142,20 +355,8 @@ func writeBOM(bom *cyclonedx.BOM, outputPath string, format cyclonedx.BOMFileFor
 		defer writer.Close()
 	}
 
-	// Create encoder
-	encoder := cyclonedx.NewBOMEncoder(writer, format)
-
-	if pretty {
-		encoder.SetPretty(true)
-	}
-
-	// For JSON, disable HTML escaping to make the output more readable
-	if format == cyclonedx.BOMFileFormatJSON {
-		encoder.SetEscapeHTML(false)
-	}
-
 	// Encode BOM
-	if err := encoder.Encode(bom); err != nil {
+	if err := encodeBOM(writer, bom, format, pretty); err != nil {
 		return fmt.Errorf("failed to encode BOM: %w", err)
 	}
 
@@ -44,12 +44,12 @@ func VerifyCommand(cmd *cobra.Command, args []string) {
 	log.Printf("Verifying SBOM: %s", sbomPath)
 
 	// Parse and validate SBOM
-	bom, err := cdxutil.ParseCycloneDX(sbomPath)
+	bom, input, err := cdxutil.ReadBOMFile(sbomPath)
 	if err != nil {
 		log.Fatal("Failed to parse SBOM", "error", err)
 	}
 
-	log.Printf("✓ SBOM is valid CycloneDX format")
+	log.Printf("✓ SBOM is valid %s format", input.Format)
 
 	// Validate SBOM content structure
 	validationErrors := validateSBOMContent(bom)
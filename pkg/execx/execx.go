@@ -2,14 +2,19 @@ package execx
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"time"
 )
 
 var ErrNotFound = exec.ErrNotFound
 
+// killWaitDelay is how long to wait for the output pipes to close after a command is killed
+const killWaitDelay = 5 * time.Second
+
 type ExternalCommandError struct {
 	Message  string
 	ExitCode int
@@ -21,7 +26,26 @@ func (e *ExternalCommandError) Error() string {
 }
 
 func Exec(command string, args ...string) (string, error) {
-	cmd := exec.Command(command, args...)
+	return ExecContext(context.Background(), command, args...)
+}
+
+// ExecContext runs a command and kills it (and any processes it started) when ctx is done.
+// The returned error wraps ctx.Err() if the command was killed because of ctx.
+func ExecContext(ctx context.Context, command string, args ...string) (string, error) {
+	return ExecDirContext(ctx, "", command, args...)
+}
+
+// ExecDirContext is ExecContext with the working directory of the command set to dir (the current directory if
+// dir is empty)
+func ExecDirContext(ctx context.Context, dir string, command string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Dir = dir
+	if ctx.Done() != nil {
+		killProcessGroupOnCancel(cmd)
+	}
+
+	// don't wait forever for output from orphaned child processes after the command is killed
+	cmd.WaitDelay = killWaitDelay
 
 	// Buffer to capture stdout and stderr
 	var stdoutBuf, stderrBuf bytes.Buffer
@@ -33,6 +57,10 @@ func Exec(command string, args ...string) (string, error) {
 
 	// Execute the command
 	err := cmd.Run()
+	if err != nil && ctx.Err() != nil {
+		return "", fmt.Errorf("'%s' was stopped: %w", command, ctx.Err())
+	}
+
 	if err != nil {
 		var exerr *exec.ExitError
 		if errors.As(err, &exerr) {
@@ -0,0 +1,33 @@
+package execx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecContext(t *testing.T) {
+	output, err := ExecContext(context.Background(), "echo", "hello")
+	require.NoError(t, err)
+	assert.Equal(t, "hello\n", output)
+
+	_, err = ExecContext(context.Background(), "false")
+	var extCmdErr *ExternalCommandError
+	require.ErrorAs(t, err, &extCmdErr)
+	assert.Equal(t, 1, extCmdErr.ExitCode)
+}
+
+func TestExecContext_Timeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// the child process (sleep) must be killed as well, otherwise this blocks until it exits
+	start := time.Now()
+	_, err := ExecContext(ctx, "sh", "-c", "sleep 30; echo done")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "unexpected error: %v", err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package execx
+
+import "os/exec"
+
+// killProcessGroupOnCancel is a no-op on Windows, the command itself is killed when its context is done
+func killProcessGroupOnCancel(cmd *exec.Cmd) {}
@@ -1,6 +1,7 @@
 package execx
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -51,6 +52,10 @@ func TrivyAbsolutePath() (string, bool) {
 }
 
 func Trivy(args ...string) (string, error) {
+	return TrivyContext(context.Background(), args...)
+}
+
+func TrivyContext(ctx context.Context, args ...string) (string, error) {
 	log.Debug(fmt.Sprintf("running 'trivy %s'", strings.Join(args, " ")))
 
 	trivyPath, found := TrivyAbsolutePath()
@@ -61,7 +66,7 @@ func Trivy(args ...string) (string, error) {
 		os.Exit(1)
 	}
 
-	output, err := Exec(trivyPath, args...)
+	output, err := ExecContext(ctx, trivyPath, args...)
 	if err != nil {
 		if errors.Is(err, ErrNotFound) {
 			log.Error("Trivy not found in $PATH")
@@ -7,8 +7,25 @@ import (
 // MergeBom merges two cyclonedx.BOM structs non-destructively.
 // For non-array fields, the first input (a) takes precedence.
 // For array fields, items from both inputs are combined.
+// Components with the same normalized purl (or name+group+version without a purl) are merged
+// and references to the duplicate BOMRefs are rewritten to the BOMRef of the first one.
+// The merge strategy of component fields can be changed with WithStrategy.
+// Merge directives of the root components (see MergeDirectiveProperty) are applied first.
 // Returns a new BOM struct without modifying the inputs.
-func MergeBom(a, b *cyclonedx.BOM) *cyclonedx.BOM {
+func MergeBom(a, b *cyclonedx.BOM, opts ...Option) *cyclonedx.BOM {
+	o := newOptions(opts)
+
+	if a != nil && b != nil {
+		var nest bool
+		if a, b, nest = applyDirectives(a, b); nest {
+			return mergeBomAsSubtree(a, b, o)
+		}
+	}
+
+	return mergeBom(a, b, o)
+}
+
+func mergeBom(a, b *cyclonedx.BOM, o options) *cyclonedx.BOM {
 	if a == nil {
 		if b == nil {
 			return nil
@@ -56,10 +73,15 @@ func MergeBom(a, b *cyclonedx.BOM) *cyclonedx.BOM {
 		result.Metadata = copyMetadata(b.Metadata)
 	} else if b.Metadata != nil {
 		result.Metadata = mergeMetadata(result.Metadata, b.Metadata)
+
+		if a.Metadata.Component != nil && b.Metadata.Component != nil {
+			root := o.componentMerger(SectionRootComponent)(*a.Metadata.Component, *b.Metadata.Component)
+			result.Metadata.Component = &root
+		}
 	}
 
 	// Merge array fields with proper merge functions
-	result.Components = mergeComponentSlice(result.Components, b.Components)
+	result.Components = mergeComponentSliceWith(result.Components, b.Components, o.componentMerger(SectionComponents))
 
 	// Special handling for root component dependencies - this also handles regular merge
 	// but excludes the second BOM's root dependencies to avoid duplication
@@ -85,6 +107,9 @@ func MergeBom(a, b *cyclonedx.BOM) *cyclonedx.BOM {
 		result.Definitions = mergeDefinitions(result.Definitions, b.Definitions)
 	}
 
+	// merge components found with different BOMRefs (e.g. by different scanners)
+	deduplicateComponents(result, o.componentMerger(SectionComponents))
+
 	return result
 }
 
@@ -94,8 +119,22 @@ func MergeBom(a, b *cyclonedx.BOM) *cyclonedx.BOM {
 // All other components and dependencies from the second BOM are also merged.
 // If both BOMs have the same root component (same BOMRef OR same name+version+purl),
 // performs a regular merge instead to avoid self-dependencies.
+// Merge directives of the root components (see MergeDirectiveProperty) are applied first.
 // Returns a new BOM struct without modifying the inputs.
-func MergeBomAsDependency(a, b *cyclonedx.BOM) *cyclonedx.BOM {
+func MergeBomAsDependency(a, b *cyclonedx.BOM, opts ...Option) *cyclonedx.BOM {
+	o := newOptions(opts)
+
+	if a != nil && b != nil {
+		var nest bool
+		if a, b, nest = applyDirectives(a, b); nest {
+			return mergeBomAsSubtree(a, b, o)
+		}
+	}
+
+	return mergeBomAsDependency(a, b, o)
+}
+
+func mergeBomAsDependency(a, b *cyclonedx.BOM, o options) *cyclonedx.BOM {
 	if a == nil {
 		if b == nil {
 			return nil
@@ -109,10 +148,10 @@ func MergeBomAsDependency(a, b *cyclonedx.BOM) *cyclonedx.BOM {
 		return copyBOM(a)
 	}
 
-	// Check if both BOMs have the same root component
-	if hasSameRootComponent(a, b) {
+	// Check if both BOMs have the same root component (or b has none after applying the directives)
+	if hasSameRootComponent(a, b) || b.Metadata == nil || b.Metadata.Component == nil {
 		// If same root component, just do regular merge to avoid self-dependency
-		return MergeBom(a, b)
+		return mergeBom(a, b, o)
 	}
 
 	// Start with deep copy of a as base
@@ -152,11 +191,13 @@ func MergeBomAsDependency(a, b *cyclonedx.BOM) *cyclonedx.BOM {
 				}
 
 				if rootDepIndex >= 0 {
-					// Add to existing root dependency
-					if (*result.Dependencies)[rootDepIndex].Dependencies == nil {
-						(*result.Dependencies)[rootDepIndex].Dependencies = &[]string{}
+					// Add to existing root dependency (the refs are copied, they can be shared with a)
+					var refs []string
+					if (*result.Dependencies)[rootDepIndex].Dependencies != nil {
+						refs = append(refs, *(*result.Dependencies)[rootDepIndex].Dependencies...)
 					}
-					*(*result.Dependencies)[rootDepIndex].Dependencies = append(*(*result.Dependencies)[rootDepIndex].Dependencies, rootComponent.BOMRef)
+					refs = append(refs, rootComponent.BOMRef)
+					(*result.Dependencies)[rootDepIndex].Dependencies = &refs
 				} else {
 					// Create new root dependency with this component as a dependency
 					rootDep := cyclonedx.Dependency{
@@ -176,7 +217,7 @@ func MergeBomAsDependency(a, b *cyclonedx.BOM) *cyclonedx.BOM {
 	}
 
 	// Merge all other components and dependencies from b
-	result.Components = mergeComponentSlice(result.Components, b.Components)
+	result.Components = mergeComponentSliceWith(result.Components, b.Components, o.componentMerger(SectionComponents))
 	result.Dependencies = mergeDependencySlice(result.Dependencies, b.Dependencies)
 
 	// Merge other fields from b
@@ -201,39 +242,46 @@ func MergeBomAsDependency(a, b *cyclonedx.BOM) *cyclonedx.BOM {
 		result.Definitions = mergeDefinitions(result.Definitions, b.Definitions)
 	}
 
+	// merge components found with different BOMRefs (e.g. by different scanners)
+	deduplicateComponents(result, o.componentMerger(SectionComponents))
+
 	return result
 }
 
-func MergeBomsAsDependency(boms []*cyclonedx.BOM) *cyclonedx.BOM {
+func MergeBomsAsDependency(boms []*cyclonedx.BOM, opts ...Option) *cyclonedx.BOM {
 	if len(boms) == 0 {
 		return nil
 	}
 
+	// a single BOM is only repaired
 	if len(boms) == 1 {
-		return copyBOM(boms[0])
+		if o := newOptions(opts); o.conflicts != nil {
+			*o.conflicts = append(*o.conflicts, nil)
+		}
+		repaired, _ := RepairGraph(boms[0], opts...)
+		return repaired
 	}
 
-	merged := boms[0]
-	for i := 1; i < len(boms); i++ {
-		merged = MergeBomAsDependency(merged, boms[i])
-	}
-	return merged
+	repaired, _ := RepairGraph(mergeBoms(boms, true, opts), opts...)
+	return repaired
 }
 
-func MergeBoms(boms []*cyclonedx.BOM) *cyclonedx.BOM {
+func MergeBoms(boms []*cyclonedx.BOM, opts ...Option) *cyclonedx.BOM {
 	if len(boms) == 0 {
 		return nil
 	}
 
+	// a single BOM is only repaired
 	if len(boms) == 1 {
-		return copyBOM(boms[0])
+		if o := newOptions(opts); o.conflicts != nil {
+			*o.conflicts = append(*o.conflicts, nil)
+		}
+		repaired, _ := RepairGraph(boms[0], opts...)
+		return repaired
 	}
 
-	merged := boms[0]
-	for i := 1; i < len(boms); i++ {
-		merged = MergeBom(merged, boms[i])
-	}
-	return merged
+	repaired, _ := RepairGraph(mergeBoms(boms, false, opts), opts...)
+	return repaired
 }
 
 // hasSameRootComponent checks if two BOMs have the same root component.
@@ -1,6 +1,8 @@
 package mergex
 
 import (
+	"slices"
+
 	"github.com/CycloneDX/cyclonedx-go"
 )
 
@@ -502,24 +504,37 @@ func mergePropertySlice(a, b *[]cyclonedx.Property) *[]cyclonedx.Property {
 }
 
 func mergeComponentSlice(a, b *[]cyclonedx.Component) *[]cyclonedx.Component {
+	return mergeComponentSliceWith(a, b, MergeComponent)
+}
+
+// mergeComponentSliceWith combines the components, components that are in both slices are merged with merge
+func mergeComponentSliceWith(a, b *[]cyclonedx.Component, merge func(a, b cyclonedx.Component) cyclonedx.Component) *[]cyclonedx.Component {
 	if a == nil && b == nil {
 		return nil
 	}
 
 	// Use map to track components by BOMRef for deduplication
 	componentMap := make(map[string]cyclonedx.Component)
+	// keys in the order they were first seen (keeps the output stable)
+	var keys []string
+	add := func(key string, comp cyclonedx.Component) {
+		if _, exists := componentMap[key]; !exists {
+			keys = append(keys, key)
+		}
+		componentMap[key] = comp
+	}
 
 	// First add all components from a
 	if a != nil {
 		for _, comp := range *a {
 			if comp.BOMRef != "" {
-				componentMap[comp.BOMRef] = comp
+				add(comp.BOMRef, comp)
 			} else {
 				// Components without BOMRef are always included
 				// Use a temporary key based on name+version+packageURL for deduplication
 				key := comp.Name + "|" + comp.Version + "|" + comp.PackageURL
 				if _, exists := componentMap[key]; !exists {
-					componentMap[key] = comp
+					add(key, comp)
 				}
 			}
 		}
@@ -531,19 +546,19 @@ func mergeComponentSlice(a, b *[]cyclonedx.Component) *[]cyclonedx.Component {
 			if comp.BOMRef != "" {
 				if existing, exists := componentMap[comp.BOMRef]; exists {
 					// Merge components with same BOMRef
-					componentMap[comp.BOMRef] = MergeComponent(existing, comp)
+					componentMap[comp.BOMRef] = merge(existing, comp)
 				} else {
 					// Add new component with unique BOMRef
-					componentMap[comp.BOMRef] = comp
+					add(comp.BOMRef, comp)
 				}
 			} else {
 				// Components without BOMRef are always included if not duplicate
 				key := comp.Name + "|" + comp.Version + "|" + comp.PackageURL
 				if existing, exists := componentMap[key]; exists {
 					// Merge components with same key
-					componentMap[key] = MergeComponent(existing, comp)
+					componentMap[key] = merge(existing, comp)
 				} else {
-					componentMap[key] = comp
+					add(key, comp)
 				}
 			}
 		}
@@ -555,8 +570,8 @@ func mergeComponentSlice(a, b *[]cyclonedx.Component) *[]cyclonedx.Component {
 
 	// Convert back to slice
 	result := make([]cyclonedx.Component, 0, len(componentMap))
-	for _, comp := range componentMap {
-		result = append(result, comp)
+	for _, key := range keys {
+		result = append(result, componentMap[key])
 	}
 
 	return &result
@@ -601,17 +616,31 @@ func mergePatchSlice(a, b *[]cyclonedx.Patch) *[]cyclonedx.Patch {
 	return &result
 }
 
+// mergeEvidenceIdentitySlice merges identity evidence by field. The methods from both inputs are combined
+// (so merged duplicates keep every scanner that identified the component) and the highest confidence wins.
 func mergeEvidenceIdentitySlice(a, b *[]cyclonedx.EvidenceIdentity) *[]cyclonedx.EvidenceIdentity {
 	if a == nil && b == nil {
 		return nil
 	}
 
 	var result []cyclonedx.EvidenceIdentity
-	if a != nil {
-		result = append(result, *a...)
-	}
-	if b != nil {
-		result = append(result, *b...)
+	for _, identities := range []*[]cyclonedx.EvidenceIdentity{a, b} {
+		if identities == nil {
+			continue
+		}
+
+	NextIdentity:
+		for _, identity := range *identities {
+			for i := range result {
+				if result[i].Field == identity.Field {
+					result[i] = mergeEvidenceIdentity(result[i], identity)
+					continue NextIdentity
+				}
+			}
+
+			// copy methods and tools to avoid modifying the inputs when merging
+			result = append(result, mergeEvidenceIdentity(cyclonedx.EvidenceIdentity{Field: identity.Field}, identity))
+		}
 	}
 
 	if len(result) == 0 {
@@ -620,6 +649,51 @@ func mergeEvidenceIdentitySlice(a, b *[]cyclonedx.EvidenceIdentity) *[]cyclonedx
 	return &result
 }
 
+func mergeEvidenceIdentity(a, b cyclonedx.EvidenceIdentity) cyclonedx.EvidenceIdentity {
+	result := a
+
+	if b.Confidence != nil && (result.Confidence == nil || *b.Confidence > *result.Confidence) {
+		confidence := *b.Confidence
+		result.Confidence = &confidence
+	}
+
+	var methods []cyclonedx.EvidenceIdentityMethod
+	if a.Methods != nil {
+		methods = append(methods, *a.Methods...)
+	}
+	if b.Methods != nil {
+		for _, method := range *b.Methods {
+			if !slices.ContainsFunc(methods, func(m cyclonedx.EvidenceIdentityMethod) bool {
+				return m.Technique == method.Technique && m.Value == method.Value
+			}) {
+				methods = append(methods, method)
+			}
+		}
+	}
+	result.Methods = nil
+	if len(methods) > 0 {
+		result.Methods = &methods
+	}
+
+	var tools []cyclonedx.BOMReference
+	if a.Tools != nil {
+		tools = append(tools, *a.Tools...)
+	}
+	if b.Tools != nil {
+		for _, tool := range *b.Tools {
+			if !slices.Contains(tools, tool) {
+				tools = append(tools, tool)
+			}
+		}
+	}
+	result.Tools = nil
+	if len(tools) > 0 {
+		result.Tools = &tools
+	}
+
+	return result
+}
+
 func mergeEvidenceOccurrenceSlice(a, b *[]cyclonedx.EvidenceOccurrence) *[]cyclonedx.EvidenceOccurrence {
 	if a == nil && b == nil {
 		return nil
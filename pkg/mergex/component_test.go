@@ -865,3 +865,50 @@ func TestMergeComponentSlice_Immutability(t *testing.T) {
 		assert.Equal(t, "Copyright B", (*result)[0].Copyright)
 	})
 }
+
+func TestMergeEvidenceIdentitySlice(t *testing.T) {
+	confidence := func(c float32) *float32 { return &c }
+
+	a := &[]cyclonedx.EvidenceIdentity{
+		{
+			Field:      cyclonedx.EvidenceIdentityFieldTypePURL,
+			Confidence: confidence(0.7),
+			Methods: &[]cyclonedx.EvidenceIdentityMethod{
+				{Technique: cyclonedx.EvidenceIdentityTechniqueManifestAnalysis, Confidence: confidence(0.7), Value: "observer:scanner=trivy/npm"},
+			},
+		},
+	}
+	b := &[]cyclonedx.EvidenceIdentity{
+		{
+			Field:      cyclonedx.EvidenceIdentityFieldTypePURL,
+			Confidence: confidence(0.9),
+			Methods: &[]cyclonedx.EvidenceIdentityMethod{
+				{Technique: cyclonedx.EvidenceIdentityTechniqueManifestAnalysis, Confidence: confidence(0.9), Value: "observer:scanner=scalibr/javascript/packagelockjson"},
+				{Technique: cyclonedx.EvidenceIdentityTechniqueManifestAnalysis, Confidence: confidence(0.7), Value: "observer:scanner=trivy/npm"},
+			},
+		},
+		{
+			Field:      cyclonedx.EvidenceIdentityFieldTypeHash,
+			Confidence: confidence(1),
+		},
+	}
+
+	result := mergeEvidenceIdentitySlice(a, b)
+	assert.NotNil(t, result)
+	assert.Len(t, *result, 2)
+
+	purl := (*result)[0]
+	assert.Equal(t, cyclonedx.EvidenceIdentityFieldTypePURL, purl.Field)
+	assert.Equal(t, float32(0.9), *purl.Confidence)
+	assert.Len(t, *purl.Methods, 2)
+	assert.Equal(t, "observer:scanner=trivy/npm", (*purl.Methods)[0].Value)
+	assert.Equal(t, "observer:scanner=scalibr/javascript/packagelockjson", (*purl.Methods)[1].Value)
+
+	assert.Equal(t, cyclonedx.EvidenceIdentityFieldTypeHash, (*result)[1].Field)
+
+	// inputs are not modified
+	assert.Equal(t, float32(0.7), *(*a)[0].Confidence)
+	assert.Len(t, *(*a)[0].Methods, 1)
+
+	assert.Nil(t, mergeEvidenceIdentitySlice(nil, nil))
+}
@@ -0,0 +1,212 @@
+package mergex
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+// Conflict is a field where two components that are merged have different (non-empty) values.
+// By default MergeBom keeps the First value (or combines both for licenses), see WithStrategy.
+type Conflict struct {
+	BOMRef string `json:"bomRef"`
+	Field  string `json:"field"`
+	First  string `json:"first"`
+	Second string `json:"second"`
+}
+
+// componentFields are the component fields compared by FindConflicts
+var componentFields = []struct {
+	name  string
+	value func(c cyclonedx.Component) string
+}{
+	{"type", func(c cyclonedx.Component) string { return string(c.Type) }},
+	{"group", func(c cyclonedx.Component) string { return c.Group }},
+	{"name", func(c cyclonedx.Component) string { return c.Name }},
+	{"version", func(c cyclonedx.Component) string { return c.Version }},
+	{"purl", func(c cyclonedx.Component) string {
+		if c.PackageURL == "" {
+			return ""
+		}
+		return NormalizePurl(c.PackageURL)
+	}},
+	{"cpe", func(c cyclonedx.Component) string { return c.CPE }},
+	{"scope", func(c cyclonedx.Component) string { return string(c.Scope) }},
+	{"licenses", func(c cyclonedx.Component) string { return licensesString(c.Licenses) }},
+	{"supplier", func(c cyclonedx.Component) string {
+		if c.Supplier == nil {
+			return ""
+		}
+		return c.Supplier.Name
+	}},
+	{"publisher", func(c cyclonedx.Component) string { return c.Publisher }},
+	{"author", func(c cyclonedx.Component) string { return c.Author }},
+	{"copyright", func(c cyclonedx.Component) string { return c.Copyright }},
+}
+
+// FindConflicts returns the field level conflicts between the components that MergeBom(a, b) merges: the root
+// components and components with the same BOMRef or identity (see componentIdentity). Fields that are empty in
+// one of the components are not conflicts.
+func FindConflicts(a, b *cyclonedx.BOM) []Conflict {
+	if a == nil || b == nil {
+		return nil
+	}
+
+	var conflicts []Conflict
+
+	if a.Metadata != nil && a.Metadata.Component != nil && b.Metadata != nil && b.Metadata.Component != nil {
+		conflicts = append(conflicts, componentConflicts(*a.Metadata.Component, *b.Metadata.Component)...)
+	}
+
+	if a.Components == nil || b.Components == nil {
+		return conflicts
+	}
+
+	byRef := map[string]int{}
+	byIdentity := map[string]int{}
+	for i, component := range *a.Components {
+		if component.BOMRef != "" {
+			if _, found := byRef[component.BOMRef]; !found {
+				byRef[component.BOMRef] = i
+			}
+		}
+		if identity := componentIdentity(component); identity != "" {
+			if _, found := byIdentity[identity]; !found {
+				byIdentity[identity] = i
+			}
+		}
+	}
+
+	for _, component := range *b.Components {
+		i, found := byRef[component.BOMRef]
+		if !found || component.BOMRef == "" {
+			identity := componentIdentity(component)
+			if identity == "" {
+				continue
+			}
+			if i, found = byIdentity[identity]; !found {
+				continue
+			}
+		}
+
+		conflicts = append(conflicts, componentConflicts((*a.Components)[i], component)...)
+	}
+
+	return conflicts
+}
+
+// WithConflicts appends the conflicts of every BOM merged by MergeBoms or MergeBomsAsDependency with the BOMs before
+// it to conflicts (one entry per BOM, like FindMergeConflicts). The conflicts are found while merging.
+func WithConflicts(conflicts *[][]Conflict) Option {
+	return func(o *options) {
+		o.conflicts = conflicts
+	}
+}
+
+// FindMergeConflicts returns the conflicts of every BOM with the BOMs before it (conflicts[i] are the conflicts of
+// boms[i]), like FindConflicts between the merged BOMs before it and boms[i], but in a single pass over the
+// components. Components are merged with the strategies in opts, as they are by MergeBoms.
+func FindMergeConflicts(boms []*cyclonedx.BOM, opts ...Option) [][]Conflict {
+	o := newOptions(opts)
+	merge := o.componentMerger(SectionComponents)
+	mergeRoot := o.componentMerger(SectionRootComponent)
+
+	conflicts := make([][]Conflict, len(boms))
+
+	var root *cyclonedx.Component
+	var components []cyclonedx.Component
+	byRef := map[string]int{}
+	byIdentity := map[string]int{}
+
+	for i, bom := range boms {
+		if bom == nil {
+			continue
+		}
+
+		if bom.Metadata != nil && bom.Metadata.Component != nil {
+			if root == nil {
+				component := *bom.Metadata.Component
+				root = &component
+			} else {
+				conflicts[i] = append(conflicts[i], componentConflicts(*root, *bom.Metadata.Component)...)
+				merged := mergeRoot(*root, *bom.Metadata.Component)
+				root = &merged
+			}
+		}
+
+		if bom.Components == nil {
+			continue
+		}
+
+		// components of this BOM are only compared with the components of the BOMs before it
+		added := len(components)
+		for _, component := range *bom.Components {
+			identity := componentIdentity(component)
+
+			j, found := byRef[component.BOMRef]
+			if !found || component.BOMRef == "" {
+				if identity != "" {
+					j, found = byIdentity[identity]
+				}
+			}
+
+			if !found {
+				j = len(components)
+				components = append(components, component)
+				if _, found := byRef[component.BOMRef]; !found && component.BOMRef != "" {
+					byRef[component.BOMRef] = j
+				}
+				if _, found := byIdentity[identity]; !found && identity != "" {
+					byIdentity[identity] = j
+				}
+				continue
+			}
+
+			if j < added {
+				conflicts[i] = append(conflicts[i], componentConflicts(components[j], component)...)
+			}
+			components[j] = merge(components[j], component)
+		}
+	}
+
+	return conflicts
+}
+
+func componentConflicts(a, b cyclonedx.Component) []Conflict {
+	var conflicts []Conflict
+	for _, field := range componentFields {
+		first, second := field.value(a), field.value(b)
+		if first != "" && second != "" && first != second {
+			conflicts = append(conflicts, Conflict{
+				BOMRef: firstNonEmpty(a.BOMRef, b.BOMRef),
+				Field:  field.name,
+				First:  first,
+				Second: second,
+			})
+		}
+	}
+	return conflicts
+}
+
+// licensesString returns the sorted license ids, names and expressions
+func licensesString(licenses *cyclonedx.Licenses) string {
+	if licenses == nil {
+		return ""
+	}
+
+	var values []string
+	for _, choice := range *licenses {
+		switch {
+		case choice.Expression != "":
+			values = append(values, choice.Expression)
+		case choice.License != nil && choice.License.ID != "":
+			values = append(values, choice.License.ID)
+		case choice.License != nil && choice.License.Name != "":
+			values = append(values, choice.License.Name)
+		}
+	}
+
+	slices.Sort(values)
+	return strings.Join(slices.Compact(values), ", ")
+}
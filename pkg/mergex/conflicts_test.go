@@ -0,0 +1,133 @@
+package mergex
+
+import (
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindConflicts(t *testing.T) {
+	a := &cyclonedx.BOM{
+		Metadata: &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "root", Name: "app", Version: "1.0"}},
+		Components: &[]cyclonedx.Component{
+			{
+				BOMRef:     "pkg:npm/a@1.0.0",
+				Name:       "a",
+				Version:    "1.0.0",
+				PackageURL: "pkg:npm/a@1.0.0",
+				Licenses:   &cyclonedx.Licenses{{License: &cyclonedx.License{ID: "MIT"}}, {License: &cyclonedx.License{ID: "Apache-2.0"}}},
+				Supplier:   &cyclonedx.OrganizationalEntity{Name: "Acme"},
+			},
+			{BOMRef: "b", Name: "b", Version: "2.0", Scope: cyclonedx.ScopeRequired},
+			{BOMRef: "c", Name: "c", Version: "1.0"},
+		},
+	}
+
+	b := &cyclonedx.BOM{
+		Metadata: &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "other-root", Name: "app", Version: "1.1"}},
+		Components: &[]cyclonedx.Component{
+			// same purl, different BOMRef
+			{
+				BOMRef:     "uuid-a",
+				Name:       "a",
+				Version:    "1.0.0",
+				PackageURL: "pkg:npm/a@v1.0.0",
+				Licenses:   &cyclonedx.Licenses{{License: &cyclonedx.License{ID: "GPL-3.0-only"}}},
+				Supplier:   &cyclonedx.OrganizationalEntity{Name: "Acme Inc"},
+			},
+			// same BOMRef
+			{BOMRef: "b", Name: "b", Version: "2.1", Scope: cyclonedx.ScopeExcluded},
+			// empty values and license order are not conflicts
+			{BOMRef: "c", Name: "c"},
+			// not in a
+			{BOMRef: "d", Name: "d", Version: "1.0"},
+		},
+	}
+
+	assert.Equal(t, []Conflict{
+		{BOMRef: "root", Field: "version", First: "1.0", Second: "1.1"},
+		{BOMRef: "pkg:npm/a@1.0.0", Field: "licenses", First: "Apache-2.0, MIT", Second: "GPL-3.0-only"},
+		{BOMRef: "pkg:npm/a@1.0.0", Field: "supplier", First: "Acme", Second: "Acme Inc"},
+		{BOMRef: "b", Field: "version", First: "2.0", Second: "2.1"},
+		{BOMRef: "b", Field: "scope", First: "required", Second: "excluded"},
+	}, FindConflicts(a, b))
+
+	assert.Empty(t, FindConflicts(a, a))
+	assert.Empty(t, FindConflicts(a, nil))
+}
+
+func TestFindMergeConflicts(t *testing.T) {
+	newBom := func(root string, version string, components ...cyclonedx.Component) *cyclonedx.BOM {
+		return &cyclonedx.BOM{
+			Metadata:   &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "root-" + root, Name: "app", Version: version}},
+			Components: &components,
+		}
+	}
+
+	a := newBom("a", "1.0",
+		cyclonedx.Component{BOMRef: "pkg:npm/a@1.0.0", Name: "a", Version: "1.0.0", PackageURL: "pkg:npm/a@1.0.0", Licenses: &cyclonedx.Licenses{{License: &cyclonedx.License{ID: "MIT"}}}},
+	)
+	b := newBom("b", "1.1",
+		// same identity, different BOMRef
+		cyclonedx.Component{BOMRef: "uuid-a", Name: "a", Version: "1.0.0", PackageURL: "pkg:npm/a@v1.0.0", Supplier: &cyclonedx.OrganizationalEntity{Name: "Acme"}},
+		cyclonedx.Component{BOMRef: "b", Name: "b", Version: "2.0"},
+		// not compared with the components of the same BOM
+		cyclonedx.Component{BOMRef: "b", Name: "b", Version: "2.1"},
+	)
+	c := newBom("c", "",
+		// the supplier from b was merged into a
+		cyclonedx.Component{BOMRef: "pkg:npm/a@1.0.0", Name: "a", Supplier: &cyclonedx.OrganizationalEntity{Name: "Acme Inc"}},
+		cyclonedx.Component{BOMRef: "b", Name: "b", Version: "2.2"},
+	)
+
+	boms := []*cyclonedx.BOM{a, nil, b, c}
+	conflicts := FindMergeConflicts(boms)
+
+	assert.Equal(t, [][]Conflict{
+		nil,
+		nil,
+		{{BOMRef: "root-a", Field: "version", First: "1.0", Second: "1.1"}},
+		{
+			{BOMRef: "pkg:npm/a@1.0.0", Field: "supplier", First: "Acme", Second: "Acme Inc"},
+			{BOMRef: "b", Field: "version", First: "2.0", Second: "2.2"},
+		},
+	}, conflicts)
+
+	// the same conflicts as FindConflicts with the merged BOMs before every input
+	assert.Equal(t, FindConflicts(a, b), conflicts[2])
+	assert.Equal(t, FindConflicts(MergeBom(a, b), c), conflicts[3])
+
+	// with strategies the merged values are compared
+	conflicts = FindMergeConflicts(boms, WithStrategy("version", StrategyLastWins))
+	assert.Equal(t, []Conflict{
+		{BOMRef: "pkg:npm/a@1.0.0", Field: "supplier", First: "Acme", Second: "Acme Inc"},
+		{BOMRef: "b", Field: "version", First: "2.1", Second: "2.2"},
+	}, conflicts[3])
+
+	assert.Empty(t, FindMergeConflicts(nil))
+
+	t.Run("while merging", func(t *testing.T) {
+		var merged [][]Conflict
+		MergeBoms(boms, WithConflicts(&merged))
+		assert.Equal(t, FindMergeConflicts(boms), merged)
+
+		merged = nil
+		MergeBoms(boms, WithStrategy("version", StrategyLastWins), WithConflicts(&merged))
+		assert.Equal(t, conflicts, merged)
+
+		merged = nil
+		MergeBoms([]*cyclonedx.BOM{a}, WithConflicts(&merged))
+		assert.Equal(t, [][]Conflict{nil}, merged)
+
+		// a different root component is nested and compared with the components, the same root component is merged
+		nested := &cyclonedx.BOM{Metadata: &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "pkg:npm/a@1.0.0", Name: "a", Version: "1.1.0"}}}
+		merged = nil
+		MergeBomsAsDependency([]*cyclonedx.BOM{a, newBom("a", "1.1"), nested}, WithConflicts(&merged))
+		assert.Equal(t, [][]Conflict{
+			nil,
+			{{BOMRef: "root-a", Field: "version", First: "1.0", Second: "1.1"}},
+			{{BOMRef: "pkg:npm/a@1.0.0", Field: "version", First: "1.0.0", Second: "1.1.0"}},
+		}, merged)
+	})
+}
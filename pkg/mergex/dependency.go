@@ -30,10 +30,15 @@ func mergeDependencySlice(a, b *[]cyclonedx.Dependency) *[]cyclonedx.Dependency
 
 	// Use map to track dependencies by Ref
 	depMap := make(map[string]cyclonedx.Dependency)
+	// refs in the order they were first seen (keeps the output stable)
+	var refs []string
 
 	// First add all dependencies from a
 	if a != nil {
 		for _, dep := range *a {
+			if _, exists := depMap[dep.Ref]; !exists {
+				refs = append(refs, dep.Ref)
+			}
 			depMap[dep.Ref] = dep
 		}
 	}
@@ -46,6 +51,7 @@ func mergeDependencySlice(a, b *[]cyclonedx.Dependency) *[]cyclonedx.Dependency
 				depMap[dep.Ref] = mergeDependency(existing, dep)
 			} else {
 				// Add new dependency (unique Ref)
+				refs = append(refs, dep.Ref)
 				depMap[dep.Ref] = dep
 			}
 		}
@@ -57,8 +63,8 @@ func mergeDependencySlice(a, b *[]cyclonedx.Dependency) *[]cyclonedx.Dependency
 
 	// Convert back to slice
 	result := make([]cyclonedx.Dependency, 0, len(depMap))
-	for _, dep := range depMap {
-		result = append(result, dep)
+	for _, ref := range refs {
+		result = append(result, depMap[ref])
 	}
 
 	return &result
@@ -70,30 +76,25 @@ func mergeStringSliceWithDeduplication(a, b *[]string) *[]string {
 		return nil
 	}
 
-	// Use map to track unique strings
-	strMap := make(map[string]bool)
+	// Use map to track unique strings, in the order they were first seen (keeps the output stable)
+	seen := make(map[string]bool)
+	var result []string
 
-	// Add strings from both slices
-	if a != nil {
-		for _, s := range *a {
-			strMap[s] = true
+	for _, slice := range []*[]string{a, b} {
+		if slice == nil {
+			continue
 		}
-	}
-	if b != nil {
-		for _, s := range *b {
-			strMap[s] = true
+		for _, s := range *slice {
+			if !seen[s] {
+				seen[s] = true
+				result = append(result, s)
+			}
 		}
 	}
 
-	if len(strMap) == 0 {
+	if len(result) == 0 {
 		return nil
 	}
 
-	// Convert back to slice
-	result := make([]string, 0, len(strMap))
-	for s := range strMap {
-		result = append(result, s)
-	}
-
 	return &result
 }
\ No newline at end of file
@@ -0,0 +1,169 @@
+package mergex
+
+import (
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+// MergeDirectiveProperty is the property of a root component that tells mergex how to merge its BOM. Scanners use it
+// for internal hints, it is removed from the output with StripDirectives.
+const MergeDirectiveProperty = "observer:internal:merge"
+
+// MergeDirective is the value of MergeDirectiveProperty
+type MergeDirective string
+
+const (
+	// DirectiveInline marks a synthetic root component (a wrapper for the results of a scanner). The wrapper is
+	// dissolved when the BOM is merged: its components are hoisted and references to it point to the other root.
+	DirectiveInline MergeDirective = "inline"
+	// DirectiveNest nests the BOM under the root component of the other BOM, see MergeBomAsSubtree
+	DirectiveNest MergeDirective = "nest"
+	// DirectiveReplaceRoot makes the root component of the BOM the root of the merged BOM, references to the other
+	// root component point to it
+	DirectiveReplaceRoot MergeDirective = "replace-root"
+)
+
+// rootDirective returns the merge directive of the root component of the BOM ("" if there is none)
+func rootDirective(bom *cyclonedx.BOM) MergeDirective {
+	if bom == nil || bom.Metadata == nil || bom.Metadata.Component == nil || bom.Metadata.Component.Properties == nil {
+		return ""
+	}
+
+	for _, property := range *bom.Metadata.Component.Properties {
+		if property.Name == MergeDirectiveProperty {
+			return MergeDirective(property.Value)
+		}
+	}
+
+	return ""
+}
+
+// IsInline returns true if the root component of the BOM is a synthetic wrapper (see DirectiveInline)
+func IsInline(bom *cyclonedx.BOM) bool {
+	return rootDirective(bom) == DirectiveInline
+}
+
+// applyDirectives rewrites a and b according to the merge directives of their root components (the inputs are
+// copied, not modified). Returns true if b should be nested under the root component of a.
+func applyDirectives(a, b *cyclonedx.BOM) (*cyclonedx.BOM, *cyclonedx.BOM, bool) {
+	directiveA, directiveB := rootDirective(a), rootDirective(b)
+	if directiveA == "" && directiveB == "" {
+		return a, b, false
+	}
+
+	// a wrapper is dissolved into the other root component (if both are wrappers, b is dissolved into the wrapper
+	// of a). Without another root component the wrapper is kept, it can be dissolved by a later merge.
+	switch {
+	case directiveB == DirectiveInline && hasRoot(a):
+		b = dissolveRoot(b, rootRef(a))
+	case directiveA == DirectiveInline && hasRoot(b):
+		a = dissolveRoot(a, rootRef(b))
+	}
+
+	switch {
+	case directiveB == DirectiveReplaceRoot && directiveA != DirectiveReplaceRoot:
+		a = replaceRoot(a, b.Metadata.Component)
+	case directiveA == DirectiveReplaceRoot && directiveB != DirectiveReplaceRoot:
+		b = replaceRoot(b, a.Metadata.Component)
+	}
+
+	return a, b, directiveB == DirectiveNest && canNest(a, b)
+}
+
+// canNest returns true if b can be nested under the root component of a
+func canNest(a, b *cyclonedx.BOM) bool {
+	return hasRoot(a) && hasRoot(b) && !hasSameRootComponent(a, b)
+}
+
+func hasRoot(bom *cyclonedx.BOM) bool {
+	return bom.Metadata != nil && bom.Metadata.Component != nil
+}
+
+func rootRef(bom *cyclonedx.BOM) string {
+	if !hasRoot(bom) {
+		return ""
+	}
+	return bom.Metadata.Component.BOMRef
+}
+
+// dissolveRoot returns a copy of the BOM without its root component. The subcomponents of the root are hoisted to
+// the components and references to the root are rewritten to root (or removed if root is empty).
+func dissolveRoot(bom *cyclonedx.BOM, root string) *cyclonedx.BOM {
+	result := copyBOM(bom)
+	wrapper := result.Metadata.Component
+	result.Metadata.Component = nil
+
+	if wrapper.Components != nil {
+		result.Components = mergeComponentSlice(result.Components, wrapper.Components)
+	}
+
+	if wrapper.BOMRef != "" {
+		if root != "" {
+			renameRefs(result, map[string]string{wrapper.BOMRef: root})
+		} else {
+			removeDependency(result, wrapper.BOMRef)
+		}
+	}
+
+	return result
+}
+
+// replaceRoot returns a copy of the BOM with root as its root component. The subcomponents of the replaced root are
+// kept and references to it are rewritten to root.
+func replaceRoot(bom *cyclonedx.BOM, root *cyclonedx.Component) *cyclonedx.BOM {
+	result := copyBOM(bom)
+	if result.Metadata == nil {
+		result.Metadata = &cyclonedx.Metadata{}
+	}
+
+	replaced := result.Metadata.Component
+	result.Metadata.Component = copyComponent(root)
+
+	if replaced == nil {
+		return result
+	}
+
+	if replaced.Components != nil {
+		result.Metadata.Component.Components = mergeComponentSlice(result.Metadata.Component.Components, replaced.Components)
+	}
+
+	if replaced.BOMRef != "" && root.BOMRef != "" && replaced.BOMRef != root.BOMRef {
+		renameRefs(result, map[string]string{replaced.BOMRef: root.BOMRef})
+	}
+
+	return result
+}
+
+// StripDirectives removes the merge directives (see MergeDirectiveProperty) from all components of the BOM.
+// The BOM is modified in place, the components are copied (they can be shared with the inputs of a merge).
+func StripDirectives(bom *cyclonedx.BOM) {
+	if bom == nil {
+		return
+	}
+
+	strip := func(c cyclonedx.Component) cyclonedx.Component {
+		if c.Properties == nil {
+			return c
+		}
+
+		var properties []cyclonedx.Property
+		for _, property := range *c.Properties {
+			if property.Name != MergeDirectiveProperty {
+				properties = append(properties, property)
+			}
+		}
+
+		if len(properties) == 0 {
+			c.Properties = nil
+		} else {
+			c.Properties = &properties
+		}
+		return c
+	}
+
+	if bom.Metadata != nil && bom.Metadata.Component != nil {
+		root := strip(*bom.Metadata.Component)
+		root.Components = mapComponentSlice(root.Components, strip)
+		bom.Metadata.Component = &root
+	}
+	bom.Components = mapComponentSlice(bom.Components, strip)
+}
@@ -0,0 +1,202 @@
+package mergex
+
+import (
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func directiveProperties(directive MergeDirective) *[]cyclonedx.Property {
+	return &[]cyclonedx.Property{{Name: MergeDirectiveProperty, Value: string(directive)}}
+}
+
+func TestMergeBomInline(t *testing.T) {
+	app := func() *cyclonedx.BOM {
+		return &cyclonedx.BOM{
+			Metadata: &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "app", Name: "app"}},
+			Components: &[]cyclonedx.Component{
+				{BOMRef: "pkg:npm/lodash@4.17.21", Name: "lodash", Version: "4.17.21"},
+			},
+			Dependencies: &[]cyclonedx.Dependency{
+				{Ref: "app", Dependencies: &[]string{"pkg:npm/lodash@4.17.21"}},
+			},
+		}
+	}
+
+	wrapper := func() *cyclonedx.BOM {
+		return &cyclonedx.BOM{
+			Metadata: &cyclonedx.Metadata{Component: &cyclonedx.Component{
+				BOMRef:     "windows-binary",
+				Name:       "windows-binary",
+				Properties: directiveProperties(DirectiveInline),
+				Components: &[]cyclonedx.Component{
+					{BOMRef: "vcredist", Name: "Microsoft Visual C++ Redistributable", Version: "14.0"},
+				},
+			}},
+			Components: &[]cyclonedx.Component{
+				{BOMRef: "openssl", Name: "openssl", Version: "3.0.0"},
+			},
+			Dependencies: &[]cyclonedx.Dependency{
+				{Ref: "windows-binary", Dependencies: &[]string{"vcredist", "openssl"}},
+			},
+		}
+	}
+
+	assertInlined := func(t *testing.T, merged *cyclonedx.BOM) {
+		require.NotNil(t, merged.Metadata.Component)
+		assert.Equal(t, "app", merged.Metadata.Component.BOMRef)
+		assert.Nil(t, merged.Metadata.Component.Components)
+
+		var refs []string
+		for _, c := range *merged.Components {
+			refs = append(refs, c.BOMRef)
+		}
+		assert.ElementsMatch(t, []string{"pkg:npm/lodash@4.17.21", "openssl", "vcredist"}, refs)
+
+		require.Len(t, *merged.Dependencies, 1)
+		assert.Equal(t, "app", (*merged.Dependencies)[0].Ref)
+		assert.ElementsMatch(t, []string{"pkg:npm/lodash@4.17.21", "vcredist", "openssl"}, *(*merged.Dependencies)[0].Dependencies)
+	}
+
+	t.Run("MergeBom", func(t *testing.T) {
+		assertInlined(t, MergeBom(app(), wrapper()))
+	})
+
+	t.Run("MergeBomAsDependency", func(t *testing.T) {
+		assertInlined(t, MergeBomAsDependency(app(), wrapper()))
+	})
+
+	t.Run("MergeBomAsSubtree", func(t *testing.T) {
+		assertInlined(t, MergeBomAsSubtree(app(), wrapper()))
+	})
+
+	t.Run("wrapper first", func(t *testing.T) {
+		assertInlined(t, MergeBoms([]*cyclonedx.BOM{wrapper(), app()}))
+	})
+
+	t.Run("only wrappers", func(t *testing.T) {
+		merged := MergeBoms([]*cyclonedx.BOM{wrapper(), wrapper()})
+		// there is no root component to dissolve the wrapper into, it is kept for a later merge
+		assert.True(t, IsInline(merged))
+		assertInlined(t, MergeBom(app(), merged))
+	})
+
+	t.Run("inputs are not modified", func(t *testing.T) {
+		a, b := app(), wrapper()
+		MergeBom(a, b)
+		assert.Equal(t, app(), a)
+		assert.Equal(t, wrapper(), b)
+	})
+}
+
+func TestMergeBomNest(t *testing.T) {
+	product := &cyclonedx.BOM{
+		Metadata: &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "product", Name: "product"}},
+	}
+
+	service := &cyclonedx.BOM{
+		Metadata: &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "service", Name: "service", Properties: directiveProperties(DirectiveNest)}},
+		Components: &[]cyclonedx.Component{
+			{BOMRef: "pkg:golang/github.com/google/uuid@v1.6.0", Name: "github.com/google/uuid", Version: "v1.6.0"},
+		},
+		Dependencies: &[]cyclonedx.Dependency{
+			{Ref: "service", Dependencies: &[]string{"pkg:golang/github.com/google/uuid@v1.6.0"}},
+		},
+	}
+
+	for name, merge := range map[string]func(a, b *cyclonedx.BOM, opts ...Option) *cyclonedx.BOM{
+		"MergeBom":             MergeBom,
+		"MergeBomAsDependency": MergeBomAsDependency,
+	} {
+		t.Run(name, func(t *testing.T) {
+			merged := merge(product, service)
+
+			assert.Equal(t, "product", merged.Metadata.Component.BOMRef)
+			assert.Nil(t, merged.Components)
+			require.NotNil(t, merged.Metadata.Component.Components)
+			require.Len(t, *merged.Metadata.Component.Components, 1)
+
+			subtree := (*merged.Metadata.Component.Components)[0]
+			assert.Equal(t, "service", subtree.BOMRef)
+			require.Len(t, *subtree.Components, 1)
+
+			assert.Contains(t, *merged.Dependencies, cyclonedx.Dependency{Ref: "product", Dependencies: &[]string{"service"}})
+		})
+	}
+}
+
+func TestMergeBomReplaceRoot(t *testing.T) {
+	scan := &cyclonedx.BOM{
+		Metadata: &cyclonedx.Metadata{Component: &cyclonedx.Component{
+			BOMRef:     "scan",
+			Name:       "checkout",
+			Components: &[]cyclonedx.Component{{BOMRef: "module", Name: "module"}},
+		}},
+		Components: &[]cyclonedx.Component{
+			{BOMRef: "pkg:npm/react@18.0.0", Name: "react", Version: "18.0.0"},
+		},
+		Dependencies: &[]cyclonedx.Dependency{
+			{Ref: "scan", Dependencies: &[]string{"pkg:npm/react@18.0.0"}},
+		},
+	}
+
+	curated := &cyclonedx.BOM{
+		Metadata: &cyclonedx.Metadata{Component: &cyclonedx.Component{
+			BOMRef:     "product",
+			Name:       "Product",
+			Version:    "2.0",
+			Properties: directiveProperties(DirectiveReplaceRoot),
+		}},
+	}
+
+	merged := MergeBom(scan, curated)
+
+	root := merged.Metadata.Component
+	assert.Equal(t, "product", root.BOMRef)
+	assert.Equal(t, "Product", root.Name)
+	assert.Equal(t, "2.0", root.Version)
+	require.NotNil(t, root.Components)
+	assert.Equal(t, "module", (*root.Components)[0].BOMRef)
+
+	assert.Equal(t, []cyclonedx.Dependency{
+		{Ref: "product", Dependencies: &[]string{"pkg:npm/react@18.0.0"}},
+	}, *merged.Dependencies)
+
+	// inputs are not modified
+	assert.Equal(t, "scan", scan.Metadata.Component.BOMRef)
+	assert.Equal(t, "scan", (*scan.Dependencies)[0].Ref)
+}
+
+func TestStripDirectives(t *testing.T) {
+	input := &cyclonedx.BOM{
+		Metadata: &cyclonedx.Metadata{Component: &cyclonedx.Component{
+			BOMRef:     "root",
+			Properties: directiveProperties(DirectiveReplaceRoot),
+			Components: &[]cyclonedx.Component{
+				{BOMRef: "nested", Properties: directiveProperties(DirectiveNest)},
+			},
+		}},
+		Components: &[]cyclonedx.Component{
+			{BOMRef: "component", Properties: &[]cyclonedx.Property{
+				{Name: MergeDirectiveProperty, Value: string(DirectiveInline)},
+				{Name: "observer:source", Value: "scan"},
+			}},
+		},
+	}
+
+	bom := copyBOM(input)
+	StripDirectives(bom)
+
+	assert.Nil(t, bom.Metadata.Component.Properties)
+	assert.Nil(t, (*bom.Metadata.Component.Components)[0].Properties)
+	assert.Equal(t, &[]cyclonedx.Property{{Name: "observer:source", Value: "scan"}}, (*bom.Components)[0].Properties)
+
+	// the components shared with the input are not modified
+	assert.Equal(t, DirectiveReplaceRoot, rootDirective(input))
+	assert.Len(t, *(*input.Metadata.Component.Components)[0].Properties, 1)
+	assert.Len(t, *(*input.Components)[0].Properties, 2)
+
+	StripDirectives(nil)
+}
@@ -0,0 +1,449 @@
+package mergex
+
+import (
+	"fmt"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+// GraphIssueType is the kind of problem found in the dependency graph of a BOM
+type GraphIssueType string
+
+const (
+	// IssueDuplicateRef is a BOMRef used by more than one component or service
+	IssueDuplicateRef GraphIssueType = "duplicate-ref"
+	// IssueDanglingRef is a ref in the dependencies that points to no component or service
+	IssueDanglingRef GraphIssueType = "dangling-ref"
+	// IssueOrphan is a component that the dependencies of the root component do not reach
+	IssueOrphan GraphIssueType = "orphan"
+	// IssueCycle is a cycle in the dependency graph
+	IssueCycle GraphIssueType = "cycle"
+)
+
+// GraphIssue is a problem with the dependency graph of a BOM, see ValidateGraph
+type GraphIssue struct {
+	Type GraphIssueType `json:"type"`
+	Ref  string         `json:"ref"`
+	// Refs are the dependencies that reference a dangling ref, or the refs of a cycle (starting and ending with Ref)
+	Refs []string `json:"refs,omitempty"`
+	// Repair is how RepairGraph repaired the issue (empty if it was not repaired)
+	Repair string `json:"repair,omitempty"`
+}
+
+// WithAttachOrphans makes RepairGraph add the orphans (components that are not reachable from the root component)
+// to the dependencies of the root component. By default they are only reported.
+func WithAttachOrphans() Option {
+	return func(o *options) {
+		o.attachOrphans = true
+	}
+}
+
+// WithGraphIssues appends the problems found (and repaired) by RepairGraph to issues. MergeBoms,
+// MergeBomsAsDependency and MergeBomsAsSubtree repair the merged BOM, this reports what they repaired.
+func WithGraphIssues(issues *[]GraphIssue) Option {
+	return func(o *options) {
+		o.graphIssues = issues
+	}
+}
+
+// ValidateGraph returns the problems with the dependency graph of the BOM: BOMRefs that are used more than once,
+// dependency refs that point to no component or service, components that are not reachable from the root component
+// (only the first component of every unreachable part of the graph is reported) and cycles.
+func ValidateGraph(bom *cyclonedx.BOM) []GraphIssue {
+	if bom == nil {
+		return nil
+	}
+
+	return checkGraph(copyBOM(bom), options{}, false)
+}
+
+// RepairGraph repairs the problems found by ValidateGraph and returns them, with the repair that was made:
+//   - duplicate BOMRefs are renamed (the first component or service keeps the BOMRef)
+//   - dangling refs are re-pointed to the component with the same normalized purl, or removed
+//   - orphans are attached to the root component with WithAttachOrphans, otherwise they are only reported
+//   - cycles are only reported
+//
+// Returns a new BOM struct without modifying the input.
+func RepairGraph(bom *cyclonedx.BOM, opts ...Option) (*cyclonedx.BOM, []GraphIssue) {
+	if bom == nil {
+		return nil, nil
+	}
+
+	o := newOptions(opts)
+	result := copyBOM(bom)
+	issues := checkGraph(result, o, true)
+	if o.graphIssues != nil {
+		*o.graphIssues = append(*o.graphIssues, issues...)
+	}
+	return result, issues
+}
+
+// checkGraph finds (and repairs if repair is true) the problems with the dependency graph of the BOM
+func checkGraph(bom *cyclonedx.BOM, o options, repair bool) []GraphIssue {
+	var issues []GraphIssue
+	issues = append(issues, uniqueRefs(bom, repair)...)
+	issues = append(issues, danglingRefs(bom, repair)...)
+	issues = append(issues, orphans(bom, repair && o.attachOrphans)...)
+	issues = append(issues, cycles(bom)...)
+	return issues
+}
+
+// uniqueRefs finds BOMRefs that are used more than once. If repair is true, duplicates that describe the same
+// component (see sameComponent) are merged into the first component with the BOMRef and the others are renamed.
+// References to a duplicate BOMRef keep pointing to the first component or service with the BOMRef.
+func uniqueRefs(bom *cyclonedx.BOM, repair bool) []GraphIssue {
+	// the first component with each BOMRef, merged with its duplicates
+	firsts := map[string]*cyclonedx.Component{}
+	first := func(c *cyclonedx.Component) {
+		if c.BOMRef == "" {
+			return
+		}
+		if existing, found := firsts[c.BOMRef]; !found {
+			component := *c
+			firsts[c.BOMRef] = &component
+		} else if sameComponent(*existing, *c) {
+			*existing = MergeComponent(*existing, *c)
+		}
+	}
+	if hasRoot(bom) {
+		walkComponents(bom.Metadata.Component, first)
+	}
+	walkComponentSlice(bom.Components, first)
+
+	used := graphRefs(bom)
+	kept := map[string]bool{}
+	var issues []GraphIssue
+
+	rename := func(ref string) string {
+		issue := GraphIssue{Type: IssueDuplicateRef, Ref: ref}
+		if repair {
+			renamed := ref
+			for i := 2; used[renamed]; i++ {
+				renamed = fmt.Sprintf("%s-%d", ref, i)
+			}
+			used[renamed] = true
+			kept[renamed] = true
+			issue.Repair = "renamed to " + renamed
+			ref = renamed
+		}
+		issues = append(issues, issue)
+		return ref
+	}
+
+	// check returns the component to keep, false if it is a duplicate that is merged into the first one
+	check := func(c cyclonedx.Component) (cyclonedx.Component, bool) {
+		switch {
+		case c.BOMRef == "":
+		case !kept[c.BOMRef]:
+			kept[c.BOMRef] = true
+			if repair {
+				c = *firsts[c.BOMRef]
+			}
+		case sameComponent(*firsts[c.BOMRef], c):
+			issues = append(issues, GraphIssue{Type: IssueDuplicateRef, Ref: c.BOMRef, Repair: repairText(repair, "merged")})
+			return c, !repair
+		default:
+			c.BOMRef = rename(c.BOMRef)
+		}
+		return c, true
+	}
+
+	var checkSlice func(components *[]cyclonedx.Component) *[]cyclonedx.Component
+	checkSlice = func(components *[]cyclonedx.Component) *[]cyclonedx.Component {
+		if components == nil {
+			return nil
+		}
+
+		result := make([]cyclonedx.Component, 0, len(*components))
+		for _, component := range *components {
+			component, keep := check(component)
+			if !keep {
+				continue
+			}
+			component.Components = checkSlice(component.Components)
+			result = append(result, component)
+		}
+		return &result
+	}
+
+	if hasRoot(bom) {
+		root, _ := check(*bom.Metadata.Component)
+		root.Components = checkSlice(root.Components)
+		bom.Metadata.Component = &root
+	}
+	bom.Components = checkSlice(bom.Components)
+	bom.Services = mapServiceSlice(bom.Services, func(s cyclonedx.Service) cyclonedx.Service {
+		if s.BOMRef == "" {
+			return s
+		}
+		if kept[s.BOMRef] {
+			s.BOMRef = rename(s.BOMRef)
+		}
+		kept[s.BOMRef] = true
+		return s
+	})
+
+	return issues
+}
+
+// sameComponent returns true if a and b (with the same BOMRef) describe the same component: they have the same
+// identity (see componentIdentity) or, without an identity, the same type and name
+func sameComponent(a, b cyclonedx.Component) bool {
+	if identity := componentIdentity(a); identity != "" {
+		return identity == componentIdentity(b)
+	}
+	return componentIdentity(b) == "" && a.Type == b.Type && a.Name == b.Name
+}
+
+func repairText(repair bool, text string) string {
+	if !repair {
+		return ""
+	}
+	return text
+}
+
+// danglingRefs finds dependency refs that point to no component or service. If repair is true they are re-pointed
+// to the component with the same normalized purl (for refs that are purls) or removed.
+func danglingRefs(bom *cyclonedx.BOM, repair bool) []GraphIssue {
+	if bom.Dependencies == nil {
+		return nil
+	}
+
+	refs := graphRefs(bom)
+
+	// components by identity, to re-point purl refs
+	identities := map[string]string{}
+	identify := func(c *cyclonedx.Component) {
+		if identity := componentIdentity(*c); identity != "" && c.BOMRef != "" {
+			if _, found := identities[identity]; !found {
+				identities[identity] = c.BOMRef
+			}
+		}
+	}
+	if hasRoot(bom) {
+		walkComponentSlice(bom.Metadata.Component.Components, identify)
+	}
+	walkComponentSlice(bom.Components, identify)
+
+	index := map[string]int{}
+	var issues []GraphIssue
+	dangling := func(ref string) int {
+		i, found := index[ref]
+		if !found {
+			i = len(issues)
+			index[ref] = i
+			issues = append(issues, GraphIssue{Type: IssueDanglingRef, Ref: ref})
+		}
+		return i
+	}
+
+	for _, dependency := range *bom.Dependencies {
+		if !refs[dependency.Ref] {
+			dangling(dependency.Ref)
+		}
+		if dependency.Dependencies == nil {
+			continue
+		}
+		for _, ref := range *dependency.Dependencies {
+			if !refs[ref] {
+				i := dangling(ref)
+				issues[i].Refs = append(issues[i].Refs, dependency.Ref)
+			}
+		}
+	}
+
+	if !repair || len(issues) == 0 {
+		return issues
+	}
+
+	renames := map[string]string{}
+	removed := map[string]bool{}
+	for i, issue := range issues {
+		if target, found := identities[componentIdentity(cyclonedx.Component{PackageURL: issue.Ref})]; found {
+			renames[issue.Ref] = target
+			issues[i].Repair = "re-pointed to " + target
+		} else {
+			removed[issue.Ref] = true
+			issues[i].Repair = "removed"
+		}
+	}
+
+	renameRefs(bom, renames)
+	removeDependencies(bom, removed)
+
+	return issues
+}
+
+// orphans finds the components that are not reachable from the root component (through the dependencies, or as a
+// subcomponent of a reachable component) and attaches them to the root component if attach is true. Only the first
+// component of every unreachable part of the graph is reported (and attached), the rest is reachable through it.
+func orphans(bom *cyclonedx.BOM, attach bool) []GraphIssue {
+	if !hasRoot(bom) || bom.Metadata.Component.BOMRef == "" {
+		return nil
+	}
+	root := bom.Metadata.Component.BOMRef
+
+	edges := map[string][]string{}
+	if bom.Dependencies != nil {
+		for _, dependency := range *bom.Dependencies {
+			if dependency.Dependencies != nil {
+				edges[dependency.Ref] = append(edges[dependency.Ref], *dependency.Dependencies...)
+			}
+		}
+	}
+
+	// subcomponents are reachable through their parent
+	var components []string
+	var collect func(parent string, children *[]cyclonedx.Component)
+	collect = func(parent string, children *[]cyclonedx.Component) {
+		if children == nil {
+			return
+		}
+		for _, child := range *children {
+			if child.BOMRef != "" {
+				components = append(components, child.BOMRef)
+				if parent != "" {
+					edges[parent] = append(edges[parent], child.BOMRef)
+				}
+			}
+			collect(child.BOMRef, child.Components)
+		}
+	}
+	collect(root, bom.Metadata.Component.Components)
+	collect("", bom.Components)
+
+	reachable := map[string]bool{}
+	visit := func(ref string) {
+		queue := []string{ref}
+		reachable[ref] = true
+		for len(queue) > 0 {
+			next := queue[0]
+			queue = queue[1:]
+			for _, dependency := range edges[next] {
+				if !reachable[dependency] {
+					reachable[dependency] = true
+					queue = append(queue, dependency)
+				}
+			}
+		}
+	}
+	visit(root)
+
+	var issues []GraphIssue
+	var attached []string
+	for _, ref := range components {
+		if reachable[ref] {
+			continue
+		}
+
+		issue := GraphIssue{Type: IssueOrphan, Ref: ref}
+		if attach {
+			issue.Repair = "attached to " + root
+			attached = append(attached, ref)
+		}
+		issues = append(issues, issue)
+		visit(ref)
+	}
+
+	if len(attached) > 0 {
+		bom.Dependencies = mergeDependencySlice(bom.Dependencies, &[]cyclonedx.Dependency{{Ref: root, Dependencies: &attached}})
+	}
+
+	return issues
+}
+
+// cycles finds the cycles in the dependency graph, every cycle is reported once
+func cycles(bom *cyclonedx.BOM) []GraphIssue {
+	if bom.Dependencies == nil {
+		return nil
+	}
+
+	edges := map[string][]string{}
+	for _, dependency := range *bom.Dependencies {
+		if dependency.Dependencies != nil {
+			edges[dependency.Ref] = append(edges[dependency.Ref], *dependency.Dependencies...)
+		}
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	var path []string
+	var issues []GraphIssue
+
+	var visit func(ref string)
+	visit = func(ref string) {
+		state[ref] = visiting
+		path = append(path, ref)
+
+		for _, dependency := range edges[ref] {
+			switch state[dependency] {
+			case visiting:
+				// the path from the dependency to ref (and back to the dependency) is a cycle
+				for i := len(path) - 1; i >= 0; i-- {
+					if path[i] == dependency {
+						cycle := append(append([]string{}, path[i:]...), dependency)
+						issues = append(issues, GraphIssue{Type: IssueCycle, Ref: dependency, Refs: cycle})
+						break
+					}
+				}
+			case 0:
+				visit(dependency)
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[ref] = visited
+	}
+
+	if hasRoot(bom) && bom.Metadata.Component.BOMRef != "" {
+		visit(bom.Metadata.Component.BOMRef)
+	}
+	for _, dependency := range *bom.Dependencies {
+		if state[dependency.Ref] == 0 {
+			visit(dependency.Ref)
+		}
+	}
+
+	return issues
+}
+
+// graphRefs returns the BOMRefs of the root component, the components and the services (including nested ones)
+func graphRefs(bom *cyclonedx.BOM) map[string]bool {
+	refs := map[string]bool{}
+	add := func(c *cyclonedx.Component) {
+		if c.BOMRef != "" {
+			refs[c.BOMRef] = true
+		}
+	}
+
+	if hasRoot(bom) {
+		walkComponents(bom.Metadata.Component, add)
+	}
+	walkComponentSlice(bom.Components, add)
+	mapServiceSlice(bom.Services, func(s cyclonedx.Service) cyclonedx.Service {
+		if s.BOMRef != "" {
+			refs[s.BOMRef] = true
+		}
+		return s
+	})
+
+	return refs
+}
+
+// mapServiceSlice returns a copy of the services (and their nested services) with fn applied to each service
+func mapServiceSlice(services *[]cyclonedx.Service, fn func(s cyclonedx.Service) cyclonedx.Service) *[]cyclonedx.Service {
+	if services == nil {
+		return nil
+	}
+
+	result := make([]cyclonedx.Service, 0, len(*services))
+	for _, service := range *services {
+		service = fn(service)
+		service.Services = mapServiceSlice(service.Services, fn)
+		result = append(result, service)
+	}
+	return &result
+}
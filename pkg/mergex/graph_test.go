@@ -0,0 +1,174 @@
+package mergex
+
+import (
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateGraph(t *testing.T) {
+	bom := &cyclonedx.BOM{
+		Metadata: &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "app", Name: "app"}},
+		Components: &[]cyclonedx.Component{
+			{BOMRef: "a", Name: "a"},
+			{BOMRef: "b", Name: "b"},
+			{BOMRef: "orphan", Name: "orphan"},
+			{BOMRef: "orphan-dependency", Name: "orphan-dependency"},
+			{BOMRef: "a", Name: "not-a"},
+		},
+		Dependencies: &[]cyclonedx.Dependency{
+			{Ref: "app", Dependencies: &[]string{"a", "missing"}},
+			{Ref: "a", Dependencies: &[]string{"b"}},
+			{Ref: "b", Dependencies: &[]string{"app", "missing"}},
+			{Ref: "orphan", Dependencies: &[]string{"orphan-dependency"}},
+			{Ref: "gone"},
+		},
+	}
+
+	issues := ValidateGraph(bom)
+
+	assert.Equal(t, []GraphIssue{
+		{Type: IssueDuplicateRef, Ref: "a"},
+		{Type: IssueDanglingRef, Ref: "missing", Refs: []string{"app", "b"}},
+		{Type: IssueDanglingRef, Ref: "gone"},
+		{Type: IssueOrphan, Ref: "orphan"},
+		{Type: IssueCycle, Ref: "app", Refs: []string{"app", "a", "b", "app"}},
+	}, issues)
+
+	// the input is not modified
+	assert.Len(t, *bom.Components, 5)
+	assert.Len(t, *bom.Dependencies, 5)
+
+	assert.Nil(t, ValidateGraph(nil))
+	assert.Empty(t, ValidateGraph(&cyclonedx.BOM{}))
+}
+
+func TestRepairGraph(t *testing.T) {
+	bom := &cyclonedx.BOM{
+		Metadata: &cyclonedx.Metadata{Component: &cyclonedx.Component{
+			BOMRef: "app",
+			Name:   "app",
+			Components: &[]cyclonedx.Component{
+				{BOMRef: "pkg:npm/react@18.0.0", Name: "react", Version: "18.0.0", PackageURL: "pkg:npm/react@18.0.0"},
+			},
+		}},
+		Components: &[]cyclonedx.Component{
+			{BOMRef: "pkg:npm/lodash@4.17.21", Name: "lodash", Version: "4.17.21", PackageURL: "pkg:npm/lodash@4.17.21"},
+			// same component as the subcomponent of the root component
+			{BOMRef: "pkg:npm/react@18.0.0", Name: "react", Version: "18.0.0", PackageURL: "pkg:npm/react@18.0.0", Licenses: &cyclonedx.Licenses{{License: &cyclonedx.License{ID: "MIT"}}}},
+			// different component with the same BOMRef
+			{BOMRef: "pkg:npm/lodash@4.17.21", Name: "lodash-es", Version: "4.17.21", PackageURL: "pkg:npm/lodash-es@4.17.21"},
+			{BOMRef: "orphan", Name: "orphan"},
+		},
+		Services: &[]cyclonedx.Service{
+			{BOMRef: "orphan", Name: "api"},
+		},
+		Dependencies: &[]cyclonedx.Dependency{
+			{Ref: "app", Dependencies: &[]string{"pkg:npm/lodash@v4.17.21", "missing"}},
+		},
+	}
+
+	t.Run("default", func(t *testing.T) {
+		repaired, issues := RepairGraph(bom)
+
+		assert.Equal(t, []GraphIssue{
+			{Type: IssueDuplicateRef, Ref: "pkg:npm/react@18.0.0", Repair: "merged"},
+			{Type: IssueDuplicateRef, Ref: "pkg:npm/lodash@4.17.21", Repair: "renamed to pkg:npm/lodash@4.17.21-2"},
+			{Type: IssueDuplicateRef, Ref: "orphan", Repair: "renamed to orphan-2"},
+			{Type: IssueDanglingRef, Ref: "pkg:npm/lodash@v4.17.21", Refs: []string{"app"}, Repair: "re-pointed to pkg:npm/lodash@4.17.21"},
+			{Type: IssueDanglingRef, Ref: "missing", Refs: []string{"app"}, Repair: "removed"},
+			{Type: IssueOrphan, Ref: "pkg:npm/lodash@4.17.21-2"},
+			{Type: IssueOrphan, Ref: "orphan"},
+		}, issues)
+
+		// the duplicate react is merged into the subcomponent of the root component
+		require.Len(t, *repaired.Metadata.Component.Components, 1)
+		assert.NotNil(t, (*repaired.Metadata.Component.Components)[0].Licenses)
+
+		var refs []string
+		for _, c := range *repaired.Components {
+			refs = append(refs, c.BOMRef)
+		}
+		assert.Equal(t, []string{"pkg:npm/lodash@4.17.21", "pkg:npm/lodash@4.17.21-2", "orphan"}, refs)
+		assert.Equal(t, "orphan-2", (*repaired.Services)[0].BOMRef)
+
+		assert.Equal(t, []cyclonedx.Dependency{
+			{Ref: "app", Dependencies: &[]string{"pkg:npm/lodash@4.17.21"}},
+		}, *repaired.Dependencies)
+
+		// only the orphans are left
+		for _, issue := range ValidateGraph(repaired) {
+			assert.Equal(t, IssueOrphan, issue.Type, issue)
+		}
+	})
+
+	t.Run("attach orphans", func(t *testing.T) {
+		repaired, issues := RepairGraph(bom, WithAttachOrphans())
+
+		assert.Equal(t, GraphIssue{Type: IssueOrphan, Ref: "orphan", Repair: "attached to app"}, issues[len(issues)-1])
+		assert.Equal(t, []cyclonedx.Dependency{
+			{Ref: "app", Dependencies: &[]string{"pkg:npm/lodash@4.17.21", "pkg:npm/lodash@4.17.21-2", "orphan"}},
+		}, *repaired.Dependencies)
+		assert.Empty(t, ValidateGraph(repaired))
+	})
+
+	// the input is not modified
+	assert.Len(t, *bom.Components, 4)
+	assert.Nil(t, (*bom.Metadata.Component.Components)[0].Licenses)
+	assert.Equal(t, "orphan", (*bom.Services)[0].BOMRef)
+	assert.Equal(t, []string{"pkg:npm/lodash@v4.17.21", "missing"}, *(*bom.Dependencies)[0].Dependencies)
+}
+
+func TestMergeBomsRepairsGraph(t *testing.T) {
+	a := &cyclonedx.BOM{
+		Metadata:   &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "app", Name: "app"}},
+		Components: &[]cyclonedx.Component{{BOMRef: "a", Name: "a"}},
+		Dependencies: &[]cyclonedx.Dependency{
+			{Ref: "app", Dependencies: &[]string{"a", "filtered"}},
+		},
+	}
+
+	b := &cyclonedx.BOM{
+		Metadata:   &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "lib", Name: "lib"}},
+		Components: &[]cyclonedx.Component{{BOMRef: "b", Name: "b"}},
+		Dependencies: &[]cyclonedx.Dependency{
+			{Ref: "lib", Dependencies: &[]string{"b"}},
+			{Ref: "b", Dependencies: &[]string{"filtered"}},
+		},
+	}
+
+	for name, merge := range map[string]func(boms []*cyclonedx.BOM, opts ...Option) *cyclonedx.BOM{
+		"MergeBoms":             MergeBoms,
+		"MergeBomsAsDependency": MergeBomsAsDependency,
+		"MergeBomsAsSubtree":    MergeBomsAsSubtree,
+	} {
+		t.Run(name, func(t *testing.T) {
+			var issues []GraphIssue
+			merged := merge([]*cyclonedx.BOM{a, b}, WithGraphIssues(&issues))
+
+			// the repairs are reported
+			assert.Contains(t, issues, GraphIssue{Type: IssueDanglingRef, Ref: "filtered", Refs: []string{"app", "b"}, Repair: "removed"})
+
+			for _, issue := range ValidateGraph(merged) {
+				assert.Equal(t, IssueOrphan, issue.Type, issue)
+			}
+			for _, dependency := range *merged.Dependencies {
+				assert.NotEqual(t, "filtered", dependency.Ref)
+				assert.NotContains(t, *dependency.Dependencies, "filtered")
+			}
+		})
+
+		t.Run(name+" single BOM", func(t *testing.T) {
+			var issues []GraphIssue
+			merged := merge([]*cyclonedx.BOM{a}, WithGraphIssues(&issues))
+
+			assert.Equal(t, []GraphIssue{{Type: IssueDanglingRef, Ref: "filtered", Refs: []string{"app"}, Repair: "removed"}}, issues)
+			assert.Equal(t, []string{"a"}, *(*merged.Dependencies)[0].Dependencies)
+
+			// the input is not modified
+			assert.Equal(t, []string{"a", "filtered"}, *(*a.Dependencies)[0].Dependencies)
+		})
+	}
+}
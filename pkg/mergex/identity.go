@@ -0,0 +1,228 @@
+package mergex
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/package-url/packageurl-go"
+)
+
+// versionPrefixTypes are purl types where a "v" version prefix is not part of the version (v1.2.3 == 1.2.3)
+var versionPrefixTypes = map[string]bool{
+	packageurl.TypeCargo:    true,
+	packageurl.TypeComposer: true,
+	packageurl.TypeGem:      true,
+	packageurl.TypeHex:      true,
+	packageurl.TypeNPM:      true,
+	packageurl.TypeNuget:    true,
+	packageurl.TypePub:      true,
+	packageurl.TypePyPi:     true,
+}
+
+// NormalizePurl returns the canonical form of a purl used to compare components. On top of the purl spec
+// normalization (lower case type, sorted qualifiers and per type case rules for namespace and name) it
+//   - lower cases nuget names and pypi/nuget versions (both are case-insensitive)
+//   - removes "v" version prefixes for types that don't use them
+//   - moves the deb/rpm epoch qualifier into the version (1:2.3 and 2.3?epoch=1 are the same)
+//
+// Invalid purls are returned as is.
+func NormalizePurl(purl string) string {
+	p, err := packageurl.FromString(strings.TrimSpace(purl))
+	if err != nil {
+		return purl
+	}
+
+	p.Version = strings.TrimSpace(p.Version)
+
+	switch p.Type {
+	case packageurl.TypeNuget:
+		p.Name = strings.ToLower(p.Name)
+		p.Version = strings.ToLower(p.Version)
+	case packageurl.TypePyPi:
+		p.Version = strings.ToLower(p.Version)
+	case packageurl.TypeDebian, packageurl.TypeRPM:
+		var qualifiers packageurl.Qualifiers
+		for _, q := range p.Qualifiers {
+			if q.Key == "epoch" {
+				if p.Version != "" && !strings.Contains(p.Version, ":") && q.Value != "0" {
+					p.Version = q.Value + ":" + p.Version
+				}
+				continue
+			}
+			qualifiers = append(qualifiers, q)
+		}
+		p.Qualifiers = qualifiers
+	}
+
+	if versionPrefixTypes[p.Type] && len(p.Version) > 1 && (p.Version[0] == 'v' || p.Version[0] == 'V') && p.Version[1] >= '0' && p.Version[1] <= '9' {
+		p.Version = p.Version[1:]
+	}
+
+	return p.ToString()
+}
+
+// componentIdentity returns the key used to find duplicate components: the normalized purl or, for components
+// without a purl, name+group+version. Files and components without a name have no identity.
+func componentIdentity(component cyclonedx.Component) string {
+	if component.PackageURL != "" {
+		return "purl:" + NormalizePurl(component.PackageURL)
+	}
+
+	if component.Name == "" || component.Type == cyclonedx.ComponentTypeFile {
+		return ""
+	}
+
+	return "component:" + component.Group + "|" + component.Name + "|" + component.Version
+}
+
+// deduplicateComponents merges top level components with the same identity (see componentIdentity) using merge.
+// The first component is kept and every reference to the BOMRefs of its duplicates is rewritten to its BOMRef.
+func deduplicateComponents(bom *cyclonedx.BOM, merge func(a, b cyclonedx.Component) cyclonedx.Component) {
+	if bom == nil || bom.Components == nil {
+		return
+	}
+
+	// components with the same identity as the root component are not merged (that would be a self-dependency)
+	var rootIdentity string
+	if bom.Metadata != nil && bom.Metadata.Component != nil {
+		rootIdentity = componentIdentity(*bom.Metadata.Component)
+	}
+
+	survivors := map[string]int{}
+	renames := map[string]string{}
+	var result []cyclonedx.Component
+
+	for _, component := range *bom.Components {
+		identity := componentIdentity(component)
+		if identity == "" || identity == rootIdentity {
+			result = append(result, component)
+			continue
+		}
+
+		i, found := survivors[identity]
+		if !found {
+			survivors[identity] = len(result)
+			result = append(result, component)
+			continue
+		}
+
+		if component.BOMRef != "" && component.BOMRef != result[i].BOMRef {
+			if result[i].BOMRef == "" {
+				result[i].BOMRef = component.BOMRef
+			} else {
+				renames[component.BOMRef] = result[i].BOMRef
+			}
+		}
+		result[i] = merge(result[i], component)
+	}
+
+	if len(result) == len(*bom.Components) {
+		return
+	}
+
+	bom.Components = &result
+	renameRefs(bom, renames)
+}
+
+// renameRefs rewrites references to components (dependencies, compositions and vulnerabilities) according to
+// renames (old BOMRef -> new BOMRef). Dependencies that end up with the same ref are merged.
+func renameRefs(bom *cyclonedx.BOM, renames map[string]string) {
+	if len(renames) == 0 {
+		return
+	}
+
+	rename := func(ref string) string {
+		if renamed, found := renames[ref]; found {
+			return renamed
+		}
+		return ref
+	}
+
+	renameSlice := func(refs *[]string) *[]string {
+		if refs == nil {
+			return nil
+		}
+
+		result := make([]string, 0, len(*refs))
+		seen := map[string]bool{}
+		for _, ref := range *refs {
+			ref = rename(ref)
+			if !seen[ref] {
+				seen[ref] = true
+				result = append(result, ref)
+			}
+		}
+		return &result
+	}
+
+	if bom.Dependencies != nil {
+		var dependencies []cyclonedx.Dependency
+		index := map[string]int{}
+
+		for _, dependency := range *bom.Dependencies {
+			dependency.Ref = rename(dependency.Ref)
+			dependency.Dependencies = renameSlice(dependency.Dependencies)
+
+			// drop self references created by the rename (an empty list stays an empty list, not null)
+			if dependency.Dependencies != nil {
+				filtered := make([]string, 0, len(*dependency.Dependencies))
+				for _, ref := range *dependency.Dependencies {
+					if ref != dependency.Ref {
+						filtered = append(filtered, ref)
+					}
+				}
+				dependency.Dependencies = &filtered
+			}
+
+			if i, found := index[dependency.Ref]; found {
+				dependencies[i] = mergeDependency(dependencies[i], dependency)
+				continue
+			}
+
+			index[dependency.Ref] = len(dependencies)
+			dependencies = append(dependencies, dependency)
+		}
+
+		bom.Dependencies = &dependencies
+	}
+
+	// the slices are copied, they can be shared with the merge inputs
+	if bom.Compositions != nil {
+		compositions := slices.Clone(*bom.Compositions)
+		bom.Compositions = &compositions
+		for i := range compositions {
+			composition := &compositions[i]
+			if composition.Assemblies != nil {
+				assemblies := make([]cyclonedx.BOMReference, 0, len(*composition.Assemblies))
+				for _, ref := range *composition.Assemblies {
+					assemblies = append(assemblies, cyclonedx.BOMReference(rename(string(ref))))
+				}
+				composition.Assemblies = &assemblies
+			}
+			if composition.Dependencies != nil {
+				dependencies := make([]cyclonedx.BOMReference, 0, len(*composition.Dependencies))
+				for _, ref := range *composition.Dependencies {
+					dependencies = append(dependencies, cyclonedx.BOMReference(rename(string(ref))))
+				}
+				composition.Dependencies = &dependencies
+			}
+		}
+	}
+
+	if bom.Vulnerabilities != nil {
+		vulnerabilities := slices.Clone(*bom.Vulnerabilities)
+		bom.Vulnerabilities = &vulnerabilities
+		for i := range vulnerabilities {
+			vulnerability := &vulnerabilities[i]
+			if vulnerability.Affects != nil {
+				affects := make([]cyclonedx.Affects, 0, len(*vulnerability.Affects))
+				for _, affect := range *vulnerability.Affects {
+					affect.Ref = rename(affect.Ref)
+					affects = append(affects, affect)
+				}
+				vulnerability.Affects = &affects
+			}
+		}
+	}
+}
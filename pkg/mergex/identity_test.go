@@ -0,0 +1,162 @@
+package mergex
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizePurl(t *testing.T) {
+	tests := []struct {
+		purl     string
+		expected string
+	}{
+		{"pkg:npm/left-pad@1.3.0", "pkg:npm/left-pad@1.3.0"},
+		{"pkg:NPM/Left-Pad@v1.3.0", "pkg:npm/left-pad@1.3.0"},
+		{"pkg:npm/%40angular/core@16.0.0", "pkg:npm/%40angular/core@16.0.0"},
+		{"pkg:pypi/Django_Rest@3.14.0RC1", "pkg:pypi/django-rest@3.14.0rc1"},
+		{"pkg:nuget/Newtonsoft.Json@13.0.1", "pkg:nuget/newtonsoft.json@13.0.1"},
+		{"pkg:maven/org.Apache/Commons@1.0?type=jar&classifier=sources", "pkg:maven/org.Apache/Commons@1.0?classifier=sources&type=jar"},
+		{"pkg:golang/github.com/Foo/bar@v1.2.3", "pkg:golang/github.com/foo/bar@v1.2.3"},
+		{"pkg:deb/debian/openssl@3.0.11-1?epoch=1&arch=amd64", "pkg:deb/debian/openssl@1%3A3.0.11-1?arch=amd64"},
+		{"pkg:deb/debian/openssl@1:3.0.11-1?arch=amd64", "pkg:deb/debian/openssl@1%3A3.0.11-1?arch=amd64"},
+		{"pkg:rpm/redhat/bash@5.1?epoch=0", "pkg:rpm/redhat/bash@5.1"},
+		{"pkg:generic/openssl@3.0?download_url=", "pkg:generic/openssl@3.0"},
+		{"not a purl", "not a purl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.purl, func(t *testing.T) {
+			assert.Equal(t, tt.expected, NormalizePurl(tt.purl))
+		})
+	}
+}
+
+func TestMergeBom_DeduplicatesByPurl(t *testing.T) {
+	// scalibr uses purls as BOMRefs
+	a := &cyclonedx.BOM{
+		Metadata: &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "root-a", Name: "app"}},
+		Components: &[]cyclonedx.Component{
+			{BOMRef: "pkg:npm/express@4.18.2", Name: "express", Version: "4.18.2", PackageURL: "pkg:npm/express@4.18.2"},
+			{BOMRef: "pkg:npm/debug@2.6.9", Name: "debug", Version: "2.6.9", PackageURL: "pkg:npm/debug@2.6.9"},
+		},
+		Dependencies: &[]cyclonedx.Dependency{
+			{Ref: "root-a", Dependencies: &[]string{"pkg:npm/express@4.18.2"}},
+			{Ref: "pkg:npm/express@4.18.2", Dependencies: &[]string{"pkg:npm/debug@2.6.9"}},
+		},
+	}
+
+	// trivy uses UUIDs as BOMRefs
+	b := &cyclonedx.BOM{
+		Metadata: &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "root-b", Name: "app"}},
+		Components: &[]cyclonedx.Component{
+			{BOMRef: "2f1c6d1e-express", Name: "express", Version: "4.18.2", PackageURL: "pkg:npm/express@4.18.2", Licenses: &cyclonedx.Licenses{{License: &cyclonedx.License{ID: "MIT"}}}},
+			{BOMRef: "9a7e3b2c-debug", Name: "debug", Version: "2.6.9", PackageURL: "pkg:npm/debug@v2.6.9"},
+			{BOMRef: "5d4c3b2a-ms", Name: "ms", Version: "2.0.0", PackageURL: "pkg:npm/ms@2.0.0"},
+		},
+		Dependencies: &[]cyclonedx.Dependency{
+			{Ref: "root-b", Dependencies: &[]string{"2f1c6d1e-express"}},
+			{Ref: "2f1c6d1e-express", Dependencies: &[]string{"9a7e3b2c-debug"}},
+			{Ref: "9a7e3b2c-debug", Dependencies: &[]string{"5d4c3b2a-ms"}},
+		},
+		Vulnerabilities: &[]cyclonedx.Vulnerability{
+			{ID: "CVE-2017-16137", Affects: &[]cyclonedx.Affects{{Ref: "9a7e3b2c-debug"}}},
+		},
+	}
+
+	merged := MergeBom(a, b)
+
+	require.Len(t, *merged.Components, 3)
+	express := (*merged.Components)[0]
+	assert.Equal(t, "pkg:npm/express@4.18.2", express.BOMRef)
+	require.NotNil(t, express.Licenses, "the duplicates are merged")
+
+	dependencies := map[string][]string{}
+	for _, dependency := range *merged.Dependencies {
+		dependencies[dependency.Ref] = *dependency.Dependencies
+	}
+	assert.Equal(t, map[string][]string{
+		"root-a":                 {"pkg:npm/express@4.18.2"},
+		"pkg:npm/express@4.18.2": {"pkg:npm/debug@2.6.9"},
+		"pkg:npm/debug@2.6.9":    {"5d4c3b2a-ms"},
+	}, dependencies)
+
+	assert.Equal(t, "pkg:npm/debug@2.6.9", (*(*merged.Vulnerabilities)[0].Affects)[0].Ref)
+
+	// the inputs are not modified
+	assert.Equal(t, "9a7e3b2c-debug", (*(*b.Vulnerabilities)[0].Affects)[0].Ref)
+	assert.Equal(t, "2f1c6d1e-express", (*b.Dependencies)[1].Ref)
+}
+
+func TestMergeBom_DeduplicatesEmptyDependsOn(t *testing.T) {
+	// trivy and scalibr on the same target, leaf packages have an empty dependsOn
+	a := &cyclonedx.BOM{
+		Metadata:   &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "root", Name: "app"}},
+		Components: &[]cyclonedx.Component{{BOMRef: "pkg:npm/ms@2.0.0", Name: "ms", Version: "2.0.0", PackageURL: "pkg:npm/ms@2.0.0"}},
+		Dependencies: &[]cyclonedx.Dependency{
+			{Ref: "root", Dependencies: &[]string{"pkg:npm/ms@2.0.0"}},
+			{Ref: "pkg:npm/ms@2.0.0", Dependencies: &[]string{}},
+		},
+	}
+	b := &cyclonedx.BOM{
+		Metadata: &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "root", Name: "app"}},
+		Components: &[]cyclonedx.Component{
+			{BOMRef: "5d4c3b2a-ms", Name: "ms", Version: "2.0.0", PackageURL: "pkg:npm/ms@2.0.0"},
+			{BOMRef: "7e6f5a4b-debug", Name: "debug", Version: "2.6.9", PackageURL: "pkg:npm/debug@2.6.9"},
+		},
+		Dependencies: &[]cyclonedx.Dependency{
+			{Ref: "root", Dependencies: &[]string{"5d4c3b2a-ms", "7e6f5a4b-debug"}},
+			{Ref: "5d4c3b2a-ms", Dependencies: &[]string{}},
+			{Ref: "7e6f5a4b-debug", Dependencies: &[]string{}},
+		},
+	}
+
+	merged := MergeBom(a, b)
+
+	// a leaf that is not a duplicate keeps its empty list
+	dependencies := map[string]*[]string{}
+	for _, dependency := range *merged.Dependencies {
+		dependencies[dependency.Ref] = dependency.Dependencies
+	}
+	assert.Equal(t, &[]string{"pkg:npm/ms@2.0.0", "7e6f5a4b-debug"}, dependencies["root"])
+	require.Contains(t, dependencies, "pkg:npm/ms@2.0.0")
+	assert.Equal(t, &[]string{}, dependencies["7e6f5a4b-debug"])
+
+	// a pointer to a nil list is encoded as "dependsOn": null, which is not valid CycloneDX
+	var buf bytes.Buffer
+	require.NoError(t, cyclonedx.NewBOMEncoder(&buf, cyclonedx.BOMFileFormatJSON).EncodeVersion(merged, cyclonedx.SpecVersion1_6))
+	assert.NotContains(t, buf.String(), "null")
+}
+
+func TestMergeBom_DeduplicatesByNameGroupVersion(t *testing.T) {
+	a := &cyclonedx.BOM{
+		Components: &[]cyclonedx.Component{
+			{BOMRef: "a-1", Type: cyclonedx.ComponentTypeLibrary, Group: "acme", Name: "lib", Version: "1.0"},
+			{BOMRef: "a-2", Type: cyclonedx.ComponentTypeFile, Name: "lib.so"},
+		},
+	}
+	b := &cyclonedx.BOM{
+		Components: &[]cyclonedx.Component{
+			{BOMRef: "b-1", Type: cyclonedx.ComponentTypeLibrary, Group: "acme", Name: "lib", Version: "1.0"},
+			{BOMRef: "b-2", Type: cyclonedx.ComponentTypeLibrary, Group: "acme", Name: "lib", Version: "2.0"},
+			{BOMRef: "b-3", Type: cyclonedx.ComponentTypeFile, Name: "lib.so"},
+			{BOMRef: "b-4", Type: cyclonedx.ComponentTypeLibrary, Name: "lib", Version: "1.0", PackageURL: "pkg:generic/lib@1.0"},
+		},
+		Dependencies: &[]cyclonedx.Dependency{
+			{Ref: "b-1", Dependencies: &[]string{"b-2"}},
+		},
+	}
+
+	merged := MergeBom(a, b)
+
+	var refs []string
+	for _, component := range *merged.Components {
+		refs = append(refs, component.BOMRef)
+	}
+	// files and components with a purl are not matched by name
+	assert.Equal(t, []string{"a-1", "a-2", "b-2", "b-3", "b-4"}, refs)
+	assert.Equal(t, []cyclonedx.Dependency{{Ref: "a-1", Dependencies: &[]string{"b-2"}}}, *merged.Dependencies)
+}
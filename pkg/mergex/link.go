@@ -0,0 +1,175 @@
+package mergex
+
+import (
+	"fmt"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/sbom-observer/observer-cli/pkg/cdxutil"
+)
+
+// LinkBom adds the root component of b to the components of a, with a BOM-Link (external reference of type bom)
+// to b instead of the components of b, and makes it a dependency of the root component of a. The BOM-Link refers
+// to the BOM without a bom-ref, so it only changes when the serial number or version of b changes.
+// b needs a urn:uuid serial number and a root component.
+// Returns a new BOM struct without modifying the inputs.
+func LinkBom(a, b *cyclonedx.BOM) (*cyclonedx.BOM, error) {
+	if a == nil || b == nil {
+		return nil, fmt.Errorf("both BOMs are required")
+	}
+
+	if b.Metadata == nil || b.Metadata.Component == nil {
+		return nil, fmt.Errorf("the linked BOM %s has no root component", b.SerialNumber)
+	}
+
+	link, err := cdxutil.NewBOMLink(b, "")
+	if err != nil {
+		return nil, err
+	}
+
+	result := copyBOM(a)
+
+	// the linked root component, without its subcomponents (they are in the linked BOM)
+	stub := *copyComponent(b.Metadata.Component)
+	stub.Components = nil
+	if stub.BOMRef == "" {
+		stub.BOMRef = link.String()
+	}
+	stub.ExternalReferences = mergeExternalReferenceSlice(stub.ExternalReferences, &[]cyclonedx.ExternalReference{
+		{Type: cyclonedx.ERTypeBOM, URL: link.String()},
+	})
+
+	if result.Components == nil {
+		result.Components = &[]cyclonedx.Component{}
+	}
+	*result.Components = append(*result.Components, stub)
+
+	dependencies := []cyclonedx.Dependency{{Ref: stub.BOMRef}}
+	if result.Metadata != nil && result.Metadata.Component != nil && result.Metadata.Component.BOMRef != "" {
+		dependencies = append(dependencies, cyclonedx.Dependency{Ref: result.Metadata.Component.BOMRef, Dependencies: &[]string{stub.BOMRef}})
+	}
+	result.Dependencies = mergeDependencySlice(result.Dependencies, &dependencies)
+
+	return result, nil
+}
+
+// ResolveLinks inlines linked BOMs (see LinkBom): every component with a BOM-Link that resolve returns a BOM for is
+// removed and the linked BOM is merged into the result with merge (e.g. MergeBomAsDependency). References to the
+// removed component are rewritten to the root component of the linked BOM. Links in the linked BOMs are resolved as
+// well. Returns the links that could not be resolved.
+// Returns a new BOM struct without modifying the inputs.
+func ResolveLinks(bom *cyclonedx.BOM, resolve func(link cdxutil.BOMLink) *cyclonedx.BOM, merge func(a, b *cyclonedx.BOM) *cyclonedx.BOM) (*cyclonedx.BOM, []cdxutil.BOMLink) {
+	if bom == nil {
+		return nil, nil
+	}
+
+	result := copyBOM(bom)
+
+	// root component BOMRefs of the inlined BOMs (by BOM-Link without ref)
+	inlined := map[cdxutil.BOMLink]string{}
+	unresolved := map[cdxutil.BOMLink]bool{}
+	var unresolvedLinks []cdxutil.BOMLink
+
+	for {
+		stub, link, found := nextLink(result, unresolved)
+		if !found {
+			break
+		}
+
+		root, done := inlined[link.BOM()]
+		if !done {
+			linked := resolve(link)
+			if linked == nil {
+				unresolved[link.BOM()] = true
+				unresolvedLinks = append(unresolvedLinks, link)
+				continue
+			}
+
+			if linked.Metadata != nil && linked.Metadata.Component != nil {
+				root = linked.Metadata.Component.BOMRef
+			}
+			inlined[link.BOM()] = root
+
+			result = removeComponentRef(result, stub)
+			result = merge(result, linked)
+		} else {
+			// linked more than once
+			result = removeComponentRef(result, stub)
+		}
+
+		// references to the linked component are rewritten to the root component of the linked BOM (this also
+		// removes the duplicate references added by merge)
+		if root != "" {
+			renameRefs(result, map[string]string{stub: root})
+		} else {
+			removeDependency(result, stub)
+		}
+	}
+
+	return result, unresolvedLinks
+}
+
+// nextLink returns the first component of the BOM (or subcomponent of the root component) with a BOM-Link that is
+// not in skip
+func nextLink(bom *cyclonedx.BOM, skip map[cdxutil.BOMLink]bool) (string, cdxutil.BOMLink, bool) {
+	var ref string
+	var link cdxutil.BOMLink
+
+	find := func(c *cyclonedx.Component) {
+		if ref != "" || c.BOMRef == "" {
+			return
+		}
+		for _, l := range cdxutil.BOMLinks(*c) {
+			if !skip[l.BOM()] {
+				ref, link = c.BOMRef, l
+				return
+			}
+		}
+	}
+
+	walkComponentSlice(bom.Components, find)
+	if bom.Metadata != nil && bom.Metadata.Component != nil {
+		walkComponentSlice(bom.Metadata.Component.Components, find)
+	}
+
+	return ref, link, ref != ""
+}
+
+// removeComponentRef removes the component with the BOMRef ref from the components and the subcomponents of the root
+// component
+func removeComponentRef(bom *cyclonedx.BOM, ref string) *cyclonedx.BOM {
+	bom.Components, _, _ = removeComponent(bom.Components, ref)
+	if bom.Metadata != nil && bom.Metadata.Component != nil {
+		bom.Metadata.Component.Components, _, _ = removeComponent(bom.Metadata.Component.Components, ref)
+	}
+	return bom
+}
+
+// removeDependency removes the dependency entry of ref and every reference to it
+func removeDependency(bom *cyclonedx.BOM, ref string) {
+	removeDependencies(bom, map[string]bool{ref: true})
+}
+
+// removeDependencies removes the dependency entries of refs and every reference to them
+func removeDependencies(bom *cyclonedx.BOM, refs map[string]bool) {
+	if bom.Dependencies == nil || len(refs) == 0 {
+		return
+	}
+
+	var dependencies []cyclonedx.Dependency
+	for _, dependency := range *bom.Dependencies {
+		if refs[dependency.Ref] {
+			continue
+		}
+		if dependency.Dependencies != nil {
+			var remaining []string
+			for _, r := range *dependency.Dependencies {
+				if !refs[r] {
+					remaining = append(remaining, r)
+				}
+			}
+			dependency.Dependencies = &remaining
+		}
+		dependencies = append(dependencies, dependency)
+	}
+	bom.Dependencies = &dependencies
+}
@@ -0,0 +1,86 @@
+package mergex
+
+import (
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/sbom-observer/observer-cli/pkg/cdxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkBomAndResolveLinks(t *testing.T) {
+	product := &cyclonedx.BOM{
+		SerialNumber: "urn:uuid:8f0b9f54-7a5c-4d0e-9a53-0a1f6f4c7a10",
+		Metadata:     &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "shop", Name: "shop"}},
+	}
+
+	api := &cyclonedx.BOM{
+		SerialNumber: "urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79",
+		Version:      1,
+		Metadata:     &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "api", Name: "api"}},
+		Components:   &[]cyclonedx.Component{{BOMRef: "pkg:npm/ms@2.1.3", Name: "ms"}},
+		Dependencies: &[]cyclonedx.Dependency{{Ref: "api", Dependencies: &[]string{"pkg:npm/ms@2.1.3"}}},
+	}
+
+	web := &cyclonedx.BOM{
+		SerialNumber: "urn:uuid:0b7d5fa5-5d0c-4f37-9b39-2b9c2c39f1c4",
+		Version:      1,
+		Metadata:     &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "web", Name: "web"}},
+	}
+
+	linked, err := LinkBom(product, api)
+	require.NoError(t, err)
+	linked, err = LinkBom(linked, web)
+	require.NoError(t, err)
+
+	require.Len(t, *linked.Components, 2)
+	stub := (*linked.Components)[0]
+	assert.Equal(t, "api", stub.BOMRef)
+	assert.Nil(t, stub.Components)
+	assert.Equal(t, &[]cyclonedx.ExternalReference{
+		{Type: cyclonedx.ERTypeBOM, URL: "urn:cdx:3e671687-395b-41f5-a30f-a58921a69b79/1"},
+	}, stub.ExternalReferences)
+	assert.Equal(t, []cyclonedx.Dependency{
+		{Ref: "api"},
+		{Ref: "shop", Dependencies: &[]string{"api", "web"}},
+		{Ref: "web"},
+	}, *linked.Dependencies)
+
+	_, err = LinkBom(product, &cyclonedx.BOM{Metadata: api.Metadata})
+	assert.ErrorContains(t, err, "urn:uuid serial number")
+
+	// only the api BOM is available
+	resolved, unresolved := ResolveLinks(linked, func(link cdxutil.BOMLink) *cyclonedx.BOM {
+		if link.SerialNumber == "3e671687-395b-41f5-a30f-a58921a69b79" {
+			return api
+		}
+		return nil
+	}, func(a, b *cyclonedx.BOM) *cyclonedx.BOM {
+		return MergeBomAsDependency(a, b)
+	})
+
+	require.Len(t, unresolved, 1)
+	assert.Equal(t, "urn:cdx:0b7d5fa5-5d0c-4f37-9b39-2b9c2c39f1c4/1", unresolved[0].String())
+
+	var names []string
+	for _, component := range *resolved.Components {
+		names = append(names, component.Name)
+		if component.Name == "api" {
+			assert.Nil(t, component.ExternalReferences)
+		}
+	}
+	assert.ElementsMatch(t, []string{"web", "api", "ms"}, names)
+
+	for _, dependency := range *resolved.Dependencies {
+		if dependency.Ref == "shop" {
+			assert.ElementsMatch(t, []string{"api", "web"}, *dependency.Dependencies)
+		}
+		if dependency.Ref == "api" {
+			assert.Equal(t, []string{"pkg:npm/ms@2.1.3"}, *dependency.Dependencies)
+		}
+	}
+
+	// the input is not modified
+	assert.Len(t, *linked.Components, 2)
+}
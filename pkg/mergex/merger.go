@@ -0,0 +1,602 @@
+package mergex
+
+import (
+	"slices"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+// bomMerger merges a sequence of BOMs in a single pass, with the same result as folding them with MergeBom (or
+// MergeBomAsDependency). Instead of copying and re-indexing the accumulated BOM for every input, it keeps the
+// components, the dependencies and the other keyed sections in indexes, so the cost of adding a BOM depends on the
+// size of that BOM only. Merge directives that change the accumulated BOM (see applyDirectives) are rare, they fall
+// back to a pairwise merge.
+type bomMerger struct {
+	opts         []Option
+	asDependency bool
+	merge        func(a, b cyclonedx.Component) cyclonedx.Component
+	mergeRoot    func(a, b cyclonedx.Component) cyclonedx.Component
+
+	// result holds the header fields, the metadata and the sections that are merged pairwise, the indexed sections
+	// are added by bom
+	result *cyclonedx.BOM
+
+	components []cyclonedx.Component
+	// removed marks the components that were merged into another component with the same identity
+	removed        []bool
+	componentIndex map[string]int // by componentKey
+	identities     map[string]int // the first component with each identity (see componentIdentity)
+	rootIdentity   string
+	renames        map[string]string // BOMRefs of the removed components -> BOMRef of the component they were merged into
+
+	dependencies    []dependencyEntry
+	dependencyIndex map[string]int
+
+	properties         *keyedSlice[cyclonedx.Property]
+	externalReferences *keyedSlice[cyclonedx.ExternalReference]
+	services           *keyedSlice[cyclonedx.Service]
+	compositions       *keyedSlice[cyclonedx.Composition]
+	vulnerabilities    *keyedSlice[cyclonedx.Vulnerability]
+	annotations        *keyedSlice[cyclonedx.Annotation]
+	formulation        *keyedSlice[cyclonedx.Formula]
+}
+
+// dependencyEntry is a cyclonedx.Dependency with an index of its refs
+type dependencyEntry struct {
+	ref       string
+	dependsOn []string
+	seen      map[string]bool
+	// list is false for a nil dependsOn
+	list bool
+}
+
+func (d *dependencyEntry) add(refs []string) {
+	if d.seen == nil {
+		d.seen = make(map[string]bool, len(d.dependsOn)+len(refs))
+		for _, ref := range d.dependsOn {
+			d.seen[ref] = true
+		}
+	}
+
+	for _, ref := range refs {
+		if !d.seen[ref] {
+			d.seen[ref] = true
+			d.dependsOn = append(d.dependsOn, ref)
+		}
+	}
+}
+
+// mergeBoms merges the BOMs in order with MergeBom (or MergeBomAsDependency if asDependency is true) semantics
+func mergeBoms(boms []*cyclonedx.BOM, asDependency bool, opts []Option) *cyclonedx.BOM {
+	o := newOptions(opts)
+	m := &bomMerger{
+		opts:         opts,
+		asDependency: asDependency,
+		merge:        o.componentMerger(SectionComponents),
+		mergeRoot:    o.componentMerger(SectionRootComponent),
+		renames:      map[string]string{},
+	}
+
+	for _, bom := range boms {
+		if o.conflicts != nil {
+			*o.conflicts = append(*o.conflicts, m.conflicts(bom))
+		}
+		m.add(bom)
+	}
+
+	return m.bom()
+}
+
+// nests returns true if b is added with addAsDependency
+func (m *bomMerger) nests(b *cyclonedx.BOM) bool {
+	return m.asDependency && hasRoot(b) && !hasSameRootComponent(m.result, b)
+}
+
+// conflicts returns the conflicts between b and the accumulated BOM (see FindConflicts), before b is added
+func (m *bomMerger) conflicts(b *cyclonedx.BOM) []Conflict {
+	if b == nil || m.result == nil {
+		return nil
+	}
+
+	var conflicts []Conflict
+	var components []cyclonedx.Component
+
+	if m.nests(b) {
+		// the root component of b is added as a component
+		components = append(components, *b.Metadata.Component)
+	} else if hasRoot(m.result) && hasRoot(b) {
+		conflicts = append(conflicts, componentConflicts(*m.result.Metadata.Component, *b.Metadata.Component)...)
+	}
+
+	if b.Components != nil {
+		components = append(components, *b.Components...)
+	}
+
+	for _, component := range components {
+		i, found := 0, false
+		if component.BOMRef != "" {
+			i, found = m.componentIndex[m.resolve(component.BOMRef)]
+		}
+		if !found {
+			if identity := componentIdentity(component); identity != "" {
+				i, found = m.identities[identity]
+			}
+		}
+
+		if found && !m.removed[i] {
+			conflicts = append(conflicts, componentConflicts(m.components[i], component)...)
+		}
+	}
+
+	return conflicts
+}
+
+// add merges b into the accumulated BOM
+func (m *bomMerger) add(b *cyclonedx.BOM) {
+	if b == nil {
+		return
+	}
+
+	if m.result == nil {
+		m.reset(b)
+		return
+	}
+
+	root := &cyclonedx.BOM{Metadata: m.result.Metadata}
+	a, b, nest := applyDirectives(root, b)
+	if nest || a != root {
+		// the directives change the accumulated BOM
+		if m.asDependency {
+			m.reset(MergeBomAsDependency(m.bom(), b, m.opts...))
+		} else {
+			m.reset(MergeBom(m.bom(), b, m.opts...))
+		}
+		return
+	}
+
+	if m.nests(b) {
+		m.addAsDependency(b)
+	} else {
+		m.addMerge(b)
+	}
+}
+
+// reset replaces the accumulated BOM with a copy of bom
+func (m *bomMerger) reset(bom *cyclonedx.BOM) {
+	*m = bomMerger{
+		opts:         m.opts,
+		asDependency: m.asDependency,
+		merge:        m.merge,
+		mergeRoot:    m.mergeRoot,
+		renames:      m.renames,
+
+		componentIndex:  map[string]int{},
+		identities:      map[string]int{},
+		dependencyIndex: map[string]int{},
+
+		properties:         newKeyedSlice(func(p cyclonedx.Property) string { return p.Name }, func(a, _ cyclonedx.Property) cyclonedx.Property { return a }),
+		externalReferences: newKeyedSlice[cyclonedx.ExternalReference](nil, nil),
+		services:           newKeyedSlice(func(s cyclonedx.Service) string { return s.BOMRef }, mergeService),
+		compositions:       newKeyedSlice(func(c cyclonedx.Composition) string { return c.BOMRef }, mergeComposition),
+		vulnerabilities:    newKeyedSlice(func(v cyclonedx.Vulnerability) string { return v.BOMRef }, mergeVulnerability),
+		annotations:        newKeyedSlice(func(a cyclonedx.Annotation) string { return a.BOMRef }, mergeAnnotation),
+		formulation:        newKeyedSlice(func(f cyclonedx.Formula) string { return f.BOMRef }, mergeFormula),
+	}
+
+	if bom == nil {
+		return
+	}
+
+	m.result = &cyclonedx.BOM{
+		XMLName:      bom.XMLName,
+		XMLNS:        bom.XMLNS,
+		JSONSchema:   bom.JSONSchema,
+		BOMFormat:    bom.BOMFormat,
+		SpecVersion:  bom.SpecVersion,
+		SerialNumber: bom.SerialNumber,
+		Version:      bom.Version,
+		Metadata:     copyMetadata(bom.Metadata),
+		Declarations: copyDeclarations(bom.Declarations),
+		Definitions:  copyDefinitions(bom.Definitions),
+	}
+	m.rootIdentity = m.currentRootIdentity()
+
+	m.addComponents(bom.Components)
+	m.addDependencies(bom.Dependencies, "")
+	m.addSections(bom)
+}
+
+// addMerge merges b like MergeBom: the root components are merged and the dependencies of the root component of b
+// are moved to the root component of the accumulated BOM
+func (m *bomMerger) addMerge(b *cyclonedx.BOM) {
+	result := m.result
+
+	if result.SerialNumber == "" {
+		result.SerialNumber = b.SerialNumber
+	}
+	if result.Version == 0 {
+		result.Version = b.Version
+	}
+	if result.JSONSchema == "" {
+		result.JSONSchema = b.JSONSchema
+	}
+	if result.BOMFormat == "" {
+		result.BOMFormat = b.BOMFormat
+	}
+	if result.XMLNS == "" {
+		result.XMLNS = b.XMLNS
+	}
+	if result.SpecVersion == 0 {
+		result.SpecVersion = b.SpecVersion
+	}
+
+	if result.Metadata == nil {
+		result.Metadata = copyMetadata(b.Metadata)
+	} else if b.Metadata != nil {
+		root := result.Metadata.Component
+		result.Metadata = mergeMetadata(result.Metadata, b.Metadata)
+
+		if root != nil && b.Metadata.Component != nil {
+			merged := m.mergeRoot(*root, *b.Metadata.Component)
+			result.Metadata.Component = &merged
+		}
+	}
+
+	// components with the same identity as the root component are not merged, a new root identity can make
+	// components that were kept apart duplicates
+	if identity := m.currentRootIdentity(); identity != m.rootIdentity {
+		m.rootIdentity = identity
+		m.identities = map[string]int{}
+		m.deduplicate(m.liveComponents())
+	}
+
+	m.addComponents(b.Components)
+
+	root, bRoot := rootRef(result), rootRef(b)
+	if root == "" || bRoot == "" || root == bRoot {
+		m.addDependencies(b.Dependencies, "")
+	} else {
+		if b.Dependencies != nil {
+			for _, dependency := range *b.Dependencies {
+				if dependency.Ref == bRoot {
+					if dependency.Dependencies != nil && len(*dependency.Dependencies) > 0 {
+						m.addDependsOn(root, *dependency.Dependencies)
+					}
+					break
+				}
+			}
+		}
+		m.addDependencies(b.Dependencies, bRoot)
+	}
+
+	m.addSections(b)
+}
+
+// addAsDependency merges b like MergeBomAsDependency: the root component of b becomes a component and a dependency
+// of the root component of the accumulated BOM
+func (m *bomMerger) addAsDependency(b *cyclonedx.BOM) {
+	var components []cyclonedx.Component
+	components = append(components, *b.Metadata.Component)
+	if b.Components != nil {
+		components = append(components, *b.Components...)
+	}
+	m.addComponents(&components)
+
+	if ref := b.Metadata.Component.BOMRef; ref != "" {
+		if root := rootRef(m.result); root != "" {
+			m.addDependsOn(root, []string{ref})
+		}
+		m.dependency(ref, false)
+	}
+	m.addDependencies(b.Dependencies, "")
+
+	m.addSections(b)
+}
+
+// addComponents merges the components into the accumulated components (by BOMRef, or name+version+purl without a
+// BOMRef) and then merges the components with the same identity, like deduplicateComponents
+func (m *bomMerger) addComponents(components *[]cyclonedx.Component) {
+	if components == nil {
+		return
+	}
+
+	var changed []int
+	for _, component := range *components {
+		key := componentKey(component)
+
+		i, found := m.componentIndex[key]
+		if !found {
+			m.componentIndex[key] = len(m.components)
+			changed = append(changed, len(m.components))
+			m.components = append(m.components, component)
+			m.removed = append(m.removed, false)
+			continue
+		}
+
+		identity := componentIdentity(m.components[i])
+		m.components[i] = m.merge(m.components[i], component)
+		m.rekey(i, key)
+
+		// a component with a new identity can be a duplicate of another component
+		if componentIdentity(m.components[i]) != identity {
+			if j, found := m.identities[identity]; found && j == i {
+				delete(m.identities, identity)
+			}
+			changed = append(changed, i)
+		}
+	}
+
+	slices.Sort(changed)
+	m.deduplicate(slices.Compact(changed))
+}
+
+// deduplicate merges the components (indexes in order) into the first component with the same identity
+func (m *bomMerger) deduplicate(indexes []int) {
+	for _, i := range indexes {
+		if m.removed[i] {
+			continue
+		}
+
+		identity := componentIdentity(m.components[i])
+		if identity == "" || identity == m.rootIdentity {
+			continue
+		}
+
+		first, found := m.identities[identity]
+		switch {
+		case !found || first == i:
+			m.identities[identity] = i
+		case first < i:
+			m.mergeInto(first, i)
+		default:
+			m.identities[identity] = i
+			m.mergeInto(i, first)
+		}
+	}
+}
+
+// mergeInto merges the component duplicate into the component survivor and removes it
+func (m *bomMerger) mergeInto(survivor, duplicate int) {
+	s, d := m.components[survivor], m.components[duplicate]
+
+	m.removed[duplicate] = true
+	if i, found := m.componentIndex[componentKey(d)]; found && i == duplicate {
+		delete(m.componentIndex, componentKey(d))
+	}
+
+	key := componentKey(s)
+	if d.BOMRef != "" && d.BOMRef != s.BOMRef {
+		if s.BOMRef == "" {
+			s.BOMRef = d.BOMRef
+		} else {
+			m.renames[d.BOMRef] = s.BOMRef
+		}
+	}
+	m.components[survivor] = m.merge(s, d)
+	m.rekey(survivor, key)
+}
+
+// rekey updates the index of the component i if merging changed its key
+func (m *bomMerger) rekey(i int, key string) {
+	updated := componentKey(m.components[i])
+	if updated == key {
+		return
+	}
+
+	if j, found := m.componentIndex[key]; found && j == i {
+		delete(m.componentIndex, key)
+	}
+	if _, found := m.componentIndex[updated]; !found {
+		m.componentIndex[updated] = i
+	}
+}
+
+func (m *bomMerger) liveComponents() []int {
+	var indexes []int
+	for i := range m.components {
+		if !m.removed[i] {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+func (m *bomMerger) currentRootIdentity() string {
+	if !hasRoot(m.result) {
+		return ""
+	}
+	return componentIdentity(*m.result.Metadata.Component)
+}
+
+// addDependencies merges the dependencies (except the one of skip) into the accumulated dependencies
+func (m *bomMerger) addDependencies(dependencies *[]cyclonedx.Dependency, skip string) {
+	if dependencies == nil {
+		return
+	}
+
+	for _, dependency := range *dependencies {
+		if skip != "" && dependency.Ref == skip {
+			continue
+		}
+
+		ref := m.resolve(dependency.Ref)
+		_, exists := m.dependencyIndex[ref]
+
+		entry := m.dependency(ref, dependency.Dependencies != nil)
+		if dependency.Dependencies != nil {
+			entry.add(m.resolveAll(*dependency.Dependencies))
+		}
+		// like mergeDependency, merged entries without dependencies have a nil dependsOn
+		if exists {
+			entry.list = len(entry.dependsOn) > 0
+		}
+	}
+}
+
+// addDependsOn adds refs to the dependencies of ref
+func (m *bomMerger) addDependsOn(ref string, refs []string) {
+	entry := m.dependency(m.resolve(ref), true)
+	entry.add(m.resolveAll(refs))
+	entry.list = len(entry.dependsOn) > 0
+}
+
+// dependency returns the dependency entry of ref, a new entry is added if there is none
+func (m *bomMerger) dependency(ref string, list bool) *dependencyEntry {
+	i, found := m.dependencyIndex[ref]
+	if !found {
+		i = len(m.dependencies)
+		m.dependencyIndex[ref] = i
+		m.dependencies = append(m.dependencies, dependencyEntry{ref: ref, list: list})
+	}
+	return &m.dependencies[i]
+}
+
+// resolve returns the BOMRef of the component that the component with the BOMRef ref was merged into
+func (m *bomMerger) resolve(ref string) string {
+	// renames can be chained (a removed component was merged into a component that was removed later)
+	for range len(m.renames) {
+		renamed, found := m.renames[ref]
+		if !found {
+			break
+		}
+		ref = renamed
+	}
+	return ref
+}
+
+func (m *bomMerger) resolveAll(refs []string) []string {
+	if len(m.renames) == 0 {
+		return refs
+	}
+
+	resolved := make([]string, len(refs))
+	for i, ref := range refs {
+		resolved[i] = m.resolve(ref)
+	}
+	return resolved
+}
+
+// addSections merges the sections of b that are not components or dependencies
+func (m *bomMerger) addSections(b *cyclonedx.BOM) {
+	m.properties.add(b.Properties)
+	m.externalReferences.add(b.ExternalReferences)
+	m.services.add(b.Services)
+	m.compositions.add(b.Compositions)
+	m.vulnerabilities.add(b.Vulnerabilities)
+	m.annotations.add(b.Annotations)
+	m.formulation.add(b.Formulation)
+
+	if b.Declarations != nil {
+		m.result.Declarations = mergeDeclarations(m.result.Declarations, b.Declarations)
+	}
+	if b.Definitions != nil {
+		m.result.Definitions = mergeDefinitions(m.result.Definitions, b.Definitions)
+	}
+}
+
+// bom returns the accumulated BOM
+func (m *bomMerger) bom() *cyclonedx.BOM {
+	if m.result == nil {
+		return nil
+	}
+
+	result := *m.result
+	result.Metadata = copyMetadata(m.result.Metadata)
+
+	live := map[string]bool{}
+	var components []cyclonedx.Component
+	for i, component := range m.components {
+		if !m.removed[i] {
+			components = append(components, component)
+			live[component.BOMRef] = true
+		}
+	}
+	if len(components) > 0 {
+		result.Components = &components
+	}
+
+	if len(m.dependencies) > 0 {
+		dependencies := make([]cyclonedx.Dependency, 0, len(m.dependencies))
+		for _, entry := range m.dependencies {
+			dependency := cyclonedx.Dependency{Ref: entry.ref}
+			if entry.list {
+				// an empty list stays an empty list ("dependsOn": [] and not null)
+				dependsOn := make([]string, 0, len(entry.dependsOn))
+				dependsOn = append(dependsOn, entry.dependsOn...)
+				dependency.Dependencies = &dependsOn
+			}
+			dependencies = append(dependencies, dependency)
+		}
+		result.Dependencies = &dependencies
+	}
+
+	result.Properties = m.properties.slice()
+	result.ExternalReferences = m.externalReferences.slice()
+	result.Services = m.services.slice()
+	result.Compositions = m.compositions.slice()
+	result.Vulnerabilities = m.vulnerabilities.slice()
+	result.Annotations = m.annotations.slice()
+	result.Formulation = m.formulation.slice()
+
+	// references added before their component was merged into another component (a BOMRef that is used by a
+	// component again is not renamed)
+	renames := map[string]string{}
+	for ref := range m.renames {
+		if resolved := m.resolve(ref); !live[ref] && resolved != ref {
+			renames[ref] = resolved
+		}
+	}
+	renameRefs(&result, renames)
+
+	return &result
+}
+
+// componentKey is the key used to merge components: the BOMRef, or name+version+purl without a BOMRef
+func componentKey(component cyclonedx.Component) string {
+	if component.BOMRef != "" {
+		return component.BOMRef
+	}
+	return component.Name + "|" + component.Version + "|" + component.PackageURL
+}
+
+// keyedSlice merges slices in one pass: an item with the key of an earlier item is merged into it with merge. The
+// items keep the order in which their keys were first seen. Without a key function all items are kept.
+type keyedSlice[T any] struct {
+	items []T
+	index map[string]int
+	key   func(item T) string
+	merge func(a, b T) T
+}
+
+func newKeyedSlice[T any](key func(item T) string, merge func(a, b T) T) *keyedSlice[T] {
+	return &keyedSlice[T]{index: map[string]int{}, key: key, merge: merge}
+}
+
+func (s *keyedSlice[T]) add(items *[]T) {
+	if items == nil {
+		return
+	}
+
+	if s.key == nil {
+		s.items = append(s.items, *items...)
+		return
+	}
+
+	for _, item := range *items {
+		key := s.key(item)
+		if i, found := s.index[key]; found {
+			s.items[i] = s.merge(s.items[i], item)
+			continue
+		}
+		s.index[key] = len(s.items)
+		s.items = append(s.items, item)
+	}
+}
+
+func (s *keyedSlice[T]) slice() *[]T {
+	if len(s.items) == 0 {
+		return nil
+	}
+	items := slices.Clone(s.items)
+	return &items
+}
@@ -0,0 +1,256 @@
+package mergex
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// foldBoms merges the BOMs pairwise (the reference for the single pass merge of MergeBoms and MergeBomsAsDependency)
+func foldBoms(boms []*cyclonedx.BOM, merge func(a, b *cyclonedx.BOM, opts ...Option) *cyclonedx.BOM, opts ...Option) *cyclonedx.BOM {
+	merged := boms[0]
+	for _, bom := range boms[1:] {
+		merged = merge(merged, bom, opts...)
+	}
+	repaired, _ := RepairGraph(merged, opts...)
+	return repaired
+}
+
+// generateBoms returns n service BOMs with size components each, drawn from a shared set of packages. Some
+// components use a purl as BOMRef and some a BOMRef of their own (like different scanners do), so the merge has
+// to merge components by BOMRef and by identity.
+func generateBoms(n, size int, seed int64) []*cyclonedx.BOM {
+	random := rand.New(rand.NewSource(seed))
+	licenses := []string{"MIT", "Apache-2.0", "BSD-3-Clause", ""}
+
+	boms := make([]*cyclonedx.BOM, 0, n)
+	for i := range n {
+		root := fmt.Sprintf("service-%d", i)
+		if i > 0 && i%10 == 0 {
+			// the same service scanned twice
+			root = "service-0"
+		}
+
+		bom := &cyclonedx.BOM{
+			SerialNumber: fmt.Sprintf("urn:uuid:00000000-0000-0000-0000-%012d", i),
+			Version:      1,
+			Metadata:     &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: root, Type: cyclonedx.ComponentTypeApplication, Name: root, Version: "1.0.0"}},
+			Properties:   &[]cyclonedx.Property{{Name: "service", Value: root}},
+		}
+
+		var components []cyclonedx.Component
+		var refs []string
+		for _, p := range random.Perm(size * 5)[:size] {
+			purl := fmt.Sprintf("pkg:npm/package-%d@1.%d.0", p, p%3)
+			component := cyclonedx.Component{BOMRef: purl, Type: cyclonedx.ComponentTypeLibrary, Name: fmt.Sprintf("package-%d", p), Version: fmt.Sprintf("1.%d.0", p%3), PackageURL: purl}
+			if random.Intn(4) == 0 {
+				component.BOMRef = fmt.Sprintf("ref-%d-%d", i, p)
+			}
+			if license := licenses[random.Intn(len(licenses))]; license != "" {
+				component.Licenses = &cyclonedx.Licenses{{License: &cyclonedx.License{ID: license}}}
+			}
+			components = append(components, component)
+			refs = append(refs, component.BOMRef)
+		}
+		bom.Components = &components
+
+		dependencies := []cyclonedx.Dependency{{Ref: root, Dependencies: &[]string{refs[0], refs[1]}}}
+		for j, ref := range refs {
+			dependsOn := []string{refs[random.Intn(len(refs))]}
+			if j%7 == 0 {
+				dependsOn = append(dependsOn, "missing")
+			}
+			dependencies = append(dependencies, cyclonedx.Dependency{Ref: ref, Dependencies: &dependsOn})
+		}
+		bom.Dependencies = &dependencies
+
+		boms = append(boms, bom)
+	}
+
+	return boms
+}
+
+// assertSameBom compares the BOMs, ignoring the order of the dependencies, dependsOn and properties
+func assertSameBom(t *testing.T, expected, actual *cyclonedx.BOM) {
+	t.Helper()
+
+	assert.Equal(t, expected.SerialNumber, actual.SerialNumber)
+	assert.Equal(t, expected.Metadata.Component.BOMRef, actual.Metadata.Component.BOMRef)
+	assert.Equal(t, *expected.Components, *actual.Components)
+	if expected.Properties != nil {
+		require.NotNil(t, actual.Properties)
+		assert.ElementsMatch(t, *expected.Properties, *actual.Properties)
+	} else {
+		assert.Nil(t, actual.Properties)
+	}
+
+	dependencies := func(bom *cyclonedx.BOM) map[string][]string {
+		result := map[string][]string{}
+		for _, dependency := range *bom.Dependencies {
+			var dependsOn []string
+			if dependency.Dependencies != nil {
+				dependsOn = slices.Sorted(slices.Values(*dependency.Dependencies))
+			}
+			result[dependency.Ref] = dependsOn
+		}
+		return result
+	}
+	assert.Equal(t, dependencies(expected), dependencies(actual))
+}
+
+func TestMergeBomsSinglePass(t *testing.T) {
+	boms := generateBoms(40, 30, 1)
+
+	t.Run("MergeBoms", func(t *testing.T) {
+		assertSameBom(t, foldBoms(boms, MergeBom), MergeBoms(boms))
+	})
+
+	t.Run("MergeBomsAsDependency", func(t *testing.T) {
+		assertSameBom(t, foldBoms(boms, MergeBomAsDependency), MergeBomsAsDependency(boms))
+	})
+
+	t.Run("strategies", func(t *testing.T) {
+		opts := []Option{WithStrategy("licenses", StrategyLastWins), WithAttachOrphans()}
+		assertSameBom(t, foldBoms(boms, MergeBom, opts...), MergeBoms(boms, opts...))
+		assertSameBom(t, foldBoms(boms, MergeBomAsDependency, opts...), MergeBomsAsDependency(boms, opts...))
+	})
+
+	t.Run("nil inputs", func(t *testing.T) {
+		merged := MergeBoms([]*cyclonedx.BOM{nil, boms[0], nil, boms[1]})
+		assertSameBom(t, foldBoms(boms[:2], MergeBom), merged)
+	})
+
+	t.Run("inputs are not modified", func(t *testing.T) {
+		expected := generateBoms(40, 30, 1)
+		MergeBoms(boms)
+		MergeBomsAsDependency(boms)
+		assert.Equal(t, expected, boms)
+	})
+}
+
+func TestMergeBomsSinglePassDuplicates(t *testing.T) {
+	// the same package found with a different BOMRef in every input, and one input that only has it as a dependency
+	a := &cyclonedx.BOM{
+		Metadata:   &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "app", Name: "app"}},
+		Components: &[]cyclonedx.Component{{BOMRef: "lodash-a", Name: "lodash", Version: "4.17.21", PackageURL: "pkg:npm/lodash@4.17.21"}},
+		Dependencies: &[]cyclonedx.Dependency{
+			{Ref: "app", Dependencies: &[]string{"lodash-a"}},
+		},
+	}
+	b := &cyclonedx.BOM{
+		Metadata: &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "lib", Name: "lib"}},
+		Components: &[]cyclonedx.Component{
+			{BOMRef: "lodash-b", Name: "lodash", Version: "v4.17.21", PackageURL: "pkg:npm/lodash@v4.17.21", Licenses: &cyclonedx.Licenses{{License: &cyclonedx.License{ID: "MIT"}}}},
+			{BOMRef: "pkg:npm/ms@2.1.3", Name: "ms", Version: "2.1.3", PackageURL: "pkg:npm/ms@2.1.3"},
+		},
+		Dependencies: &[]cyclonedx.Dependency{
+			{Ref: "lib", Dependencies: &[]string{"lodash-b"}},
+			{Ref: "lodash-b", Dependencies: &[]string{"pkg:npm/ms@2.1.3"}},
+		},
+	}
+	c := &cyclonedx.BOM{
+		Metadata:   &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "cli", Name: "cli"}},
+		Components: &[]cyclonedx.Component{{BOMRef: "lodash-c", Name: "lodash", Version: "4.17.21", PackageURL: "pkg:npm/lodash@4.17.21"}},
+		Dependencies: &[]cyclonedx.Dependency{
+			{Ref: "cli", Dependencies: &[]string{"lodash-c"}},
+		},
+	}
+
+	merged := MergeBomsAsDependency([]*cyclonedx.BOM{a, b, c})
+
+	var refs []string
+	for _, component := range *merged.Components {
+		refs = append(refs, component.BOMRef)
+	}
+	assert.Equal(t, []string{"lodash-a", "lib", "pkg:npm/ms@2.1.3", "cli"}, refs)
+	require.NotNil(t, (*merged.Components)[0].Licenses)
+
+	assert.Equal(t, []cyclonedx.Dependency{
+		{Ref: "app", Dependencies: &[]string{"lodash-a", "lib", "cli"}},
+		{Ref: "lib", Dependencies: &[]string{"lodash-a"}},
+		{Ref: "lodash-a", Dependencies: &[]string{"pkg:npm/ms@2.1.3"}},
+		{Ref: "cli", Dependencies: &[]string{"lodash-a"}},
+	}, *merged.Dependencies)
+
+	assertSameBom(t, foldBoms([]*cyclonedx.BOM{a, b, c}, MergeBomAsDependency), merged)
+}
+
+func TestMergeBomsSinglePassEmptyDependsOn(t *testing.T) {
+	// leaf packages have an empty dependsOn (i.e. from Trivy)
+	a := &cyclonedx.BOM{
+		Metadata:   &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "app", Name: "app"}},
+		Components: &[]cyclonedx.Component{{BOMRef: "pkg:npm/ms@2.1.3", Name: "ms", Version: "2.1.3", PackageURL: "pkg:npm/ms@2.1.3"}},
+		Dependencies: &[]cyclonedx.Dependency{
+			{Ref: "app", Dependencies: &[]string{"pkg:npm/ms@2.1.3"}},
+			{Ref: "pkg:npm/ms@2.1.3", Dependencies: &[]string{}},
+		},
+	}
+	b := &cyclonedx.BOM{
+		Metadata:   &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "app", Name: "app"}},
+		Components: &[]cyclonedx.Component{{BOMRef: "pkg:npm/lodash@4.17.21", Name: "lodash", Version: "4.17.21", PackageURL: "pkg:npm/lodash@4.17.21"}},
+		Dependencies: &[]cyclonedx.Dependency{
+			{Ref: "app", Dependencies: &[]string{"pkg:npm/lodash@4.17.21"}},
+			{Ref: "pkg:npm/lodash@4.17.21", Dependencies: &[]string{}},
+		},
+	}
+
+	expected := foldBoms([]*cyclonedx.BOM{a, b}, MergeBom)
+	merged := MergeBoms([]*cyclonedx.BOM{a, b})
+	assertSameBom(t, expected, merged)
+
+	expectedJSON, err := json.Marshal(expected.Dependencies)
+	require.NoError(t, err)
+	mergedJSON, err := json.Marshal(merged.Dependencies)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, string(expectedJSON), string(mergedJSON))
+	assert.NotContains(t, string(mergedJSON), "null")
+	assert.Contains(t, string(mergedJSON), `{"ref":"pkg:npm/lodash@4.17.21","dependsOn":[]}`)
+}
+
+func BenchmarkMergeBoms(b *testing.B) {
+	for _, n := range []int{10, 100, 1000, 2000} {
+		boms := generateBoms(n, 200, 1)
+
+		b.Run(fmt.Sprintf("boms=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				MergeBoms(boms)
+			}
+		})
+	}
+}
+
+func BenchmarkMergeBomsAsDependency(b *testing.B) {
+	for _, n := range []int{10, 100, 1000, 2000} {
+		boms := generateBoms(n, 200, 1)
+
+		b.Run(fmt.Sprintf("boms=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				MergeBomsAsDependency(boms)
+			}
+		})
+	}
+}
+
+// BenchmarkMergeBomsPairwise is the baseline for BenchmarkMergeBomsAsDependency: the BOMs folded with
+// MergeBomAsDependency, which copies the accumulated BOM for every input
+func BenchmarkMergeBomsPairwise(b *testing.B) {
+	for _, n := range []int{10, 100, 500} {
+		boms := generateBoms(n, 200, 1)
+
+		b.Run(fmt.Sprintf("boms=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				foldBoms(boms, MergeBomAsDependency)
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package mergex
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+// Strategy decides which value to keep when two merged components have different values for a field
+type Strategy string
+
+const (
+	// StrategyFirstWins keeps the value from the first input, even if it is empty
+	StrategyFirstWins Strategy = "first-wins"
+	// StrategyLastWins keeps the value from the last input, even if it is empty
+	StrategyLastWins Strategy = "last-wins"
+	// StrategyUnion combines array values from both inputs (the default for arrays). Scalars are merged with
+	// StrategyPreferNonEmpty.
+	StrategyUnion Strategy = "union"
+	// StrategyPreferNonEmpty keeps the value from the first input unless it is empty (the default for scalars)
+	StrategyPreferNonEmpty Strategy = "prefer-non-empty"
+	// StrategyPreferHighestConfidence keeps the value from the component with the highest evidence identity
+	// confidence (see scanner provenance). Ties and empty values are merged with StrategyPreferNonEmpty.
+	StrategyPreferHighestConfidence Strategy = "prefer-highest-confidence"
+)
+
+// Strategies are the available merge strategies
+var Strategies = []Strategy{StrategyFirstWins, StrategyLastWins, StrategyUnion, StrategyPreferNonEmpty, StrategyPreferHighestConfidence}
+
+// sections of a BOM where components are merged
+const (
+	SectionRootComponent = "metadata.component"
+	SectionComponents    = "components"
+)
+
+// Option configures MergeBom (and RepairGraph)
+type Option func(o *options)
+
+type options struct {
+	// strategies by key (field, section or section.field)
+	strategies map[string]Strategy
+	// attachOrphans makes RepairGraph attach orphans to the root component
+	attachOrphans bool
+	// graphIssues collects the issues found by RepairGraph
+	graphIssues *[]GraphIssue
+	// conflicts collects the conflicts found by MergeBoms and MergeBomsAsDependency
+	conflicts *[][]Conflict
+}
+
+// WithStrategy sets the merge strategy for a component field (e.g. licenses), a section (metadata.component or
+// components) or a field in a section (e.g. components.supplier). The most specific key wins.
+func WithStrategy(key string, strategy Strategy) Option {
+	return func(o *options) {
+		if o.strategies == nil {
+			o.strategies = map[string]Strategy{}
+		}
+		o.strategies[key] = strategy
+	}
+}
+
+// WithStrategies sets several merge strategies, see WithStrategy
+func WithStrategies(strategies map[string]Strategy) Option {
+	return func(o *options) {
+		for key, strategy := range strategies {
+			WithStrategy(key, strategy)(o)
+		}
+	}
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// strategy returns the configured strategy for the field in the section ("" if none is configured)
+func (o options) strategy(section string, field string) Strategy {
+	for _, key := range []string{section + "." + field, field, section} {
+		if strategy, found := o.strategies[key]; found {
+			return strategy
+		}
+	}
+	return ""
+}
+
+// componentMerger returns the function used to merge components in the section
+func (o options) componentMerger(section string) func(a, b cyclonedx.Component) cyclonedx.Component {
+	if len(o.strategies) == 0 {
+		return MergeComponent
+	}
+
+	return func(a, b cyclonedx.Component) cyclonedx.Component {
+		result := MergeComponent(a, b)
+
+		for _, field := range strategyFields {
+			strategy := o.strategy(section, field.name)
+			if strategy == "" {
+				continue
+			}
+
+			switch strategy {
+			case StrategyFirstWins:
+				field.set(&result, a)
+			case StrategyLastWins:
+				field.set(&result, b)
+			case StrategyUnion:
+				if !field.array {
+					preferNonEmpty(field, &result, a, b)
+				}
+				// arrays are combined by MergeComponent
+			case StrategyPreferNonEmpty:
+				preferNonEmpty(field, &result, a, b)
+			case StrategyPreferHighestConfidence:
+				if identityConfidence(b) > identityConfidence(a) && !field.empty(b) {
+					field.set(&result, b)
+				} else {
+					preferNonEmpty(field, &result, a, b)
+				}
+			}
+		}
+
+		return result
+	}
+}
+
+func preferNonEmpty(field strategyField, result *cyclonedx.Component, a, b cyclonedx.Component) {
+	if !field.empty(a) {
+		field.set(result, a)
+	} else {
+		field.set(result, b)
+	}
+}
+
+// identityConfidence returns the highest evidence identity confidence of the component
+func identityConfidence(component cyclonedx.Component) float32 {
+	var confidence float32
+	if component.Evidence != nil && component.Evidence.Identity != nil {
+		for _, identity := range *component.Evidence.Identity {
+			if identity.Confidence != nil && *identity.Confidence > confidence {
+				confidence = *identity.Confidence
+			}
+		}
+	}
+	return confidence
+}
+
+// strategyField is a component field that can be merged with a strategy
+type strategyField struct {
+	name  string
+	array bool
+	empty func(c cyclonedx.Component) bool
+	// set copies the field from src to dst
+	set func(dst *cyclonedx.Component, src cyclonedx.Component)
+}
+
+func scalarField(name string, get func(c *cyclonedx.Component) *string) strategyField {
+	return strategyField{
+		name:  name,
+		empty: func(c cyclonedx.Component) bool { return *get(&c) == "" },
+		set:   func(dst *cyclonedx.Component, src cyclonedx.Component) { *get(dst) = *get(&src) },
+	}
+}
+
+var strategyFields = []strategyField{
+	scalarField("author", func(c *cyclonedx.Component) *string { return &c.Author }),
+	scalarField("publisher", func(c *cyclonedx.Component) *string { return &c.Publisher }),
+	scalarField("group", func(c *cyclonedx.Component) *string { return &c.Group }),
+	scalarField("name", func(c *cyclonedx.Component) *string { return &c.Name }),
+	scalarField("version", func(c *cyclonedx.Component) *string { return &c.Version }),
+	scalarField("description", func(c *cyclonedx.Component) *string { return &c.Description }),
+	scalarField("copyright", func(c *cyclonedx.Component) *string { return &c.Copyright }),
+	scalarField("cpe", func(c *cyclonedx.Component) *string { return &c.CPE }),
+	scalarField("purl", func(c *cyclonedx.Component) *string { return &c.PackageURL }),
+	{
+		name:  "scope",
+		empty: func(c cyclonedx.Component) bool { return c.Scope == "" },
+		set:   func(dst *cyclonedx.Component, src cyclonedx.Component) { dst.Scope = src.Scope },
+	},
+	{
+		name:  "supplier",
+		empty: func(c cyclonedx.Component) bool { return c.Supplier == nil },
+		set: func(dst *cyclonedx.Component, src cyclonedx.Component) {
+			dst.Supplier = copyOrganizationalEntity(src.Supplier)
+		},
+	},
+	{
+		name:  "licenses",
+		array: true,
+		empty: func(c cyclonedx.Component) bool { return c.Licenses == nil || len(*c.Licenses) == 0 },
+		set: func(dst *cyclonedx.Component, src cyclonedx.Component) {
+			dst.Licenses = copyLicenses(src.Licenses)
+		},
+	},
+	{
+		name:  "hashes",
+		array: true,
+		empty: func(c cyclonedx.Component) bool { return c.Hashes == nil || len(*c.Hashes) == 0 },
+		set: func(dst *cyclonedx.Component, src cyclonedx.Component) {
+			dst.Hashes = mergeHashSlice(src.Hashes, nil)
+		},
+	},
+	{
+		name:  "externalReferences",
+		array: true,
+		empty: func(c cyclonedx.Component) bool {
+			return c.ExternalReferences == nil || len(*c.ExternalReferences) == 0
+		},
+		set: func(dst *cyclonedx.Component, src cyclonedx.Component) {
+			dst.ExternalReferences = copyExternalReferenceSlice(src.ExternalReferences)
+		},
+	},
+	{
+		name:  "properties",
+		array: true,
+		empty: func(c cyclonedx.Component) bool { return c.Properties == nil || len(*c.Properties) == 0 },
+		set: func(dst *cyclonedx.Component, src cyclonedx.Component) {
+			dst.Properties = copyPropertySlice(src.Properties)
+		},
+	},
+}
+
+// StrategyKeys returns the keys that can be used with WithStrategy
+func StrategyKeys() []string {
+	var keys []string
+	for _, field := range strategyFields {
+		keys = append(keys, field.name)
+	}
+	sort.Strings(keys)
+	return append([]string{SectionRootComponent, SectionComponents}, keys...)
+}
+
+// ParseStrategies parses strategies in the form key=strategy, e.g. licenses=union or components.supplier=first-wins
+func ParseStrategies(values []string) (map[string]Strategy, error) {
+	strategies := map[string]Strategy{}
+	for _, value := range values {
+		key, name, found := strings.Cut(value, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("expected key=strategy, got '%s'", value)
+		}
+
+		if !isStrategyKey(key) {
+			return nil, fmt.Errorf("unknown field or section '%s' (available: %s)", key, strings.Join(StrategyKeys(), ", "))
+		}
+
+		strategy := Strategy(name)
+		if !slices.Contains(Strategies, strategy) {
+			var names []string
+			for _, s := range Strategies {
+				names = append(names, string(s))
+			}
+			return nil, fmt.Errorf("unknown merge strategy '%s' (available: %s)", name, strings.Join(names, ", "))
+		}
+
+		strategies[key] = strategy
+	}
+
+	return strategies, nil
+}
+
+func isStrategyKey(key string) bool {
+	if slices.Contains(StrategyKeys(), key) {
+		return true
+	}
+
+	for _, section := range []string{SectionRootComponent, SectionComponents} {
+		if field, found := strings.CutPrefix(key, section+"."); found && slices.ContainsFunc(strategyFields, func(f strategyField) bool { return f.name == field }) {
+			return true
+		}
+	}
+
+	return false
+}
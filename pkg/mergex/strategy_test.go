@@ -0,0 +1,126 @@
+package mergex
+
+import (
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeBomWithStrategy(t *testing.T) {
+	low, high := float32(0.3), float32(0.9)
+
+	scan := &cyclonedx.BOM{
+		Metadata: &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "root", Name: "app", Version: "1.0"}},
+		Components: &[]cyclonedx.Component{
+			{
+				BOMRef:      "a",
+				Name:        "a",
+				Version:     "1.0.0",
+				Description: "from scan",
+				Licenses:    &cyclonedx.Licenses{{License: &cyclonedx.License{ID: "MIT"}}},
+				Supplier:    &cyclonedx.OrganizationalEntity{Name: "Scanner guess"},
+				Evidence:    &cyclonedx.Evidence{Identity: &[]cyclonedx.EvidenceIdentity{{Field: cyclonedx.EvidenceIdentityFieldTypeName, Confidence: &low}}},
+			},
+		},
+	}
+
+	curated := &cyclonedx.BOM{
+		Metadata: &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "root", Name: "app", Version: "1.1", Description: "curated"}},
+		Components: &[]cyclonedx.Component{
+			{
+				BOMRef:   "a",
+				Name:     "a",
+				Version:  "1.0.1",
+				Licenses: &cyclonedx.Licenses{{License: &cyclonedx.License{ID: "Apache-2.0"}}},
+				Supplier: &cyclonedx.OrganizationalEntity{Name: "Acme"},
+				Evidence: &cyclonedx.Evidence{Identity: &[]cyclonedx.EvidenceIdentity{{Field: cyclonedx.EvidenceIdentityFieldTypeName, Confidence: &high}}},
+			},
+		},
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		merged := MergeBom(scan, curated)
+		component := (*merged.Components)[0]
+		assert.Equal(t, "1.0.0", component.Version)
+		assert.Equal(t, "Scanner guess", component.Supplier.Name)
+		assert.Len(t, *component.Licenses, 2)
+		assert.Equal(t, "1.0", merged.Metadata.Component.Version)
+	})
+
+	t.Run("last-wins", func(t *testing.T) {
+		merged := MergeBom(scan, curated, WithStrategy("supplier", StrategyLastWins), WithStrategy("description", StrategyLastWins))
+		component := (*merged.Components)[0]
+		assert.Equal(t, "Acme", component.Supplier.Name)
+		assert.Equal(t, "1.0.0", component.Version)
+		// last-wins keeps empty values
+		assert.Empty(t, component.Description)
+		assert.Len(t, *component.Licenses, 2)
+	})
+
+	t.Run("first-wins for arrays", func(t *testing.T) {
+		merged := MergeBom(scan, curated, WithStrategy("licenses", StrategyFirstWins))
+		assert.Equal(t, &cyclonedx.Licenses{{License: &cyclonedx.License{ID: "MIT"}}}, (*merged.Components)[0].Licenses)
+	})
+
+	t.Run("prefer-highest-confidence", func(t *testing.T) {
+		merged := MergeBom(scan, curated, WithStrategy("components", StrategyPreferHighestConfidence))
+		component := (*merged.Components)[0]
+		assert.Equal(t, "1.0.1", component.Version)
+		assert.Equal(t, "Acme", component.Supplier.Name)
+		assert.Equal(t, &cyclonedx.Licenses{{License: &cyclonedx.License{ID: "Apache-2.0"}}}, component.Licenses)
+		// curated has no description
+		assert.Equal(t, "from scan", component.Description)
+	})
+
+	t.Run("sections", func(t *testing.T) {
+		merged := MergeBom(scan, curated,
+			WithStrategy("metadata.component", StrategyLastWins),
+			WithStrategy("metadata.component.description", StrategyFirstWins),
+		)
+		assert.Equal(t, "1.1", merged.Metadata.Component.Version)
+		assert.Empty(t, merged.Metadata.Component.Description)
+		// components use the defaults
+		assert.Equal(t, "1.0.0", (*merged.Components)[0].Version)
+	})
+
+	t.Run("inputs are not modified", func(t *testing.T) {
+		merged := MergeBom(scan, curated, WithStrategy("supplier", StrategyLastWins))
+		(*merged.Components)[0].Supplier.Name = "changed"
+		assert.Equal(t, "Acme", (*curated.Components)[0].Supplier.Name)
+	})
+}
+
+func TestMergeBomsWithStrategyDeduplicates(t *testing.T) {
+	boms := []*cyclonedx.BOM{
+		{Components: &[]cyclonedx.Component{{BOMRef: "uuid-1", Name: "a", Version: "1.0.0", PackageURL: "pkg:npm/a@1.0.0", Author: "first"}}},
+		{Components: &[]cyclonedx.Component{{BOMRef: "uuid-2", Name: "a", Version: "1.0.0", PackageURL: "pkg:npm/a@1.0.0", Author: "last"}}},
+	}
+
+	merged := MergeBoms(boms, WithStrategy("author", StrategyLastWins))
+	require.Len(t, *merged.Components, 1)
+	assert.Equal(t, "last", (*merged.Components)[0].Author)
+}
+
+func TestParseStrategies(t *testing.T) {
+	strategies, err := ParseStrategies([]string{"licenses=union", "components.supplier=first-wins", "metadata.component=last-wins"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Strategy{
+		"licenses":            StrategyUnion,
+		"components.supplier": StrategyFirstWins,
+		"metadata.component":  StrategyLastWins,
+	}, strategies)
+
+	_, err = ParseStrategies([]string{"licenses"})
+	assert.ErrorContains(t, err, "expected key=strategy")
+
+	_, err = ParseStrategies([]string{"colour=union"})
+	assert.ErrorContains(t, err, "unknown field or section 'colour'")
+
+	_, err = ParseStrategies([]string{"components.colour=union"})
+	assert.ErrorContains(t, err, "unknown field or section")
+
+	_, err = ParseStrategies([]string{"supplier=random"})
+	assert.ErrorContains(t, err, "unknown merge strategy 'random'")
+}
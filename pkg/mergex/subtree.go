@@ -0,0 +1,209 @@
+package mergex
+
+import (
+	"slices"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+// MergeBomAsSubtree merges two BOMs by nesting the second BOM under the root component of the first one (a "super
+// BOM"). The root component of b, with the components of b as its subcomponents, is added to
+// metadata.component.components of a and the dependencies of b are kept as they are, with an extra dependency from
+// the root of a to the root of b. This keeps every merged BOM identifiable as its own subtree.
+// Components of b with a BOMRef that is already used in a are merged into the existing component instead (BOMRefs
+// must be unique in a BOM), so subtrees can share components through the dependency graph.
+// If either BOM has no root component, or both have the same root component, a regular merge is performed.
+// Merge directives of the root components (see MergeDirectiveProperty) are applied first.
+// Returns a new BOM struct without modifying the inputs.
+func MergeBomAsSubtree(a, b *cyclonedx.BOM, opts ...Option) *cyclonedx.BOM {
+	if a != nil && b != nil {
+		a, b, _ = applyDirectives(a, b)
+	}
+
+	return mergeBomAsSubtree(a, b, newOptions(opts))
+}
+
+func mergeBomAsSubtree(a, b *cyclonedx.BOM, o options) *cyclonedx.BOM {
+	if a == nil {
+		if b == nil {
+			return nil
+		}
+		// Deep copy b
+		return copyBOM(b)
+	}
+
+	if b == nil {
+		// Deep copy a
+		return copyBOM(a)
+	}
+
+	if !canNest(a, b) {
+		return mergeBom(a, b, o)
+	}
+
+	merge := o.componentMerger(SectionComponents)
+
+	// Start with deep copy of a as base
+	result := copyBOM(a)
+	root := result.Metadata.Component
+
+	subtree := *copyComponent(b.Metadata.Component)
+
+	// the root of b may already be a component of a (e.g. a workspace member found by a scanner of a)
+	if subtree.BOMRef != "" {
+		var existing cyclonedx.Component
+		var found bool
+		if result.Components, existing, found = removeComponent(result.Components, subtree.BOMRef); found {
+			subtree = merge(existing, subtree)
+		}
+		if root.Components, existing, found = removeComponent(root.Components, subtree.BOMRef); found {
+			subtree = merge(existing, subtree)
+		}
+	}
+
+	used := map[string]bool{}
+	walkComponents(root, func(c *cyclonedx.Component) { used[c.BOMRef] = true })
+	walkComponentSlice(result.Components, func(c *cyclonedx.Component) { used[c.BOMRef] = true })
+
+	// components of b that are already in a
+	shared := map[string]cyclonedx.Component{}
+	var children []cyclonedx.Component
+	if b.Components != nil {
+		for _, component := range *b.Components {
+			if component.BOMRef != "" && used[component.BOMRef] {
+				if existing, found := shared[component.BOMRef]; found {
+					component = merge(existing, component)
+				}
+				shared[component.BOMRef] = component
+				continue
+			}
+			children = append(children, component)
+		}
+	}
+
+	if len(shared) > 0 {
+		mergeShared := func(c cyclonedx.Component) cyclonedx.Component {
+			if component, found := shared[c.BOMRef]; found {
+				return merge(c, component)
+			}
+			return c
+		}
+		if component, found := shared[root.BOMRef]; found {
+			*root = merge(*root, component)
+		}
+		root.Components = mapComponentSlice(root.Components, mergeShared)
+		result.Components = mapComponentSlice(result.Components, mergeShared)
+	}
+
+	if len(children) > 0 {
+		subtree.Components = mergeComponentSliceWith(subtree.Components, &children, merge)
+	}
+
+	if root.Components == nil {
+		root.Components = &[]cyclonedx.Component{}
+	}
+	*root.Components = append(*root.Components, subtree)
+
+	// the dependency graph of b is kept intact, the root of b becomes a dependency of the root of a
+	result.Dependencies = mergeDependencySlice(result.Dependencies, b.Dependencies)
+	if root.BOMRef != "" && subtree.BOMRef != "" {
+		result.Dependencies = mergeDependencySlice(result.Dependencies, &[]cyclonedx.Dependency{
+			{Ref: root.BOMRef, Dependencies: &[]string{subtree.BOMRef}},
+		})
+	}
+
+	// Merge other fields from b
+	result.Properties = mergePropertySlice(result.Properties, b.Properties)
+	result.ExternalReferences = mergeExternalReferenceSlice(result.ExternalReferences, b.ExternalReferences)
+	result.Services = mergeServiceSliceInternal(result.Services, b.Services)
+	result.Compositions = mergeCompositionSlice(result.Compositions, b.Compositions)
+	result.Vulnerabilities = mergeVulnerabilitySlice(result.Vulnerabilities, b.Vulnerabilities)
+	result.Annotations = mergeAnnotationSlice(result.Annotations, b.Annotations)
+	result.Formulation = mergeFormulaSlice(result.Formulation, b.Formulation)
+	result.Declarations = mergeDeclarations(result.Declarations, b.Declarations)
+	result.Definitions = mergeDefinitions(result.Definitions, b.Definitions)
+
+	return result
+}
+
+// MergeBomsAsSubtree merges the BOMs into a super BOM where every BOM after the first one is nested under the
+// root component of the first one, see MergeBomAsSubtree
+func MergeBomsAsSubtree(boms []*cyclonedx.BOM, opts ...Option) *cyclonedx.BOM {
+	if len(boms) == 0 {
+		return nil
+	}
+
+	// a single BOM is only repaired
+	if len(boms) == 1 {
+		repaired, _ := RepairGraph(boms[0], opts...)
+		return repaired
+	}
+
+	merged := boms[0]
+	for i := 1; i < len(boms); i++ {
+		merged = MergeBomAsSubtree(merged, boms[i], opts...)
+	}
+
+	repaired, _ := RepairGraph(merged, opts...)
+	return repaired
+}
+
+// walkComponents calls fn for the component and all of its (nested) subcomponents
+func walkComponents(component *cyclonedx.Component, fn func(c *cyclonedx.Component)) {
+	if component == nil {
+		return
+	}
+
+	fn(component)
+	walkComponentSlice(component.Components, fn)
+}
+
+func walkComponentSlice(components *[]cyclonedx.Component, fn func(c *cyclonedx.Component)) {
+	if components == nil {
+		return
+	}
+
+	for i := range *components {
+		walkComponents(&(*components)[i], fn)
+	}
+}
+
+// mapComponentSlice returns a copy of the components (and their subcomponents) with fn applied to each component.
+// Subcomponents can be shared with the merge inputs, so they are never modified in place.
+func mapComponentSlice(components *[]cyclonedx.Component, fn func(c cyclonedx.Component) cyclonedx.Component) *[]cyclonedx.Component {
+	if components == nil {
+		return nil
+	}
+
+	result := make([]cyclonedx.Component, 0, len(*components))
+	for _, component := range *components {
+		component = fn(component)
+		component.Components = mapComponentSlice(component.Components, fn)
+		result = append(result, component)
+	}
+	return &result
+}
+
+// removeComponent returns a copy of the components without the first component (or subcomponent) with the BOMRef
+func removeComponent(components *[]cyclonedx.Component, ref string) (*[]cyclonedx.Component, cyclonedx.Component, bool) {
+	if components == nil {
+		return nil, cyclonedx.Component{}, false
+	}
+
+	for i, component := range *components {
+		if component.BOMRef == ref {
+			remaining := make([]cyclonedx.Component, 0, len(*components)-1)
+			remaining = append(remaining, (*components)[:i]...)
+			remaining = append(remaining, (*components)[i+1:]...)
+			return &remaining, component, true
+		}
+
+		if subcomponents, removed, found := removeComponent(component.Components, ref); found {
+			result := slices.Clone(*components)
+			result[i].Components = subcomponents
+			return &result, removed, true
+		}
+	}
+
+	return components, cyclonedx.Component{}, false
+}
@@ -0,0 +1,106 @@
+package mergex
+
+import (
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeBomsAsSubtree(t *testing.T) {
+	product := &cyclonedx.BOM{
+		Metadata:     &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "product", Name: "product", Version: "2.0"}},
+		Dependencies: &[]cyclonedx.Dependency{{Ref: "product"}},
+	}
+
+	api := &cyclonedx.BOM{
+		Metadata: &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "api", Name: "api", Version: "1.0"}},
+		Components: &[]cyclonedx.Component{
+			{BOMRef: "pkg:npm/express@4.0.0", Name: "express", Version: "4.0.0"},
+			{BOMRef: "pkg:npm/lodash@4.17.21", Name: "lodash", Version: "4.17.21"},
+		},
+		Dependencies: &[]cyclonedx.Dependency{
+			{Ref: "api", Dependencies: &[]string{"pkg:npm/express@4.0.0"}},
+			{Ref: "pkg:npm/express@4.0.0", Dependencies: &[]string{"pkg:npm/lodash@4.17.21"}},
+		},
+	}
+
+	web := &cyclonedx.BOM{
+		Metadata: &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "web", Name: "web", Version: "1.2"}},
+		Components: &[]cyclonedx.Component{
+			{BOMRef: "pkg:npm/react@18.0.0", Name: "react", Version: "18.0.0"},
+			{BOMRef: "pkg:npm/lodash@4.17.21", Name: "lodash", Version: "4.17.21", Licenses: &cyclonedx.Licenses{{License: &cyclonedx.License{ID: "MIT"}}}},
+		},
+		Dependencies: &[]cyclonedx.Dependency{
+			{Ref: "web", Dependencies: &[]string{"pkg:npm/react@18.0.0", "pkg:npm/lodash@4.17.21"}},
+		},
+	}
+
+	merged := MergeBomsAsSubtree([]*cyclonedx.BOM{product, api, web})
+
+	root := merged.Metadata.Component
+	assert.Equal(t, "product", root.BOMRef)
+	assert.Nil(t, merged.Components)
+	require.NotNil(t, root.Components)
+	require.Len(t, *root.Components, 2)
+
+	apiSubtree := (*root.Components)[0]
+	assert.Equal(t, "api", apiSubtree.BOMRef)
+	require.Len(t, *apiSubtree.Components, 2)
+	assert.Equal(t, "pkg:npm/express@4.0.0", (*apiSubtree.Components)[0].BOMRef)
+	// lodash is shared, it stays in the first subtree and gets the license from web
+	assert.Equal(t, "pkg:npm/lodash@4.17.21", (*apiSubtree.Components)[1].BOMRef)
+	assert.Len(t, *(*apiSubtree.Components)[1].Licenses, 1)
+
+	webSubtree := (*root.Components)[1]
+	assert.Equal(t, "web", webSubtree.BOMRef)
+	require.Len(t, *webSubtree.Components, 1)
+	assert.Equal(t, "pkg:npm/react@18.0.0", (*webSubtree.Components)[0].BOMRef)
+
+	require.Len(t, *merged.Dependencies, 4)
+	assert.Equal(t, "product", (*merged.Dependencies)[0].Ref)
+	assert.ElementsMatch(t, []string{"api", "web"}, *(*merged.Dependencies)[0].Dependencies)
+	assert.Equal(t, []cyclonedx.Dependency{
+		{Ref: "api", Dependencies: &[]string{"pkg:npm/express@4.0.0"}},
+		{Ref: "pkg:npm/express@4.0.0", Dependencies: &[]string{"pkg:npm/lodash@4.17.21"}},
+		{Ref: "web", Dependencies: &[]string{"pkg:npm/react@18.0.0", "pkg:npm/lodash@4.17.21"}},
+	}, (*merged.Dependencies)[1:])
+
+	// inputs are not modified
+	assert.Nil(t, product.Metadata.Component.Components)
+	assert.Nil(t, (*api.Components)[1].Licenses)
+}
+
+func TestMergeBomAsSubtreeRootAlreadyComponent(t *testing.T) {
+	workspace := &cyclonedx.BOM{
+		Metadata: &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "workspace", Name: "workspace"}},
+		Components: &[]cyclonedx.Component{
+			{BOMRef: "member", Name: "member", Version: "0.1.0", Supplier: &cyclonedx.OrganizationalEntity{Name: "Acme"}},
+		},
+	}
+
+	member := &cyclonedx.BOM{
+		Metadata:   &cyclonedx.Metadata{Component: &cyclonedx.Component{BOMRef: "member", Name: "member", Version: "0.1.0"}},
+		Components: &[]cyclonedx.Component{{BOMRef: "dep", Name: "dep"}},
+	}
+
+	merged := MergeBomAsSubtree(workspace, member)
+
+	assert.Empty(t, *merged.Components)
+	require.Len(t, *merged.Metadata.Component.Components, 1)
+	subtree := (*merged.Metadata.Component.Components)[0]
+	assert.Equal(t, "member", subtree.BOMRef)
+	assert.Equal(t, "Acme", subtree.Supplier.Name)
+	assert.Equal(t, &[]cyclonedx.Component{{BOMRef: "dep", Name: "dep"}}, subtree.Components)
+
+	// input is not modified
+	assert.Len(t, *workspace.Components, 1)
+}
+
+func TestMergeBomAsSubtreeWithoutRoot(t *testing.T) {
+	a := &cyclonedx.BOM{Components: &[]cyclonedx.Component{{BOMRef: "a", Name: "a"}}}
+	b := &cyclonedx.BOM{Components: &[]cyclonedx.Component{{BOMRef: "b", Name: "b"}}}
+
+	assert.Equal(t, MergeBom(a, b), MergeBomAsSubtree(a, b))
+}
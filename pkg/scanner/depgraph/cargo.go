@@ -0,0 +1,71 @@
+package depgraph
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+type cargoLockfile struct {
+	Packages []cargoPackage `toml:"package"`
+}
+
+type cargoPackage struct {
+	Name         string   `toml:"name"`
+	Version      string   `toml:"version"`
+	Source       string   `toml:"source"`
+	Dependencies []string `toml:"dependencies"`
+}
+
+// parseCargoLockfile reads the dependency graph from a Cargo.lock file.
+// Packages without a source are workspace members, their dependencies are the direct dependencies of the target.
+func parseCargoLockfile(dir string, filename string) (*Graph, error) {
+	var lockfile cargoLockfile
+	if _, err := toml.DecodeFile(filepath.Join(dir, filename), &lockfile); err != nil {
+		return nil, err
+	}
+
+	byName := map[string][]cargoPackage{}
+	for _, pkg := range lockfile.Packages {
+		byName[pkg.Name] = append(byName[pkg.Name], pkg)
+	}
+
+	// dependencies are written as "name", "name version" or "name version (source)"
+	resolve := func(dependency string) (Key, bool) {
+		fields := strings.Fields(dependency)
+		if len(fields) == 0 {
+			return Key{}, false
+		}
+
+		candidates := byName[fields[0]]
+		for _, candidate := range candidates {
+			if len(fields) == 1 || candidate.Version == fields[1] {
+				return NewKey(TypeCargo, candidate.Name, candidate.Version), true
+			}
+		}
+
+		return Key{}, false
+	}
+
+	graph := NewGraph()
+
+	for _, pkg := range lockfile.Packages {
+		var dependencies []Key
+		for _, dependency := range pkg.Dependencies {
+			if key, ok := resolve(dependency); ok {
+				dependencies = append(dependencies, key)
+			}
+		}
+
+		graph.AddPackage(NewKey(TypeCargo, pkg.Name, pkg.Version), dependencies...)
+
+		if pkg.Source == "" {
+			for _, key := range dependencies {
+				graph.AddDirect(key)
+			}
+		}
+	}
+
+	return graph, nil
+}
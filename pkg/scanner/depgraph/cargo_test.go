@@ -0,0 +1,62 @@
+package depgraph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCargoLockfile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "Cargo.lock", `version = 3
+
+[[package]]
+name = "app"
+version = "0.1.0"
+dependencies = [
+ "rand 0.8.5",
+ "serde",
+]
+
+[[package]]
+name = "rand"
+version = "0.8.5"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+dependencies = [
+ "libc",
+ "rand_core 0.6.4 (registry+https://github.com/rust-lang/crates.io-index)",
+]
+
+[[package]]
+name = "rand_core"
+version = "0.6.4"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+
+[[package]]
+name = "rand_core"
+version = "0.5.1"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+
+[[package]]
+name = "libc"
+version = "0.2.150"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+
+[[package]]
+name = "serde"
+version = "1.0.193"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+`)
+
+	graph, err := parseCargoLockfile(dir, "Cargo.lock")
+	require.NoError(t, err)
+
+	rand := NewKey(TypeCargo, "rand", "0.8.5")
+	serde := NewKey(TypeCargo, "serde", "1.0.193")
+
+	assert.Equal(t, []Key{rand, serde}, graph.Direct)
+	assert.Equal(t, []Key{NewKey(TypeCargo, "libc", "0.2.150"), NewKey(TypeCargo, "rand_core", "0.6.4")}, graph.Packages[rand])
+	assert.True(t, graph.Contains(NewKey(TypeCargo, "rand_core", "0.5.1")))
+	assert.False(t, graph.IsDirect(NewKey(TypeCargo, "libc", "0.2.150")))
+}
@@ -0,0 +1,51 @@
+package depgraph
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// parseGoMod reads the requirements from a go.mod file.
+// go.mod doesn't record the edges between modules, but requirements marked with "// indirect"
+// are transitive dependencies and all other requirements are direct dependencies.
+func parseGoMod(dir string, filename string) (*Graph, error) {
+	path := filepath.Join(dir, filename)
+
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := modfile.Parse(path, bs, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// apply replace directives the same way the scalibr go.mod extractor does
+	replaced := func(modulePath string, version string) (string, string) {
+		for _, replace := range f.Replace {
+			if replace.Old.Path == modulePath && (replace.Old.Version == "" || replace.Old.Version == version) {
+				return replace.New.Path, replace.New.Version
+			}
+		}
+		return modulePath, version
+	}
+
+	graph := NewGraph()
+
+	for _, require := range f.Require {
+		modulePath, version := replaced(require.Mod.Path, require.Mod.Version)
+		key := NewKey(TypeGolang, modulePath, strings.TrimPrefix(version, "v"))
+
+		graph.AddPackage(key)
+
+		if !require.Indirect {
+			graph.AddDirect(key)
+		}
+	}
+
+	return graph, nil
+}
@@ -0,0 +1,58 @@
+package depgraph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGoMod(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "go.mod", `module example.com/app
+
+go 1.22
+
+require (
+	github.com/google/uuid v1.6.0
+	github.com/spf13/cobra v1.9.1
+	github.com/old/module v1.0.0
+)
+
+require (
+	github.com/spf13/pflag v1.0.6 // indirect
+	github.com/inconshreveable/mousetrap v1.1.0 // indirect
+)
+
+replace github.com/old/module => github.com/new/module v1.2.0
+`)
+
+	graph, err := parseGoMod(dir, "go.mod")
+	require.NoError(t, err)
+
+	assert.Equal(t, []Key{
+		NewKey(TypeGolang, "github.com/google/uuid", "1.6.0"),
+		NewKey(TypeGolang, "github.com/spf13/cobra", "1.9.1"),
+		NewKey(TypeGolang, "github.com/new/module", "1.2.0"),
+	}, graph.Direct)
+
+	pflag := NewKey(TypeGolang, "github.com/spf13/pflag", "1.0.6")
+	assert.True(t, graph.Contains(pflag))
+	assert.False(t, graph.IsDirect(pflag))
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "go.mod", "module example.com/app\n\nrequire github.com/google/uuid v1.6.0\n")
+	writeFile(t, dir, "Cargo.lock", "not [valid toml")
+
+	graph, err := Load(dir, []string{"go.mod", "go.sum", "Cargo.lock", "observer.yml"})
+	assert.Error(t, err)
+	require.NotNil(t, graph)
+	assert.Equal(t, []Key{NewKey(TypeGolang, "github.com/google/uuid", "1.6.0")}, graph.Direct)
+	assert.False(t, graph.IsEmpty())
+
+	var nilGraph *Graph
+	assert.True(t, nilGraph.IsEmpty())
+	assert.False(t, nilGraph.Contains(NewKey(TypeGolang, "github.com/google/uuid", "1.6.0")))
+}
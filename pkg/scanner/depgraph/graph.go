@@ -0,0 +1,173 @@
+// Package depgraph reads the dependency relationships recorded in lockfiles (package-lock.json, Cargo.lock,
+// poetry.lock, go.mod etc) so that scanners that only report a flat list of packages can produce a
+// dependency tree.
+package depgraph
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/sbom-observer/observer-cli/pkg/log"
+)
+
+const (
+	TypeNpm    = "npm"
+	TypeCargo  = "cargo"
+	TypePypi   = "pypi"
+	TypeGolang = "golang"
+)
+
+// Key identifies a package in a dependency graph by its purl type, name and version
+type Key struct {
+	Type    string
+	Name    string
+	Version string
+}
+
+// NewKey creates a Key with the name normalized according to the rules of the package type
+func NewKey(purlType string, name string, version string) Key {
+	if purlType == TypePypi {
+		name = normalizePythonName(name)
+	}
+
+	return Key{
+		Type:    purlType,
+		Name:    name,
+		Version: version,
+	}
+}
+
+func (k Key) String() string {
+	return fmt.Sprintf("%s:%s@%s", k.Type, k.Name, k.Version)
+}
+
+// Graph is a dependency graph for a single scan target.
+// Direct contains the dependencies of the root (the target itself) and Packages maps every
+// package known from the lockfiles to its own (direct) dependencies.
+type Graph struct {
+	Direct   []Key
+	Packages map[Key][]Key
+	direct   map[Key]struct{}
+}
+
+func NewGraph() *Graph {
+	return &Graph{
+		Packages: map[Key][]Key{},
+		direct:   map[Key]struct{}{},
+	}
+}
+
+// Contains returns true if the package was found in any of the lockfiles
+func (g *Graph) Contains(key Key) bool {
+	if g == nil {
+		return false
+	}
+
+	_, found := g.Packages[key]
+	return found
+}
+
+// IsDirect returns true if the package is a direct dependency of the root
+func (g *Graph) IsDirect(key Key) bool {
+	if g == nil {
+		return false
+	}
+
+	_, found := g.direct[key]
+	return found
+}
+
+// IsEmpty returns true if the graph doesn't contain any packages
+func (g *Graph) IsEmpty() bool {
+	return g == nil || len(g.Packages) == 0
+}
+
+// AddPackage adds a package (and its dependencies) to the graph
+func (g *Graph) AddPackage(key Key, dependencies ...Key) {
+	existing := g.Packages[key]
+	for _, dependency := range dependencies {
+		if dependency != key && !slices.Contains(existing, dependency) {
+			existing = append(existing, dependency)
+		}
+	}
+	g.Packages[key] = existing
+}
+
+// AddDirect marks a package as a direct dependency of the root
+func (g *Graph) AddDirect(key Key) {
+	if _, found := g.direct[key]; !found {
+		g.direct[key] = struct{}{}
+		g.Direct = append(g.Direct, key)
+	}
+
+	if _, found := g.Packages[key]; !found {
+		g.Packages[key] = nil
+	}
+}
+
+// Merge adds all packages and direct dependencies from other to the graph
+func (g *Graph) Merge(other *Graph) {
+	if other == nil {
+		return
+	}
+
+	for key, dependencies := range other.Packages {
+		g.AddPackage(key, dependencies...)
+	}
+
+	for _, key := range other.Direct {
+		g.AddDirect(key)
+	}
+}
+
+// parser parses a single lockfile in dir into a dependency graph
+type parser func(dir string, filename string) (*Graph, error)
+
+var parsers = map[string]parser{
+	"package-lock.json":   parseNpmLockfile,
+	"npm-shrinkwrap.json": parseNpmLockfile,
+	"Cargo.lock":          parseCargoLockfile,
+	"poetry.lock":         parsePoetryLockfile,
+	"go.mod":              parseGoMod,
+}
+
+// Load reads the dependency graph from all supported lockfiles among files (relative to dir).
+// Unsupported files are ignored and a (possibly empty) graph is returned together with any parse errors.
+func Load(dir string, files []string) (*Graph, error) {
+	graph := NewGraph()
+
+	// sort to make the order of direct dependencies deterministic
+	files = slices.Clone(files)
+	slices.Sort(files)
+
+	var errs []error
+	for _, filename := range files {
+		parse, found := parsers[filepath.Base(filename)]
+		if !found {
+			continue
+		}
+
+		log.Debug("reading dependency graph", "dir", dir, "filename", filename)
+
+		g, err := parse(dir, filename)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read dependency graph from %s: %w", filename, err))
+			continue
+		}
+
+		graph.Merge(g)
+	}
+
+	return graph, errors.Join(errs...)
+}
+
+var pythonNameSeparators = regexp.MustCompile(`[-_.]+`)
+
+// normalizePythonName normalizes a Python package name according to PEP 503
+func normalizePythonName(name string) string {
+	return strings.ToLower(pythonNameSeparators.ReplaceAllString(name, "-"))
+}
@@ -0,0 +1,226 @@
+package depgraph
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type npmLockfile struct {
+	LockfileVersion int                          `json:"lockfileVersion"`
+	Packages        map[string]npmLockPackage    `json:"packages"`
+	Dependencies    map[string]npmLockDependency `json:"dependencies"`
+}
+
+// npmLockPackage is an entry in the "packages" section (lockfileVersion 2 and 3)
+type npmLockPackage struct {
+	Name                 string            `json:"name"`
+	Version              string            `json:"version"`
+	Resolved             string            `json:"resolved"`
+	Link                 bool              `json:"link"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+}
+
+// npmLockDependency is an entry in the nested "dependencies" section (lockfileVersion 1)
+type npmLockDependency struct {
+	Version      string                       `json:"version"`
+	Requires     map[string]string            `json:"requires"`
+	Dependencies map[string]npmLockDependency `json:"dependencies"`
+}
+
+// parseNpmLockfile reads the dependency graph from a package-lock.json or npm-shrinkwrap.json file.
+// Dependencies are resolved using the node_modules lookup rules (nearest node_modules directory first).
+func parseNpmLockfile(dir string, filename string) (*Graph, error) {
+	bs, err := os.ReadFile(filepath.Join(dir, filename))
+	if err != nil {
+		return nil, err
+	}
+
+	var lockfile npmLockfile
+	if err := json.Unmarshal(bs, &lockfile); err != nil {
+		return nil, err
+	}
+
+	packages := lockfile.Packages
+	if len(packages) == 0 && len(lockfile.Dependencies) > 0 {
+		packages = map[string]npmLockPackage{}
+		flattenNpmLockDependencies(packages, "", lockfile.Dependencies)
+	}
+
+	// lockfileVersion 1 doesn't record the root package, use package.json instead
+	if _, found := packages[""]; !found {
+		root, err := readPackageJSON(dir)
+		if err == nil {
+			packages[""] = root
+		}
+	}
+
+	graph := NewGraph()
+
+	for _, path := range sortedKeys(packages) {
+		if path == "" {
+			continue
+		}
+
+		key, ok := npmPackageKey(packages, path)
+		if !ok {
+			continue
+		}
+
+		pkg := packages[path]
+		graph.AddPackage(key, resolveNpmDependencies(packages, path, pkg.Dependencies, pkg.OptionalDependencies, pkg.PeerDependencies)...)
+	}
+
+	if root, found := packages[""]; found {
+		for _, key := range resolveNpmDependencies(packages, "", root.Dependencies, root.DevDependencies, root.OptionalDependencies, root.PeerDependencies) {
+			graph.AddDirect(key)
+		}
+	}
+
+	// workspaces are linked from node_modules and can have their own devDependencies
+	for _, path := range sortedKeys(packages) {
+		if pkg := packages[path]; pkg.Link && pkg.Resolved != "" {
+			if target, found := packages[pkg.Resolved]; found {
+				if key, ok := npmPackageKey(packages, pkg.Resolved); ok {
+					graph.AddPackage(key, resolveNpmDependencies(packages, pkg.Resolved, target.DevDependencies)...)
+				}
+			}
+		}
+	}
+
+	return graph, nil
+}
+
+// npmPackageKey returns the key for the package at path (following links)
+func npmPackageKey(packages map[string]npmLockPackage, path string) (Key, bool) {
+	pkg, found := packages[path]
+	if !found {
+		return Key{}, false
+	}
+
+	if pkg.Link {
+		if pkg.Resolved == "" || pkg.Resolved == path {
+			return Key{}, false
+		}
+		return npmPackageKey(packages, pkg.Resolved)
+	}
+
+	name := pkg.Name
+	if name == "" {
+		name = npmPackageName(path)
+	}
+
+	if name == "" {
+		return Key{}, false
+	}
+
+	return NewKey(TypeNpm, name, pkg.Version), true
+}
+
+// resolveNpmDependencies resolves dependency names to keys as seen from the package at path
+func resolveNpmDependencies(packages map[string]npmLockPackage, path string, dependencies ...map[string]string) []Key {
+	var keys []Key
+	for _, deps := range dependencies {
+		for _, name := range sortedKeys(deps) {
+			resolved, found := resolveNpmPath(packages, path, name)
+			if !found {
+				continue
+			}
+
+			if key, ok := npmPackageKey(packages, resolved); ok {
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	return keys
+}
+
+// resolveNpmPath finds the installed location of the dependency name for the package at path
+// by walking up the node_modules hierarchy
+func resolveNpmPath(packages map[string]npmLockPackage, path string, name string) (string, bool) {
+	for {
+		candidate := "node_modules/" + name
+		if path != "" {
+			candidate = path + "/node_modules/" + name
+		}
+
+		if _, found := packages[candidate]; found {
+			return candidate, true
+		}
+
+		if path == "" {
+			return "", false
+		}
+
+		i := strings.LastIndex(path, "/node_modules/")
+		if i == -1 {
+			path = ""
+		} else {
+			path = path[:i]
+		}
+	}
+}
+
+// npmPackageName extracts the package name from a node_modules path (including scope)
+func npmPackageName(path string) string {
+	i := strings.LastIndex(path, "node_modules/")
+	if i == -1 {
+		return ""
+	}
+
+	return path[i+len("node_modules/"):]
+}
+
+// flattenNpmLockDependencies converts the nested lockfileVersion 1 format to node_modules paths
+func flattenNpmLockDependencies(packages map[string]npmLockPackage, parent string, dependencies map[string]npmLockDependency) {
+	for name, dependency := range dependencies {
+		path := "node_modules/" + name
+		if parent != "" {
+			path = parent + "/node_modules/" + name
+		}
+
+		pkg := npmLockPackage{
+			Version:      dependency.Version,
+			Dependencies: dependency.Requires,
+		}
+
+		// aliased packages (i.e. "npm:real-name@1.0.0")
+		if strings.HasPrefix(dependency.Version, "npm:") {
+			if i := strings.LastIndex(dependency.Version, "@"); i > len("npm:") {
+				pkg.Name = dependency.Version[len("npm:"):i]
+				pkg.Version = dependency.Version[i+1:]
+			}
+		}
+
+		packages[path] = pkg
+
+		flattenNpmLockDependencies(packages, path, dependency.Dependencies)
+	}
+}
+
+func readPackageJSON(dir string) (npmLockPackage, error) {
+	var pkg npmLockPackage
+
+	bs, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return pkg, err
+	}
+
+	err = json.Unmarshal(bs, &pkg)
+	return pkg, err
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,105 @@
+package depgraph
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, dir string, filename string, contents string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, filename), []byte(contents), 0644))
+}
+
+func TestParseNpmLockfile_V3(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "package-lock.json", `{
+  "name": "app",
+  "lockfileVersion": 3,
+  "packages": {
+    "": {
+      "name": "app",
+      "dependencies": { "express": "^4.0.0", "alias": "npm:lodash@^4.0.0" },
+      "devDependencies": { "jest": "^29.0.0" }
+    },
+    "node_modules/express": {
+      "version": "4.18.2",
+      "dependencies": { "debug": "2.6.9", "qs": "6.11.0" }
+    },
+    "node_modules/express/node_modules/debug": { "version": "2.6.9", "dependencies": { "ms": "2.0.0" } },
+    "node_modules/debug": { "version": "4.3.4", "dependencies": { "ms": "2.1.2" } },
+    "node_modules/ms": { "version": "2.1.2" },
+    "node_modules/express/node_modules/ms": { "version": "2.0.0" },
+    "node_modules/qs": { "version": "6.11.0" },
+    "node_modules/alias": { "name": "lodash", "version": "4.17.21" },
+    "node_modules/jest": { "version": "29.7.0", "dev": true, "dependencies": { "debug": "^4.0.0" } }
+  }
+}`)
+
+	graph, err := parseNpmLockfile(dir, "package-lock.json")
+	require.NoError(t, err)
+
+	express := NewKey(TypeNpm, "express", "4.18.2")
+	lodash := NewKey(TypeNpm, "lodash", "4.17.21")
+	jest := NewKey(TypeNpm, "jest", "29.7.0")
+
+	assert.ElementsMatch(t, []Key{express, lodash, jest}, graph.Direct)
+	assert.ElementsMatch(t, []Key{NewKey(TypeNpm, "debug", "2.6.9"), NewKey(TypeNpm, "qs", "6.11.0")}, graph.Packages[express])
+	assert.Equal(t, []Key{NewKey(TypeNpm, "ms", "2.0.0")}, graph.Packages[NewKey(TypeNpm, "debug", "2.6.9")])
+	assert.Equal(t, []Key{NewKey(TypeNpm, "ms", "2.1.2")}, graph.Packages[NewKey(TypeNpm, "debug", "4.3.4")])
+	assert.Equal(t, []Key{NewKey(TypeNpm, "debug", "4.3.4")}, graph.Packages[jest])
+
+	assert.True(t, graph.IsDirect(express))
+	assert.False(t, graph.IsDirect(NewKey(TypeNpm, "qs", "6.11.0")))
+	assert.True(t, graph.Contains(NewKey(TypeNpm, "qs", "6.11.0")))
+}
+
+func TestParseNpmLockfile_V1(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "package.json", `{ "name": "app", "dependencies": { "express": "^4.0.0" } }`)
+	writeFile(t, dir, "package-lock.json", `{
+  "name": "app",
+  "lockfileVersion": 1,
+  "dependencies": {
+    "express": {
+      "version": "4.18.2",
+      "requires": { "debug": "2.6.9" },
+      "dependencies": {
+        "debug": { "version": "2.6.9" }
+      }
+    },
+    "debug": { "version": "4.3.4" }
+  }
+}`)
+
+	graph, err := parseNpmLockfile(dir, "package-lock.json")
+	require.NoError(t, err)
+
+	express := NewKey(TypeNpm, "express", "4.18.2")
+	assert.Equal(t, []Key{express}, graph.Direct)
+	assert.Equal(t, []Key{NewKey(TypeNpm, "debug", "2.6.9")}, graph.Packages[express])
+	assert.True(t, graph.Contains(NewKey(TypeNpm, "debug", "4.3.4")))
+}
+
+func TestParseNpmLockfile_Workspaces(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "package-lock.json", `{
+  "lockfileVersion": 3,
+  "packages": {
+    "": { "name": "root", "workspaces": ["packages/*"] },
+    "node_modules/lib": { "resolved": "packages/lib", "link": true },
+    "packages/lib": { "name": "lib", "version": "1.0.0", "dependencies": { "qs": "^6.0.0" } },
+    "node_modules/qs": { "version": "6.11.0" }
+  }
+}`)
+
+	graph, err := parseNpmLockfile(dir, "package-lock.json")
+	require.NoError(t, err)
+
+	lib := NewKey(TypeNpm, "lib", "1.0.0")
+	assert.True(t, graph.Contains(lib))
+	assert.Equal(t, []Key{NewKey(TypeNpm, "qs", "6.11.0")}, graph.Packages[lib])
+}
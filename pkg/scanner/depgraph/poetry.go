@@ -0,0 +1,140 @@
+package depgraph
+
+import (
+	"path/filepath"
+	"regexp"
+
+	"github.com/BurntSushi/toml"
+)
+
+type poetryLockfile struct {
+	Packages []poetryPackage `toml:"package"`
+}
+
+type poetryPackage struct {
+	Name         string         `toml:"name"`
+	Version      string         `toml:"version"`
+	Dependencies map[string]any `toml:"dependencies"`
+}
+
+type pyprojectFile struct {
+	Project struct {
+		Dependencies         []string            `toml:"dependencies"`
+		OptionalDependencies map[string][]string `toml:"optional-dependencies"`
+	} `toml:"project"`
+	DependencyGroups map[string][]any `toml:"dependency-groups"`
+	Tool             struct {
+		Poetry struct {
+			Dependencies    map[string]any `toml:"dependencies"`
+			DevDependencies map[string]any `toml:"dev-dependencies"`
+			Group           map[string]struct {
+				Dependencies map[string]any `toml:"dependencies"`
+			} `toml:"group"`
+		} `toml:"poetry"`
+	} `toml:"tool"`
+}
+
+// parsePoetryLockfile reads the dependency graph from a poetry.lock file.
+// The direct dependencies are read from pyproject.toml in the same directory, if missing
+// all packages that no other package depends on are considered direct dependencies.
+func parsePoetryLockfile(dir string, filename string) (*Graph, error) {
+	var lockfile poetryLockfile
+	if _, err := toml.DecodeFile(filepath.Join(dir, filename), &lockfile); err != nil {
+		return nil, err
+	}
+
+	byName := map[string]Key{}
+	for _, pkg := range lockfile.Packages {
+		key := NewKey(TypePypi, pkg.Name, pkg.Version)
+		if _, found := byName[key.Name]; !found {
+			byName[key.Name] = key
+		}
+	}
+
+	graph := NewGraph()
+	dependedOn := map[Key]bool{}
+
+	for _, pkg := range lockfile.Packages {
+		var dependencies []Key
+		for _, name := range sortedKeys(pkg.Dependencies) {
+			if key, found := byName[normalizePythonName(name)]; found {
+				dependencies = append(dependencies, key)
+				dependedOn[key] = true
+			}
+		}
+
+		graph.AddPackage(NewKey(TypePypi, pkg.Name, pkg.Version), dependencies...)
+	}
+
+	directNames, err := readPyprojectDependencies(dir)
+	if err != nil || len(directNames) == 0 {
+		for _, pkg := range lockfile.Packages {
+			key := NewKey(TypePypi, pkg.Name, pkg.Version)
+			if !dependedOn[key] {
+				graph.AddDirect(key)
+			}
+		}
+		return graph, nil
+	}
+
+	for _, name := range directNames {
+		if key, found := byName[normalizePythonName(name)]; found {
+			graph.AddDirect(key)
+		}
+	}
+
+	return graph, nil
+}
+
+// pep508Name matches the distribution name at the start of a PEP 508 requirement (i.e. "requests[security]>=2.8.1")
+var pep508Name = regexp.MustCompile(`^\s*([A-Za-z0-9][A-Za-z0-9._-]*)`)
+
+// readPyprojectDependencies returns the names of all dependencies declared in pyproject.toml (PEP 621, PEP 735 and poetry)
+func readPyprojectDependencies(dir string) ([]string, error) {
+	var pyproject pyprojectFile
+	if _, err := toml.DecodeFile(filepath.Join(dir, "pyproject.toml"), &pyproject); err != nil {
+		return nil, err
+	}
+
+	var names []string
+
+	addRequirement := func(requirement string) {
+		if m := pep508Name.FindStringSubmatch(requirement); m != nil {
+			names = append(names, m[1])
+		}
+	}
+
+	for _, requirement := range pyproject.Project.Dependencies {
+		addRequirement(requirement)
+	}
+
+	for _, group := range sortedKeys(pyproject.Project.OptionalDependencies) {
+		for _, requirement := range pyproject.Project.OptionalDependencies[group] {
+			addRequirement(requirement)
+		}
+	}
+
+	for _, group := range sortedKeys(pyproject.DependencyGroups) {
+		for _, requirement := range pyproject.DependencyGroups[group] {
+			// groups can also include other groups ({include-group = "name"})
+			if s, ok := requirement.(string); ok {
+				addRequirement(s)
+			}
+		}
+	}
+
+	poetry := pyproject.Tool.Poetry
+	for _, name := range sortedKeys(poetry.Dependencies) {
+		if name != "python" {
+			names = append(names, name)
+		}
+	}
+
+	names = append(names, sortedKeys(poetry.DevDependencies)...)
+
+	for _, group := range sortedKeys(poetry.Group) {
+		names = append(names, sortedKeys(poetry.Group[group].Dependencies)...)
+	}
+
+	return names, nil
+}
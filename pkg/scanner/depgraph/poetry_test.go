@@ -0,0 +1,92 @@
+package depgraph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPoetryLock = `
+[[package]]
+name = "requests"
+version = "2.31.0"
+
+[package.dependencies]
+certifi = ">=2017.4.17"
+charset-normalizer = ">=2,<4"
+
+[[package]]
+name = "certifi"
+version = "2023.11.17"
+
+[[package]]
+name = "charset-normalizer"
+version = "3.3.2"
+
+[[package]]
+name = "pytest"
+version = "7.4.3"
+
+[package.dependencies]
+colorama = {version = "*", markers = "sys_platform == \"win32\""}
+
+[[package]]
+name = "colorama"
+version = "0.4.6"
+`
+
+func TestParsePoetryLockfile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "poetry.lock", testPoetryLock)
+	writeFile(t, dir, "pyproject.toml", `
+[tool.poetry.dependencies]
+python = "^3.11"
+Requests = "^2.31"
+
+[tool.poetry.group.dev.dependencies]
+pytest = "^7.4"
+`)
+
+	graph, err := parsePoetryLockfile(dir, "poetry.lock")
+	require.NoError(t, err)
+
+	requests := NewKey(TypePypi, "requests", "2.31.0")
+	pytest := NewKey(TypePypi, "pytest", "7.4.3")
+
+	assert.Equal(t, []Key{requests, pytest}, graph.Direct)
+	assert.Equal(t, []Key{NewKey(TypePypi, "certifi", "2023.11.17"), NewKey(TypePypi, "charset-normalizer", "3.3.2")}, graph.Packages[requests])
+	assert.Equal(t, []Key{NewKey(TypePypi, "colorama", "0.4.6")}, graph.Packages[pytest])
+}
+
+func TestParsePoetryLockfile_PEP621(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "poetry.lock", testPoetryLock)
+	writeFile(t, dir, "pyproject.toml", `
+[project]
+name = "app"
+dependencies = ["requests[socks]>=2.31"]
+`)
+
+	graph, err := parsePoetryLockfile(dir, "poetry.lock")
+	require.NoError(t, err)
+
+	assert.Equal(t, []Key{NewKey(TypePypi, "requests", "2.31.0")}, graph.Direct)
+}
+
+func TestParsePoetryLockfile_WithoutPyproject(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "poetry.lock", testPoetryLock)
+
+	graph, err := parsePoetryLockfile(dir, "poetry.lock")
+	require.NoError(t, err)
+
+	// packages that no other package depends on
+	assert.Equal(t, []Key{NewKey(TypePypi, "requests", "2.31.0"), NewKey(TypePypi, "pytest", "7.4.3")}, graph.Direct)
+}
+
+func TestNormalizePythonName(t *testing.T) {
+	assert.Equal(t, "charset-normalizer", normalizePythonName("Charset_Normalizer"))
+	assert.Equal(t, "zope-interface", normalizePythonName("zope.interface"))
+	assert.Equal(t, "a-b", normalizePythonName("a-_.b"))
+}
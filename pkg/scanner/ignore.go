@@ -0,0 +1,124 @@
+package scanner
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/sbom-observer/observer-cli/pkg/types"
+)
+
+// IgnoreFileName is the name of the gitignore-style file that excludes paths from being scanned
+const IgnoreFileName = ".observerignore"
+
+type ignoreRule struct {
+	pattern gitignore.Pattern
+	rule    string
+	source  string
+}
+
+// IgnoreRules contains the exclude rules from .observerignore files and the exclude list in observer.yml
+// files found while walking a repository. Rules follow the .gitignore format; rules are relative to the
+// directory of the file they were loaded from and the last matching rule wins.
+type IgnoreRules struct {
+	rules []ignoreRule
+}
+
+// AddRule adds a single gitignore-style rule relative to the directory domain (path components from the scan root).
+// Empty rules and comments are ignored.
+func (r *IgnoreRules) AddRule(rule string, domain []string, source string) {
+	rule = strings.TrimSpace(rule)
+	if rule == "" || strings.HasPrefix(rule, "#") {
+		return
+	}
+
+	r.rules = append(r.rules, ignoreRule{
+		pattern: gitignore.ParsePattern(rule, domain),
+		rule:    rule,
+		source:  source,
+	})
+}
+
+// LoadDir loads the rules from .observerignore and the exclude list in observer.yml/observer.yaml in dir.
+// relativeDir is the path of dir relative to the scan root ("" for the root itself).
+func (r *IgnoreRules) LoadDir(dir string, relativeDir string) error {
+	domain := splitPath(relativeDir)
+
+	ignoreFile := filepath.Join(dir, IgnoreFileName)
+	if err := r.loadIgnoreFile(ignoreFile, domain); err != nil {
+		return err
+	}
+
+	for _, name := range []string{"observer.yml", "observer.yaml"} {
+		configFile := filepath.Join(dir, name)
+		if _, err := os.Stat(configFile); err != nil {
+			continue
+		}
+
+		var config types.ScanConfig
+		if err := types.LoadConfig(&config, configFile); err != nil {
+			return err
+		}
+
+		for _, rule := range config.Exclude {
+			r.AddRule(rule, domain, configFile)
+		}
+	}
+
+	return nil
+}
+
+func (r *IgnoreRules) loadIgnoreFile(filename string, domain []string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		r.AddRule(scanner.Text(), domain, fmt.Sprintf("%s:%d", filename, line))
+	}
+
+	return scanner.Err()
+}
+
+// Match returns true if the path (relative to the scan root) is excluded, together with the rule and
+// its source (file and line) that excluded it
+func (r *IgnoreRules) Match(relativePath string, isDir bool) (bool, string, string) {
+	if r == nil {
+		return false, "", ""
+	}
+
+	path := splitPath(relativePath)
+	if len(path) == 0 {
+		return false, "", ""
+	}
+
+	// the last matching rule decides (negated rules re-include paths)
+	for i := len(r.rules) - 1; i >= 0; i-- {
+		switch r.rules[i].pattern.Match(path, isDir) {
+		case gitignore.Exclude:
+			return true, r.rules[i].rule, r.rules[i].source
+		case gitignore.Include:
+			return false, r.rules[i].rule, r.rules[i].source
+		}
+	}
+
+	return false, "", ""
+}
+
+func splitPath(relativePath string) []string {
+	relativePath = strings.Trim(filepath.ToSlash(relativePath), "/")
+	if relativePath == "" || relativePath == "." {
+		return nil
+	}
+	return strings.Split(relativePath, "/")
+}
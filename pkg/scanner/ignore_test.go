@@ -0,0 +1,95 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIgnoreRules_Match(t *testing.T) {
+	rules := &IgnoreRules{}
+	rules.AddRule("# comment", nil, "test")
+	rules.AddRule("examples/", nil, "test:1")
+	rules.AddRule("*.generated.json", nil, "test:2")
+	rules.AddRule("fixtures", []string{"tests"}, "test:3")
+	rules.AddRule("!examples/keep", nil, "test:4")
+
+	tests := []struct {
+		path     string
+		isDir    bool
+		excluded bool
+		rule     string
+	}{
+		{"/examples", true, true, "examples/"},
+		{"/examples", false, false, ""},
+		{"/src/examples", true, true, "examples/"},
+		{"/src/package.generated.json", false, true, "*.generated.json"},
+		{"/tests/fixtures", true, true, "fixtures"},
+		{"/fixtures", true, false, ""},
+		{"/examples/keep", true, false, "!examples/keep"},
+		{"/src", true, false, ""},
+		{"", true, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			excluded, rule, _ := rules.Match(tt.path, tt.isDir)
+			assert.Equal(t, tt.excluded, excluded)
+			assert.Equal(t, tt.rule, rule)
+		})
+	}
+}
+
+func TestFindScanTargets_Excludes(t *testing.T) {
+	root := t.TempDir()
+
+	writeFile := func(name string, content string) {
+		filename := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+		require.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+	}
+
+	writeFile("go.mod", "module example.com/app\n")
+	writeFile(".observerignore", "# example projects\nexamples/\n")
+	writeFile("examples/demo/package.json", "{}")
+	writeFile("services/api/package.json", "{}")
+	writeFile("services/api/observer.yml", "exclude:\n  - testdata\n")
+	writeFile("services/api/testdata/package.json", "{}")
+	writeFile("services/web/testdata/package.json", "{}")
+
+	targets, err := FindScanTargets(root, 5, nil)
+	require.NoError(t, err)
+
+	var paths []string
+	for path := range targets {
+		rel, err := filepath.Rel(root, path)
+		require.NoError(t, err)
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+
+	assert.ElementsMatch(t, []string{".", "services/api", "services/web/testdata"}, paths)
+}
+
+func TestIgnoreRules_LoadDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, IgnoreFileName), []byte("examples/\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "observer.yml"), []byte("exclude: [fixtures/]\n"), 0644))
+
+	rules := &IgnoreRules{}
+	require.NoError(t, rules.LoadDir(dir, ""))
+
+	excluded, _, source := rules.Match("/fixtures", true)
+	assert.True(t, excluded)
+	assert.Equal(t, filepath.Join(dir, "observer.yml"), source)
+
+	excluded, _, source = rules.Match("/examples", true)
+	assert.True(t, excluded)
+	assert.Equal(t, filepath.Join(dir, IgnoreFileName)+":1", source)
+
+	// a broken config file is reported
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "observer.yml"), []byte("exclude: [\n"), 0644))
+	assert.ErrorContains(t, (&IgnoreRules{}).LoadDir(dir, ""), "failed to unmarshal config file")
+}
@@ -0,0 +1,77 @@
+package scanner
+
+import (
+	cdx "github.com/CycloneDX/cyclonedx-go"
+)
+
+// ProvenancePrefix prefixes the scanner that identified a component in evidence.identity methods,
+// e.g. observer:scanner=scalibr/javascript/packagelockjson
+const ProvenancePrefix = "observer:scanner="
+
+// confidence of the identity of components depending on how they were found
+const (
+	confidenceManifest        float32 = 0.9
+	confidenceInstrumentation float32 = 0.9
+	confidenceBinary          float32 = 0.7
+	confidenceSBOM            float32 = 0.6
+	confidenceExternal        float32 = 0.6
+)
+
+// Provenance describes how a scanner identified a component
+type Provenance struct {
+	// Source is the scanner (and plugin or package type), e.g. scalibr/javascript/packagelockjson or trivy/npm
+	Source     string
+	Technique  cdx.EvidenceIdentityTechnique
+	Confidence float32
+}
+
+// addProvenance records the scanner that identified the component in evidence.identity. Merged
+// duplicates keep the identity evidence from all scanners.
+func addProvenance(component *cdx.Component, provenance Provenance) {
+	field := cdx.EvidenceIdentityFieldTypePURL
+	if component.PackageURL == "" {
+		field = cdx.EvidenceIdentityFieldTypeName
+	}
+
+	confidence := provenance.Confidence
+	identity := cdx.EvidenceIdentity{
+		Field:      field,
+		Confidence: &confidence,
+		Methods: &[]cdx.EvidenceIdentityMethod{
+			{
+				Technique:  provenance.Technique,
+				Confidence: &confidence,
+				Value:      ProvenancePrefix + provenance.Source,
+			},
+		},
+	}
+
+	if component.Evidence == nil {
+		component.Evidence = &cdx.Evidence{}
+	}
+
+	if component.Evidence.Identity == nil {
+		component.Evidence.Identity = &[]cdx.EvidenceIdentity{}
+	}
+
+	*component.Evidence.Identity = append(*component.Evidence.Identity, identity)
+}
+
+// addProvenanceToBOM records the same provenance for all components (including nested components) in the BOM
+func addProvenanceToBOM(bom *cdx.BOM, provenance Provenance) {
+	if bom == nil || bom.Components == nil {
+		return
+	}
+
+	addProvenanceToComponents(*bom.Components, provenance)
+}
+
+func addProvenanceToComponents(components []cdx.Component, provenance Provenance) {
+	for i := range components {
+		addProvenance(&components[i], provenance)
+
+		if components[i].Components != nil {
+			addProvenanceToComponents(*components[i].Components, provenance)
+		}
+	}
+}
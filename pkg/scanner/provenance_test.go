@@ -0,0 +1,53 @@
+package scanner
+
+import (
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddProvenance(t *testing.T) {
+	component := cdx.Component{Name: "left-pad", Version: "1.3.0", PackageURL: "pkg:npm/left-pad@1.3.0"}
+
+	addProvenance(&component, Provenance{Source: "scalibr/javascript/packagelockjson", Technique: cdx.EvidenceIdentityTechniqueManifestAnalysis, Confidence: 0.9})
+
+	require.NotNil(t, component.Evidence)
+	require.NotNil(t, component.Evidence.Identity)
+	require.Len(t, *component.Evidence.Identity, 1)
+
+	identity := (*component.Evidence.Identity)[0]
+	assert.Equal(t, cdx.EvidenceIdentityFieldTypePURL, identity.Field)
+	assert.Equal(t, float32(0.9), *identity.Confidence)
+	require.Len(t, *identity.Methods, 1)
+	assert.Equal(t, cdx.EvidenceIdentityTechniqueManifestAnalysis, (*identity.Methods)[0].Technique)
+	assert.Equal(t, "observer:scanner=scalibr/javascript/packagelockjson", (*identity.Methods)[0].Value)
+
+	// components without a purl are identified by name
+	file := cdx.Component{Name: "app.exe"}
+	addProvenance(&file, Provenance{Source: "windows-binary", Technique: cdx.EvidenceIdentityTechniqueBinaryAnalysis, Confidence: 0.7})
+	assert.Equal(t, cdx.EvidenceIdentityFieldTypeName, (*file.Evidence.Identity)[0].Field)
+}
+
+func TestScalibrProvenance(t *testing.T) {
+	tests := []struct {
+		plugins   []string
+		source    string
+		technique cdx.EvidenceIdentityTechnique
+	}{
+		{[]string{"javascript/packagelockjson"}, "scalibr/javascript/packagelockjson", cdx.EvidenceIdentityTechniqueManifestAnalysis},
+		{[]string{"go/binary"}, "scalibr/go/binary", cdx.EvidenceIdentityTechniqueBinaryAnalysis},
+		{[]string{"java/archive"}, "scalibr/java/archive", cdx.EvidenceIdentityTechniqueBinaryAnalysis},
+		{[]string{"sbom/cdx"}, "scalibr/sbom/cdx", cdx.EvidenceIdentityTechniqueOther},
+		{nil, "scalibr", cdx.EvidenceIdentityTechniqueOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			provenance := scalibrProvenance(tt.plugins)
+			assert.Equal(t, tt.source, provenance.Source)
+			assert.Equal(t, tt.technique, provenance.Technique)
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package scanner
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sbom-observer/observer-cli/pkg/log"
+	"github.com/sbom-observer/observer-cli/pkg/types"
+	"golang.org/x/exp/maps"
+)
+
+// ScannerRegistration describes a scanner that can be selected for scan targets
+type ScannerRegistration struct {
+	// Name is used to select the scanner in observer.yml and on the command line
+	Name string
+	// New creates a new instance of the scanner
+	New func() RepoScanner
+	// Ecosystems are the ecosystems the scanner runs for unless the scanner chain is overridden
+	Ecosystems []Ecosystem
+	// Fallback is the name of the scanner to use instead if this scanner is not available (i.e. not installed)
+	Fallback string
+	// SkipsDevDependencies is set for scanners that can't mark development dependencies. The Fallback scanner
+	// runs instead in the default scanner chain when development dependencies are included.
+	SkipsDevDependencies bool
+}
+
+var scannerRegistry = map[string]ScannerRegistration{}
+
+// RegisterScanner adds a scanner to the registry. Scanners register themselves from init().
+func RegisterScanner(registration ScannerRegistration) {
+	if _, found := scannerRegistry[registration.Name]; found {
+		panic(fmt.Sprintf("scanner '%s' is already registered", registration.Name))
+	}
+
+	scannerRegistry[registration.Name] = registration
+}
+
+// ScannerNames returns the names of all registered scanners
+func ScannerNames() []string {
+	names := maps.Keys(scannerRegistry)
+	slices.Sort(names)
+	return names
+}
+
+// DefaultScannerChain returns the names of the scanners that run for an ecosystem by default
+func DefaultScannerChain(ecosystem Ecosystem) []string {
+	var names []string
+	for _, name := range ScannerNames() {
+		if slices.Contains(scannerRegistry[name].Ecosystems, ecosystem) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ValidateScannerOverrides checks that all ecosystems and scanners in a scanner chain override exist
+func ValidateScannerOverrides(overrides map[string][]string) error {
+	for ecosystem, names := range overrides {
+		if !slices.Contains(KnownEcosystems, Ecosystem(ecosystem)) {
+			return fmt.Errorf("unknown ecosystem '%s'", ecosystem)
+		}
+
+		for _, name := range names {
+			if _, found := scannerRegistry[name]; !found {
+				return fmt.Errorf("unknown scanner '%s' for ecosystem '%s' (available: %s)", name, ecosystem, strings.Join(ScannerNames(), ", "))
+			}
+		}
+	}
+
+	return nil
+}
+
+// NewScanner creates a scanner by name. If the scanner isn't available and has a fallback,
+// the fallback scanner is used instead.
+func NewScanner(name string) (RepoScanner, error) {
+	registration, found := scannerRegistry[name]
+	if !found {
+		return nil, fmt.Errorf("unknown scanner '%s' (available: %s)", name, strings.Join(ScannerNames(), ", "))
+	}
+
+	if registration.Fallback == "" {
+		return registration.New(), nil
+	}
+
+	fallback, err := NewScanner(registration.Fallback)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWithFallbackScanner(registration.New(), fallback), nil
+}
+
+// scannerChain returns the names of the scanners to run for an ecosystem, taking overrides
+// from observer.yml and the command line into account
+func scannerChain(ecosystem Ecosystem, overrides map[string][]string, includeDevDependencies bool) []string {
+	if names, found := overrides[string(ecosystem)]; found {
+		log.Debug("using scanner chain override", "ecosystem", ecosystem, "scanners", names)
+		if includeDevDependencies {
+			for _, name := range names {
+				if scannerRegistry[name].SkipsDevDependencies {
+					log.Warn("scanner doesn't mark development dependencies, they are not included", "ecosystem", ecosystem, "scanner", name)
+				}
+			}
+		}
+		return names
+	}
+
+	names := DefaultScannerChain(ecosystem)
+	if includeDevDependencies {
+		for i, name := range names {
+			if registration := scannerRegistry[name]; registration.SkipsDevDependencies && registration.Fallback != "" {
+				log.Debug("using fallback scanner to include development dependencies", "ecosystem", ecosystem, "scanner", name, "fallback", registration.Fallback)
+				names[i] = registration.Fallback
+			}
+		}
+	}
+
+	return names
+}
+
+// observerConfig returns the target's observer.yml. It has not been loaded into target.Config yet when the
+// scanners are selected (the config scanner runs with the other scanners).
+func observerConfig(target ScanTarget) types.ScanConfig {
+	var config types.ScanConfig
+	for filename, ecosystem := range target.Files {
+		if ecosystem != EcosystemObserver {
+			continue
+		}
+
+		if err := types.LoadConfig(&config, filepath.Join(target.Path, filename)); err != nil {
+			// reported by the config scanner
+			continue
+		}
+	}
+	return config
+}
+
+// scannerOverrides returns the scanner chain overrides for a target. Overrides from the command line
+// (already set in target.Config) take precedence over overrides in the target's observer.yml.
+func scannerOverrides(target ScanTarget, config types.ScanConfig) map[string][]string {
+	overrides := map[string][]string{}
+
+	for ecosystem, names := range config.Scanners {
+		overrides[ecosystem] = names
+	}
+
+	for ecosystem, names := range target.Config.Scanners {
+		overrides[ecosystem] = names
+	}
+
+	return overrides
+}
@@ -0,0 +1,140 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultScannerChain(t *testing.T) {
+	assert.Equal(t, []string{"moduleName", "trivy"}, DefaultScannerChain(EcosystemNpm))
+	assert.Equal(t, []string{"scalibr"}, DefaultScannerChain(EcosystemGo))
+	assert.Equal(t, []string{"scalibr-sbom"}, DefaultScannerChain(EcosystemSBOM))
+	assert.Equal(t, []string{"config"}, DefaultScannerChain(EcosystemObserver))
+	assert.Equal(t, []string{"crystalModule", "scalibr-crystal"}, DefaultScannerChain(EcosystemCrystal))
+
+	// every known ecosystem has at least one scanner
+	for _, ecosystem := range KnownEcosystems {
+		assert.NotEmpty(t, DefaultScannerChain(ecosystem), ecosystem)
+	}
+}
+
+func TestNewScanner_Ids(t *testing.T) {
+	// ids are used for timeouts, cache keys and the report, so every scanner needs its own id
+	for _, name := range ScannerNames() {
+		s, err := NewScanner(name)
+		require.NoError(t, err)
+		if _, ok := s.(*withFallbackScanner); ok {
+			continue
+		}
+		assert.Equal(t, name, s.Id())
+	}
+}
+
+func TestValidateScannerOverrides(t *testing.T) {
+	assert.NoError(t, ValidateScannerOverrides(nil))
+	assert.NoError(t, ValidateScannerOverrides(map[string][]string{"npm": {"scalibr"}, "go": {}}))
+	assert.ErrorContains(t, ValidateScannerOverrides(map[string][]string{"nmp": {"scalibr"}}), "unknown ecosystem 'nmp'")
+	assert.ErrorContains(t, ValidateScannerOverrides(map[string][]string{"npm": {"syft"}}), "unknown scanner 'syft'")
+}
+
+func TestScannersForTarget(t *testing.T) {
+	scannerIds := func(scanners []RepoScanner) []string {
+		var ids []string
+		for _, s := range scanners {
+			ids = append(ids, s.Id())
+		}
+		return ids
+	}
+
+	t.Run("default", func(t *testing.T) {
+		scanners, err := ScannersForTarget(ScanTarget{Files: map[string]Ecosystem{"go.mod": EcosystemGo}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"scalibr"}, scannerIds(scanners))
+	})
+
+	t.Run("fallback without trivy", func(t *testing.T) {
+		t.Setenv("TRIVY_DISABLED", "1")
+
+		// trivy falls back to scalibr, which already runs for go.mod
+		scanners, err := ScannersForTarget(ScanTarget{Files: map[string]Ecosystem{"go.mod": EcosystemGo, "package.json": EcosystemNpm}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"moduleName", "scalibr"}, scannerIds(scanners))
+	})
+
+	t.Run("command line override", func(t *testing.T) {
+		target := ScanTarget{Files: map[string]Ecosystem{"package.json": EcosystemNpm}}
+		target.Config.Scanners = map[string][]string{"npm": {"scalibr"}}
+
+		scanners, err := ScannersForTarget(target)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"scalibr"}, scannerIds(scanners))
+	})
+
+	t.Run("observer.yml override", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "observer.yml"), []byte("scanners:\n  npm: [moduleName, scalibr]\n"), 0644))
+
+		target := ScanTarget{
+			Path:  dir,
+			Files: map[string]Ecosystem{"package.json": EcosystemNpm, "observer.yml": EcosystemObserver},
+		}
+
+		scanners, err := ScannersForTarget(target)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"config", "moduleName", "scalibr"}, scannerIds(scanners))
+
+		// the command line takes precedence
+		target.Config.Scanners = map[string][]string{"npm": {"scalibr"}}
+		scanners, err = ScannersForTarget(target)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"config", "scalibr"}, scannerIds(scanners))
+	})
+
+	t.Run("include dev dependencies", func(t *testing.T) {
+		// trivy doesn't mark dev dependencies, so scalibr runs instead
+		includeDev := true
+		target := ScanTarget{Files: map[string]Ecosystem{"package.json": EcosystemNpm}}
+		target.Config.IncludeDevDependencies = &includeDev
+
+		scanners, err := ScannersForTarget(target)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"moduleName", "scalibr"}, scannerIds(scanners))
+
+		// includeDev in observer.yml
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "observer.yml"), []byte("includeDev: true\n"), 0644))
+		target = ScanTarget{
+			Path:  dir,
+			Files: map[string]Ecosystem{"package.json": EcosystemNpm, "observer.yml": EcosystemObserver},
+		}
+
+		scanners, err = ScannersForTarget(target)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"config", "moduleName", "scalibr"}, scannerIds(scanners))
+
+		// observer.yml takes precedence over --include-dev
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "observer.yml"), []byte("includeDev: false\n"), 0644))
+		target.Config.IncludeDevDependencies = &includeDev
+
+		scanners, err = ScannersForTarget(target)
+		require.NoError(t, err)
+		require.Len(t, scanners, 3)
+		assert.IsType(t, &withFallbackScanner{}, scanners[2], "trivy (or its fallback) runs")
+
+		// an explicit override is kept
+		assert.Equal(t, []string{"trivy"}, scannerChain(EcosystemNpm, map[string][]string{"npm": {"trivy"}}, true))
+		assert.Equal(t, []string{"moduleName", "trivy"}, scannerChain(EcosystemNpm, nil, false))
+	})
+
+	t.Run("unknown scanner", func(t *testing.T) {
+		target := ScanTarget{Files: map[string]Ecosystem{"go.mod": EcosystemGo}}
+		target.Config.Scanners = map[string][]string{"go": {"syft"}}
+
+		_, err := ScannersForTarget(target)
+		assert.ErrorContains(t, err, "unknown scanner 'syft'")
+	})
+}
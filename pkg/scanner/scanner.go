@@ -1,11 +1,15 @@
 package scanner
 
 import (
+	"context"
+	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"runtime/debug"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/google/osv-scalibr/extractor/filesystem"
 	"github.com/google/osv-scalibr/extractor/filesystem/simplefileapi"
@@ -39,10 +43,36 @@ const EcosystemUnknownBinary Ecosystem = "binary"
 const EcosystemSBOM Ecosystem = "sbom"
 const EcosystemWindowsBinary Ecosystem = "windows-binary"
 
+// EcosystemExternal is used for files that only trigger external scanners (configured in observer.yml)
+const EcosystemExternal Ecosystem = "external"
+
 // TODO: expand
 
 const EcosystemUnknown Ecosystem = "unknown"
 
+// KnownEcosystems contains all ecosystems that can be identified in a scan target
+var KnownEcosystems = []Ecosystem{
+	EcosystemGo,
+	EcosystemNpm,
+	EcosystemNuget,
+	EcosystemPython,
+	EcosystemJava,
+	EcosystemJavaBinary,
+	EcosystemRuby,
+	EcosystemPhp,
+	EcosystemRust,
+	EcosystemConan,
+	EcosystemElixir,
+	EcosystemDart,
+	EcosystemSwift,
+	EcosystemCrystal,
+	EcosystemBuildObserver,
+	EcosystemObserver,
+	EcosystemUnknownBinary,
+	EcosystemSBOM,
+	EcosystemWindowsBinary,
+}
+
 var DefaultSkipDirs = types.SliceSet[string]{
 	"node_modules",
 	".git",
@@ -54,16 +84,69 @@ type ScanTarget struct {
 	Config  types.ScanConfig
 	Results []*cdx.BOM
 	Merged  *cdx.BOM
+	// ExternalScanners contains the external scanners triggered by files in the target
+	ExternalScanners []types.ExternalScannerConfig
+	// UnresolvedFiles contains files from build observations that could not be attributed to a package
+	UnresolvedFiles []string
+	// Runs records the scanners that ran for the target (used for the scan report)
+	Runs []ScannerRun
+	// OfflineSkipped contains the scanner plugins that were skipped because they require network access (--offline)
+	OfflineSkipped []string
+}
+
+// ScannerRun records a single scanner run for a target
+type ScannerRun struct {
+	Id       string
+	Duration time.Duration
+	// Cached is true if the results were reused from the scan cache
+	Cached   bool
+	TimedOut bool
+	Err      error
 }
 
 type RepoScanner interface {
 	Id() string
 	Priority() int
-	Scan(*ScanTarget) error
+	// Scan adds the results for the target to target.Results. Scanners should stop (and clean up) when ctx is done.
+	Scan(context.Context, *ScanTarget) error
 	IsAvailable() bool
 }
 
-func FindScanTargets(initialTarget string, maxDepth uint) (map[string]*ScanTarget, error) {
+// VersionedScanner is implemented by scanners whose results only depend on the files in the target (or the
+// files returned by InputFiles, see InputFilesScanner), the scanner version and the scan config, so that the
+// results can be cached. An empty version disables caching.
+type VersionedScanner interface {
+	Version() string
+}
+
+// InputFilesScanner is implemented by versioned scanners that read more than the files in the target, e.g.
+// every file in the target directory. InputFiles returns all files read by the scan, relative to the target path.
+type InputFilesScanner interface {
+	InputFiles(target *ScanTarget) ([]string, error)
+}
+
+// moduleVersion returns the version of a Go module dependency compiled into the binary
+func moduleVersion(path string) string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+
+	for _, dep := range info.Deps {
+		if dep.Path == path {
+			if dep.Replace != nil {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+
+	return ""
+}
+
+// FindScanTargets finds the scan targets in initialTarget. Files matching the file patterns of the (trusted, see
+// ReadExternalScanners) externalScanners are added to the targets together with the external scanners they trigger.
+func FindScanTargets(initialTarget string, maxDepth uint, externalScanners []types.ExternalScannerConfig) (map[string]*ScanTarget, error) {
 	// resolve "." to the current working directory so we get sane naming
 	if initialTarget == "." {
 		cwd, err := os.Getwd()
@@ -77,6 +160,7 @@ func FindScanTargets(initialTarget string, maxDepth uint) (map[string]*ScanTarge
 	initialDepth := strings.Count(initialTarget, string(os.PathSeparator))
 
 	targets := map[string]*ScanTarget{}
+	ignoreRules := &IgnoreRules{}
 
 	err := filepath.WalkDir(initialTarget, func(currentPath string, file fs.DirEntry, err error) error {
 		depth := strings.Count(currentPath, string(os.PathSeparator)) - initialDepth
@@ -98,18 +182,39 @@ func FindScanTargets(initialTarget string, maxDepth uint) (map[string]*ScanTarge
 			return filepath.SkipDir
 		}
 
+		relativePath := strings.TrimPrefix(currentPath, initialTarget)
+
+		// skip paths excluded by .observerignore or observer.yml
+		if excluded, rule, source := ignoreRules.Match(relativePath, file.IsDir()); excluded {
+			log.Debug("excluded by ignore rule", "path", currentPath, "rule", rule, "source", source)
+			if file.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
 		// don't care about directories (yet)
 		if file.IsDir() {
+			// rules in a directory apply to everything below it
+			if err := ignoreRules.LoadDir(currentPath, relativePath); err != nil {
+				log.Warn("failed to read ignore rules", "path", currentPath, "err", err)
+			}
+
 			return nil
 		}
 
-		relativePath := strings.TrimPrefix(currentPath, initialTarget)
 		directoryPath := filepath.Dir(currentPath)
 
 		// check if it's a "file-of-interest"
 		ecosystem := IdentifyEcosystem(currentPath, relativePath, file.Name())
 		log.Debug("identified ecosystem", "absolutePath", currentPath, "relativePath", relativePath, "fileName", file.Name(), "ecosystem", ecosystem)
 
+		// check if the file triggers any external scanners
+		external := matchExternalScanners(externalScanners, file.Name())
+		if ecosystem == EcosystemUnknown && len(external) > 0 {
+			ecosystem = EcosystemExternal
+		}
+
 		if ecosystem != EcosystemUnknown {
 			target, found := targets[directoryPath]
 			if !found {
@@ -120,6 +225,12 @@ func FindScanTargets(initialTarget string, maxDepth uint) (map[string]*ScanTarge
 				targets[directoryPath] = target
 			}
 			target.Files[file.Name()] = ecosystem
+
+			for _, config := range external {
+				if !slices.ContainsFunc(target.ExternalScanners, func(c types.ExternalScannerConfig) bool { return c.Name == config.Name }) {
+					target.ExternalScanners = append(target.ExternalScanners, config)
+				}
+			}
 		}
 
 		return nil
@@ -248,70 +359,75 @@ func isConda(fileName string) bool {
 	return true
 }
 
-func ScannersForTarget(target ScanTarget) []RepoScanner {
+// ScannersForTarget returns the scanners to run for a target (ordered by priority)
+func ScannersForTarget(target ScanTarget) ([]RepoScanner, error) {
+	config := observerConfig(target)
+	overrides := scannerOverrides(target, config)
+	includeDevDependencies := target.Config.IncludeDev()
+	if config.IncludeDevDependencies != nil {
+		// observer.yml takes precedence over the command line
+		includeDevDependencies = *config.IncludeDevDependencies
+	}
+
+	// iterate in a stable order so that fallback warnings and errors are deterministic
+	filenames := maps.Keys(target.Files)
+	slices.Sort(filenames)
+
+	var ecosystems types.SliceSet[Ecosystem]
+	for _, filename := range filenames {
+		ecosystems = ecosystems.Add(target.Files[filename])
+	}
+
 	scanners := map[string]RepoScanner{}
-	for _, ecosystem := range target.Files {
-		sfe := scannersForEcosystem(ecosystem)
-		if len(sfe) == 0 {
+	created := map[string]bool{}
+	for _, ecosystem := range ecosystems {
+		// handled by the external scanners below
+		if ecosystem == EcosystemExternal {
+			continue
+		}
+
+		names := scannerChain(ecosystem, overrides, includeDevDependencies)
+		if len(names) == 0 {
 			log.Debug("skipping unsupported ecosystem", "ecosystem", ecosystem)
 			continue
 		}
-		for _, scanner := range sfe {
-			if _, ok := scanners[scanner.Id()]; !ok {
-				scanners[scanner.Id()] = scanner
+
+		for _, name := range names {
+			if created[name] {
+				continue
+			}
+			created[name] = true
+
+			scanner, err := NewScanner(name)
+			if err != nil {
+				return nil, fmt.Errorf("ecosystem '%s': %w", ecosystem, err)
 			}
+
+			// deduplicate by id, i.e. trivy falls back to scalibr when it isn't installed
+			if _, ok := scanners[scanner.Id()]; ok {
+				continue
+			}
+			scanners[scanner.Id()] = scanner
 		}
 	}
 
+	for _, config := range target.ExternalScanners {
+		scanner := NewExternalScanner(config)
+		scanners[scanner.Id()] = scanner
+	}
+
 	effectiveScanners := maps.Values(scanners)
 
 	slices.SortFunc(effectiveScanners, func(a, b RepoScanner) int {
-		return a.Priority() - b.Priority()
+		if a.Priority() != b.Priority() {
+			return a.Priority() - b.Priority()
+		}
+		return strings.Compare(a.Id(), b.Id())
 	})
 
 	for _, scanner := range effectiveScanners {
 		log.Debug("scannersForTarget", "target", target.Files, "effectiveScanners", scanner.Id())
 	}
 
-	return effectiveScanners
-}
-
-func scannersForEcosystem(ecosystem Ecosystem) []RepoScanner {
-	switch ecosystem {
-	case EcosystemObserver:
-		return []RepoScanner{&ConfigRepoScanner{}}
-	case EcosystemBuildObserver:
-		return []RepoScanner{&BuildObservationsScanner{}}
-	case EcosystemNpm:
-		return []RepoScanner{
-			&ModuleNameScanner{},
-			NewWithFallbackScanner(&TrivyScanner{}, NewDefaultScalibrRepoScanner()),
-		}
-	case EcosystemGo:
-		return []RepoScanner{NewDefaultScalibrRepoScanner()}
-	case EcosystemCrystal:
-		return []RepoScanner{
-			&CrystalShardScanner{},
-			&scalibrRepoScanner{},
-		}
-	case EcosystemJavaBinary:
-		return []RepoScanner{NewDefaultScalibrRepoScanner()}
-	case EcosystemUnknownBinary:
-		return []RepoScanner{
-			&BinaryNameScanner{},
-			NewDefaultScalibrRepoScanner(),
-		}
-	case EcosystemSBOM:
-		return []RepoScanner{NewSBOMScalibrRepoScanner()}
-	case EcosystemPython:
-		return []RepoScanner{NewDefaultScalibrRepoScanner()}
-	case EcosystemWindowsBinary:
-		return []RepoScanner{
-			&WindowsBinaryScanner{},
-		}
-	default:
-		return []RepoScanner{
-			NewWithFallbackScanner(&TrivyScanner{}, NewDefaultScalibrRepoScanner()),
-		}
-	}
+	return effectiveScanners, nil
 }
@@ -1,11 +1,20 @@
 package scanner
 
 import (
+	"context"
 	"path/filepath"
 )
 
 type BinaryNameScanner struct{}
 
+func init() {
+	RegisterScanner(ScannerRegistration{
+		Name:       "binaryName",
+		New:        func() RepoScanner { return &BinaryNameScanner{} },
+		Ecosystems: []Ecosystem{EcosystemUnknownBinary},
+	})
+}
+
 func (s *BinaryNameScanner) Id() string {
 	return "binaryName"
 }
@@ -18,7 +27,7 @@ func (s *BinaryNameScanner) Priority() int {
 	return 200
 }
 
-func (s *BinaryNameScanner) Scan(target *ScanTarget) error {
+func (s *BinaryNameScanner) Scan(ctx context.Context, target *ScanTarget) error {
 	for filename, ecosystem := range target.Files {
 		if ecosystem == EcosystemUnknownBinary {
 			if target.Config.Component.Name == "" {
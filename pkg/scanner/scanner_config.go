@@ -1,6 +1,8 @@
 package scanner
 
 import (
+	"context"
+	"fmt"
 	"path/filepath"
 
 	"github.com/sbom-observer/observer-cli/pkg/log"
@@ -9,6 +11,14 @@ import (
 
 type ConfigRepoScanner struct{}
 
+func init() {
+	RegisterScanner(ScannerRegistration{
+		Name:       "config",
+		New:        func() RepoScanner { return &ConfigRepoScanner{} },
+		Ecosystems: []Ecosystem{EcosystemObserver},
+	})
+}
+
 func (s *ConfigRepoScanner) Id() string {
 	return "config"
 }
@@ -21,7 +31,7 @@ func (s *ConfigRepoScanner) Priority() int {
 	return 100
 }
 
-func (s *ConfigRepoScanner) Scan(target *ScanTarget) error {
+func (s *ConfigRepoScanner) Scan(ctx context.Context, target *ScanTarget) error {
 	for filename := range target.Files {
 		if filename == "observer.yml" || filename == "observer.yaml" {
 			log.Info("parsing observer config file", "filename", filename)
@@ -31,6 +41,16 @@ func (s *ConfigRepoScanner) Scan(target *ScanTarget) error {
 				return err
 			}
 
+			if err := ValidateScannerOverrides(target.Config.Scanners); err != nil {
+				return fmt.Errorf("invalid scanners in %s: %w", filename, err)
+			}
+
+			for _, external := range target.Config.ExternalScanners {
+				if err := external.Validate(); err != nil {
+					return fmt.Errorf("invalid external scanner in %s: %w", filename, err)
+				}
+			}
+
 			log.Debug("loaded config", "config", target.Config)
 		}
 	}
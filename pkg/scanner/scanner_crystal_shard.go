@@ -1,6 +1,7 @@
 package scanner
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 
@@ -11,6 +12,14 @@ import (
 // CrystalShardScanner scans for Crystal shard.yml files and extracts the target's name and version.
 type CrystalShardScanner struct{}
 
+func init() {
+	RegisterScanner(ScannerRegistration{
+		Name:       "crystalModule",
+		New:        func() RepoScanner { return &CrystalShardScanner{} },
+		Ecosystems: []Ecosystem{EcosystemCrystal},
+	})
+}
+
 func (s *CrystalShardScanner) Id() string {
 	return "crystalModule"
 }
@@ -23,7 +32,7 @@ func (s *CrystalShardScanner) Priority() int {
 	return 200
 }
 
-func (s *CrystalShardScanner) Scan(target *ScanTarget) error {
+func (s *CrystalShardScanner) Scan(ctx context.Context, target *ScanTarget) error {
 	for filename, ecosystem := range target.Files {
 		if filename == "shard.yml" {
 			log.Debug("found shard.yml config file", "filename", filename, "ecosystem", ecosystem)
@@ -0,0 +1,192 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/sbom-observer/observer-cli/pkg/cdxutil"
+	"github.com/sbom-observer/observer-cli/pkg/execx"
+	"github.com/sbom-observer/observer-cli/pkg/ids"
+	"github.com/sbom-observer/observer-cli/pkg/log"
+	"github.com/sbom-observer/observer-cli/pkg/types"
+)
+
+// ExternalScanner runs a command (configured in observer.yml) that produces a CycloneDX SBOM
+type ExternalScanner struct {
+	config types.ExternalScannerConfig
+}
+
+func NewExternalScanner(config types.ExternalScannerConfig) *ExternalScanner {
+	return &ExternalScanner{config: config}
+}
+
+func (s *ExternalScanner) Id() string {
+	return "external:" + s.config.Name
+}
+
+func (s *ExternalScanner) IsAvailable() bool {
+	if len(s.config.Command) == 0 {
+		return false
+	}
+
+	_, err := exec.LookPath(s.config.Command[0])
+	return err == nil
+}
+
+func (s *ExternalScanner) Priority() int {
+	return 1000
+}
+
+// externalCommandData is the data available in command and output templates
+type externalCommandData struct {
+	Path   string
+	Name   string
+	Output string
+}
+
+func (s *ExternalScanner) Scan(ctx context.Context, target *ScanTarget) error {
+	if err := s.config.Validate(); err != nil {
+		return err
+	}
+
+	data := externalCommandData{
+		Path:   target.Path,
+		Name:   filepath.Base(target.Path),
+		Output: filepath.Join(os.TempDir(), fmt.Sprintf("sbom-%s-%s.cdx.json", ids.NextUUID(), time.Now().Format("20060102-150405"))),
+	}
+	defer os.Remove(data.Output)
+
+	// create args
+	var args []string
+	for _, arg := range s.config.Command {
+		rendered, err := renderExternalTemplate(arg, data)
+		if err != nil {
+			return fmt.Errorf("invalid command for external scanner '%s': %w", s.config.Name, err)
+		}
+		args = append(args, rendered)
+	}
+
+	// relative paths in the command and the output are relative to the target
+	output, err := execx.ExecDirContext(ctx, target.Path, args[0], args[1:]...)
+	if err != nil {
+		var extCmdErr *execx.ExternalCommandError
+		if errors.As(err, &extCmdErr) {
+			log.Error("failed to create SBOM for repository with external scanner", "scanner", s.config.Name, "path", target.Path, "exitcode", extCmdErr.ExitCode)
+			_, _ = fmt.Fprintf(os.Stderr, "-- %s output --\n", s.config.Name)
+			_, _ = fmt.Fprint(os.Stderr, extCmdErr.StdErr)
+			_, _ = fmt.Fprint(os.Stderr, "\n------------------\n")
+			return err
+		}
+
+		if errors.Is(err, execx.ErrNotFound) {
+			return fmt.Errorf("command '%s' for external scanner '%s' not found: %w", args[0], s.config.Name, err)
+		}
+
+		log.Error("failed to create sbom for repository using external scanner", "scanner", s.config.Name, "err", err)
+		return err
+	}
+
+	var bom *cdx.BOM
+	if s.config.Output == "" {
+		bom, err = cdxutil.DecodeCycloneDX(strings.NewReader(output))
+	} else {
+		var outputFile string
+		outputFile, err = renderExternalTemplate(s.config.Output, data)
+		if err != nil {
+			return fmt.Errorf("invalid output for external scanner '%s': %w", s.config.Name, err)
+		}
+
+		if !filepath.IsAbs(outputFile) {
+			outputFile = filepath.Join(target.Path, outputFile)
+		}
+
+		log.Debug("reading SBOM from external scanner", "scanner", s.config.Name, "filename", outputFile)
+		bom, err = cdxutil.ParseCycloneDX(outputFile)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse CycloneDX SBOM from external scanner '%s': %w", s.config.Name, err)
+	}
+
+	addProvenanceToBOM(bom, Provenance{
+		Source:     "external/" + s.config.Name,
+		Technique:  cdx.EvidenceIdentityTechniqueOther,
+		Confidence: confidenceExternal,
+	})
+
+	target.Results = append(target.Results, bom)
+	return nil
+}
+
+func renderExternalTemplate(text string, data externalCommandData) (string, error) {
+	t, err := template.New("external").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// ReadExternalScanners reads the external scanners from a config file (observer.yml format). External scanners
+// run arbitrary commands, so they must only be read from trusted config files: the config file in the root of
+// the scan or a config file given on the command line, never from config files found in the scanned tree.
+func ReadExternalScanners(filename string) ([]types.ExternalScannerConfig, error) {
+	var config types.ScanConfig
+	if err := types.LoadConfig(&config, filename); err != nil {
+		return nil, err
+	}
+
+	for _, external := range config.ExternalScanners {
+		if err := external.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid external scanner in %s: %w", filename, err)
+		}
+	}
+
+	return config.ExternalScanners, nil
+}
+
+// ReadRootExternalScanners reads the external scanners from observer.yml/observer.yaml in dir (but not from
+// subdirectories, see ReadExternalScanners)
+func ReadRootExternalScanners(dir string) ([]types.ExternalScannerConfig, error) {
+	var configs []types.ExternalScannerConfig
+	for _, name := range []string{"observer.yml", "observer.yaml"} {
+		filename := filepath.Join(dir, name)
+		if _, err := os.Stat(filename); err != nil {
+			continue
+		}
+
+		external, err := ReadExternalScanners(filename)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, external...)
+	}
+	return configs, nil
+}
+
+// matchExternalScanners returns the external scanners triggered by a file
+func matchExternalScanners(configs []types.ExternalScannerConfig, fileName string) []types.ExternalScannerConfig {
+	var matches []types.ExternalScannerConfig
+	for _, config := range configs {
+		for _, pattern := range config.Files {
+			if matched, _ := filepath.Match(pattern, fileName); matched {
+				matches = append(matches, config)
+				break
+			}
+		}
+	}
+	return matches
+}
@@ -0,0 +1,163 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sbom-observer/observer-cli/pkg/execx"
+	"github.com/sbom-observer/observer-cli/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const externalTestBOM = `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.6",
+  "version": 1,
+  "components": [
+    {"bom-ref": "pkg:generic/acme-lib@1.0.0", "type": "library", "name": "acme-lib", "version": "1.0.0", "purl": "pkg:generic/acme-lib@1.0.0"}
+  ]
+}`
+
+func TestExternalScanner_Scan(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "build.acme"), []byte(externalTestBOM), 0644))
+
+	tests := []struct {
+		name   string
+		config types.ExternalScannerConfig
+	}{
+		{
+			name: "stdout",
+			config: types.ExternalScannerConfig{
+				Name:    "acme",
+				Command: []string{"cat", "{{.Path}}/build.acme"},
+				Files:   []string{"*.acme"},
+			},
+		},
+		{
+			name: "output file",
+			config: types.ExternalScannerConfig{
+				Name:    "acme",
+				Command: []string{"cp", "{{.Path}}/build.acme", "{{.Output}}"},
+				Files:   []string{"*.acme"},
+				Output:  "{{.Output}}",
+			},
+		},
+		{
+			name: "relative output file",
+			config: types.ExternalScannerConfig{
+				Name:    "acme",
+				Command: []string{"true"},
+				Files:   []string{"*.acme"},
+				Output:  "build.acme",
+			},
+		},
+		{
+			// the command runs in the target directory, so it writes the file that is read
+			name: "relative output file written by the command",
+			config: types.ExternalScannerConfig{
+				Name:    "acme",
+				Command: []string{"cp", "build.acme", "generated.cdx.json"},
+				Files:   []string{"*.acme"},
+				Output:  "generated.cdx.json",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := &ScanTarget{Path: dir, Files: map[string]Ecosystem{"build.acme": EcosystemExternal}}
+
+			err := NewExternalScanner(tt.config).Scan(context.Background(), target)
+			require.NoError(t, err)
+			require.Len(t, target.Results, 1)
+			require.NotNil(t, target.Results[0].Components)
+			assert.Equal(t, "acme-lib", (*target.Results[0].Components)[0].Name)
+		})
+	}
+}
+
+func TestExternalScanner_Failure(t *testing.T) {
+	target := &ScanTarget{Path: t.TempDir()}
+
+	err := NewExternalScanner(types.ExternalScannerConfig{
+		Name:    "acme",
+		Command: []string{"false"},
+		Files:   []string{"*.acme"},
+	}).Scan(context.Background(), target)
+
+	var extCmdErr *execx.ExternalCommandError
+	require.ErrorAs(t, err, &extCmdErr)
+	assert.Equal(t, 1, extCmdErr.ExitCode)
+	assert.Empty(t, target.Results)
+}
+
+func TestReadRootExternalScanners(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "observer.yml"), []byte("externalScanners:\n  - name: acme\n    command: [acme-sbom, '{{.Path}}']\n    files: ['*.acme']\n"), 0644))
+
+	external, err := ReadRootExternalScanners(root)
+	require.NoError(t, err)
+	require.Len(t, external, 1)
+	assert.Equal(t, "acme", external[0].Name)
+
+	external, err = ReadRootExternalScanners(t.TempDir())
+	require.NoError(t, err)
+	assert.Empty(t, external)
+
+	// invalid scanners are reported
+	require.NoError(t, os.WriteFile(filepath.Join(root, "observer.yml"), []byte("externalScanners:\n  - name: acme\n    files: ['*.acme']\n"), 0644))
+	_, err = ReadRootExternalScanners(root)
+	assert.ErrorContains(t, err, "external scanner 'acme' is missing a command")
+}
+
+func TestFindScanTargets_ExternalScanners(t *testing.T) {
+	root := t.TempDir()
+
+	writeFile := func(name string, content string) {
+		filename := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+		require.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+	}
+
+	writeFile("services/api/build.acme", "")
+	writeFile("services/web/package.json", "{}")
+	writeFile("services/web/build.acme", "")
+	// external scanners in the scanned tree (i.e. vendored code) are never run
+	writeFile("vendor/lib/observer.yml", "externalScanners:\n  - name: untrusted\n    command: [untrusted-sbom]\n    files: ['*.json']\n")
+	writeFile("vendor/lib/package.json", "{}")
+
+	external := []types.ExternalScannerConfig{{Name: "acme", Command: []string{"acme-sbom", "{{.Path}}"}, Files: []string{"*.acme"}}}
+
+	targets, err := FindScanTargets(root, 5, external)
+	require.NoError(t, err)
+
+	api := targets[filepath.Join(root, "services/api")]
+	require.NotNil(t, api)
+	assert.Equal(t, map[string]Ecosystem{"build.acme": EcosystemExternal}, api.Files)
+	require.Len(t, api.ExternalScanners, 1)
+	assert.Equal(t, "acme", api.ExternalScanners[0].Name)
+
+	web := targets[filepath.Join(root, "services/web")]
+	require.NotNil(t, web)
+	assert.Equal(t, EcosystemNpm, web.Files["package.json"])
+	assert.Len(t, web.ExternalScanners, 1)
+
+	vendored := targets[filepath.Join(root, "vendor/lib")]
+	require.NotNil(t, vendored)
+	assert.Empty(t, vendored.ExternalScanners)
+
+	scanners, err := ScannersForTarget(*api)
+	require.NoError(t, err)
+	require.Len(t, scanners, 1)
+	assert.Equal(t, "external:acme", scanners[0].Id())
+
+	// without external scanners the files only used by them are not scan targets
+	targets, err = FindScanTargets(root, 5, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, targets, filepath.Join(root, "services/api"))
+	assert.Empty(t, targets[filepath.Join(root, "services/web")].ExternalScanners)
+}
@@ -1,6 +1,7 @@
 package scanner
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
@@ -10,6 +11,14 @@ import (
 
 type ModuleNameScanner struct{}
 
+func init() {
+	RegisterScanner(ScannerRegistration{
+		Name:       "moduleName",
+		New:        func() RepoScanner { return &ModuleNameScanner{} },
+		Ecosystems: []Ecosystem{EcosystemNpm},
+	})
+}
+
 func (s *ModuleNameScanner) Id() string {
 	return "moduleName"
 }
@@ -22,7 +31,7 @@ func (s *ModuleNameScanner) Priority() int {
 	return 200
 }
 
-func (s *ModuleNameScanner) Scan(target *ScanTarget) error {
+func (s *ModuleNameScanner) Scan(ctx context.Context, target *ScanTarget) error {
 	for filename, ecosystem := range target.Files {
 		if filename == "package.json" {
 			log.Debug("found package.json config file", "filename", filename, "ecosystem", ecosystem)
@@ -1,6 +1,7 @@
 package scanner
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -15,6 +16,14 @@ import (
 
 type BuildObservationsScanner struct{}
 
+func init() {
+	RegisterScanner(ScannerRegistration{
+		Name:       "build-observations",
+		New:        func() RepoScanner { return &BuildObservationsScanner{} },
+		Ecosystems: []Ecosystem{EcosystemBuildObserver},
+	})
+}
+
 func (s *BuildObservationsScanner) Id() string {
 	return "build-observations"
 }
@@ -27,7 +36,7 @@ func (s *BuildObservationsScanner) Priority() int {
 	return 1000
 }
 
-func (s *BuildObservationsScanner) Scan(target *ScanTarget) error {
+func (s *BuildObservationsScanner) Scan(ctx context.Context, target *ScanTarget) error {
 	log := log.Logger.WithPrefix("build-observations")
 
 	for filename, ecosystem := range target.Files {
@@ -47,11 +56,13 @@ func (s *BuildObservationsScanner) Scan(target *ScanTarget) error {
 				return fmt.Errorf("failed to decode build observations file: %w", err)
 			}
 
-			bom, err := ScanObservations(target.Config, observations)
+			bom, unresolvedFiles, err := scanObservations(target.Config, observations)
 			if err != nil {
 				return fmt.Errorf("failed to scan build observations: %w", err)
 			}
 
+			target.UnresolvedFiles = append(target.UnresolvedFiles, unresolvedFiles...)
+
 			target.Results = append(target.Results, bom)
 		}
 	}
@@ -59,6 +70,13 @@ func (s *BuildObservationsScanner) Scan(target *ScanTarget) error {
 }
 
 func ScanObservations(config types.ScanConfig, observations builds.BuildObservations) (*cdx.BOM, error) {
+	bom, _, err := scanObservations(config, observations)
+	return bom, err
+}
+
+// scanObservations creates a BOM from build observations and returns it together with the files
+// that could not be attributed to any package
+func scanObservations(config types.ScanConfig, observations builds.BuildObservations) (*cdx.BOM, []string, error) {
 	log := log.Logger.WithPrefix("build-observations")
 
 	log.Debugf("filtering dependencies from %d/%d observed build operations", len(observations.FilesOpened), len(observations.FilesExecuted))
@@ -66,7 +84,7 @@ func ScanObservations(config types.ScanConfig, observations builds.BuildObservat
 
 	dependencies, err := builds.ResolveDependencies(observations)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse build observations file: %w", err)
+		return nil, nil, fmt.Errorf("failed to parse build observations file: %w", err)
 	}
 
 	log.Debugf("resolved %d unique code dependencies", len(dependencies.Code))
@@ -75,9 +93,15 @@ func ScanObservations(config types.ScanConfig, observations builds.BuildObservat
 
 	bom, err := builds.GenerateCycloneDX(dependencies, config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate CycloneDX BOM: %w", err)
+		return nil, nil, fmt.Errorf("failed to generate CycloneDX BOM: %w", err)
 	}
 
+	addProvenanceToBOM(bom, Provenance{
+		Source:     "build-observations",
+		Technique:  cdx.EvidenceIdentityTechniqueInstrumentation,
+		Confidence: confidenceInstrumentation,
+	})
+
 	// report unresolved files
 	if len(dependencies.UnresolvedFiles) > 0 {
 		// log.Warn("scanning build observations found unattributed files", "observations", filepath.Join(target.Path, filename))
@@ -86,5 +110,5 @@ func ScanObservations(config types.ScanConfig, observations builds.BuildObservat
 		}
 	}
 
-	return bom, nil
+	return bom, dependencies.UnresolvedFiles, nil
 }
@@ -5,13 +5,17 @@ import (
 	"fmt"
 	"path/filepath"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/CycloneDX/cyclonedx-go"
 	scalibr "github.com/google/osv-scalibr"
 	"github.com/google/osv-scalibr/binary/platform"
 	"github.com/google/osv-scalibr/extractor"
+	"github.com/google/osv-scalibr/extractor/filesystem/language/dotnet/dotnetpe"
+	"github.com/google/osv-scalibr/extractor/filesystem/language/java/archive"
 	"github.com/google/osv-scalibr/extractor/filesystem/language/javascript/packagejson"
+	"github.com/google/osv-scalibr/extractor/filesystem/language/rust/cargoauditable"
 	"github.com/google/osv-scalibr/extractor/filesystem/osv"
 	cdxemeta "github.com/google/osv-scalibr/extractor/filesystem/sbom/cdx/metadata"
 	spdxmeta "github.com/google/osv-scalibr/extractor/filesystem/sbom/spdx/metadata"
@@ -21,16 +25,43 @@ import (
 	"github.com/google/osv-scalibr/purl"
 	"github.com/google/uuid"
 	"github.com/sbom-observer/observer-cli/pkg/log"
+	"github.com/sbom-observer/observer-cli/pkg/scanner/depgraph"
 	localextractors "github.com/sbom-observer/observer-cli/pkg/scanner/scalibr"
+	"github.com/sbom-observer/observer-cli/pkg/types"
 )
 
 type scalibrRepoScanner struct {
+	id                     string
 	includeDevDependencies bool
 	pluginNames            []string
 }
 
+func init() {
+	RegisterScanner(ScannerRegistration{
+		Name: "scalibr",
+		New:  func() RepoScanner { return NewDefaultScalibrRepoScanner() },
+		Ecosystems: []Ecosystem{
+			EcosystemGo,
+			EcosystemPython,
+			EcosystemJavaBinary,
+			EcosystemUnknownBinary,
+		},
+	})
+	RegisterScanner(ScannerRegistration{
+		Name:       "scalibr-sbom",
+		New:        func() RepoScanner { return NewSBOMScalibrRepoScanner() },
+		Ecosystems: []Ecosystem{EcosystemSBOM},
+	})
+	RegisterScanner(ScannerRegistration{
+		Name:       "scalibr-crystal",
+		New:        func() RepoScanner { return NewCrystalScalibrRepoScanner() },
+		Ecosystems: []Ecosystem{EcosystemCrystal},
+	})
+}
+
 func NewDefaultScalibrRepoScanner() *scalibrRepoScanner {
 	return &scalibrRepoScanner{
+		id:                     "scalibr",
 		includeDevDependencies: false,
 		pluginNames:            []string{"default", "dotnet", "ruby", "rust", "cpp", "php", "erlang", "elixir"},
 	}
@@ -38,13 +69,22 @@ func NewDefaultScalibrRepoScanner() *scalibrRepoScanner {
 
 func NewSBOMScalibrRepoScanner() *scalibrRepoScanner {
 	return &scalibrRepoScanner{
+		id:                     "scalibr-sbom",
 		includeDevDependencies: false,
 		pluginNames:            []string{"sbom"},
 	}
 }
 
+// NewCrystalScalibrRepoScanner returns a scanner that only runs the Crystal shard.lock extractor
+func NewCrystalScalibrRepoScanner() *scalibrRepoScanner {
+	return &scalibrRepoScanner{
+		id:                     "scalibr-crystal",
+		includeDevDependencies: false,
+	}
+}
+
 func (s *scalibrRepoScanner) Id() string {
-	return "scalibr"
+	return s.id
 }
 
 func (s *scalibrRepoScanner) IsAvailable() bool {
@@ -55,7 +95,16 @@ func (s *scalibrRepoScanner) Priority() int {
 	return 1000
 }
 
-func (s *scalibrRepoScanner) Scan(target *ScanTarget) error {
+func (s *scalibrRepoScanner) Version() string {
+	version := moduleVersion("github.com/google/osv-scalibr")
+	if version == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("%s %s", version, strings.Join(s.pluginNames, ","))
+}
+
+func (s *scalibrRepoScanner) Scan(ctx context.Context, target *ScanTarget) error {
 	capabilities := &plugin.Capabilities{
 		OS:            platform.OS(),
 		Network:       plugin.NetworkOnline,
@@ -63,12 +112,25 @@ func (s *scalibrRepoScanner) Scan(target *ScanTarget) error {
 		RunningSystem: true,
 	}
 
+	if types.Offline {
+		capabilities.Network = plugin.NetworkOffline
+	}
+
 	usePlugins, err := plugins.FromNames(s.pluginNames)
 	if err != nil {
 		return fmt.Errorf("failed to get default usePlugins: %w", err)
 	}
 
 	usePlugins = append(usePlugins, localextractors.CrystalShardLockExtractor{})
+
+	if types.Offline {
+		skipped := networkPlugins(usePlugins, capabilities)
+		for _, name := range skipped {
+			log.Debug("skipping plugin that requires network access", "plugin", name, "path", target.Path)
+		}
+		target.OfflineSkipped = append(target.OfflineSkipped, skipped...)
+	}
+
 	usePlugins = plugin.FilterByCapabilities(usePlugins, capabilities)
 
 	var filesToScan []string
@@ -93,11 +155,14 @@ func (s *scalibrRepoScanner) Scan(target *ScanTarget) error {
 		len(config.Plugins),
 	)
 
-	// TODO: context timeout
-	scalibrResult := scalibr.New().Scan(context.Background(), config)
+	scalibrResult := scalibr.New().Scan(ctx, config)
 
 	log.Debugf("Scan status: %v", scalibrResult.Status)
 
+	if ctx.Err() != nil {
+		return fmt.Errorf("scan was stopped: %w", ctx.Err())
+	}
+
 	if scalibrResult.Status.Status != plugin.ScanStatusSucceeded {
 		return fmt.Errorf("scan wasn't successful: %s", scalibrResult.Status.FailureReason)
 	}
@@ -128,28 +193,42 @@ func (s *scalibrRepoScanner) Scan(target *ScanTarget) error {
 		//},
 	}
 
+	// read the dependency graph from lockfiles (scalibr only reports a flat list of packages)
+	var files []string
+	for f := range target.Files {
+		files = append(files, f)
+	}
+
+	graph, err := depgraph.Load(target.Path, files)
+	if err != nil {
+		log.Warn("failed to read dependency graph, dependencies will be reported as direct dependencies", "path", target.Path, "err", err)
+	}
+
 	// add inventory to bom
-	scalibrToCycloneDX(bom, scalibrResult, s.includeDevDependencies)
+	includeDevDependencies := s.includeDevDependencies || target.Config.IncludeDev()
+	scalibrToCycloneDX(bom, scalibrResult, includeDevDependencies, graph)
 
 	target.Results = append(target.Results, bom)
 
 	return nil
 }
 
-func scalibrToCycloneDX(bom *cyclonedx.BOM, r *scalibr.ScanResult, includeDevDependencies bool) {
+// scalibrToCycloneDX adds the packages found by scalibr to the BOM. If a dependency graph is available
+// the packages are added as direct or transitive dependencies of the root component according to
+// the graph, otherwise all packages are added as direct dependencies of the root component.
+// Development dependencies are skipped unless includeDevDependencies is set, in which case they are
+// added with the scope "excluded" and a property naming the dependency group.
+func scalibrToCycloneDX(bom *cyclonedx.BOM, r *scalibr.ScanResult, includeDevDependencies bool, graph *depgraph.Graph) {
 	comps := make([]cyclonedx.Component, 0, len(r.Inventory.Packages))
+	keys := make([]depgraph.Key, 0, len(r.Inventory.Packages))
 
 NextInventory:
 	for _, i := range r.Inventory.Packages {
 		// skip dev
-		if !includeDevDependencies {
-			if i.Metadata != nil {
-				if m, ok := i.Metadata.(osv.DepGroupMetadata); ok {
-					if slices.Contains(m.DepGroupVals, "dev") {
-						continue NextInventory
-					}
-				}
-			}
+		groups := depGroups(i)
+		isDevDependency := slices.ContainsFunc(groups, isDevGroup)
+		if isDevDependency && !includeDevDependencies {
+			continue NextInventory
 		}
 
 		// skip javascript/packagejson top level component
@@ -189,22 +268,171 @@ NextInventory:
 				Occurrences: &occ,
 			}
 		}
+
+		addProvenance(&pkg, scalibrProvenance(i.Plugins))
+
+		var properties []cyclonedx.Property
+
+		// dev dependencies are not part of the shipped software
+		if isDevDependency {
+			pkg.Scope = cyclonedx.ScopeExcluded
+			properties = append(properties, cyclonedx.Property{
+				Name:  "observer:dependency:group",
+				Value: strings.Join(groups, ","),
+			})
+		}
+
+		key := depgraph.NewKey(i.PURLType, i.Name, i.Version)
+		if graph.Contains(key) {
+			relationship := "transitive"
+			if graph.IsDirect(key) {
+				relationship = "direct"
+			}
+			properties = append(properties, cyclonedx.Property{
+				Name:  "observer:dependency:relationship",
+				Value: relationship,
+			})
+		}
+
+		if len(properties) > 0 {
+			pkg.Properties = &properties
+		}
+
 		comps = append(comps, pkg)
+		keys = append(keys, key)
 	}
 	bom.Components = &comps
 
-	var allRefs []string
+	if graph.IsEmpty() {
+		var allRefs []string
+		for _, comp := range comps {
+			allRefs = append(allRefs, comp.BOMRef)
+		}
+
+		// add a top level dependency section
+		bom.Dependencies = &[]cyclonedx.Dependency{
+			{
+				Ref:          bom.Metadata.Component.BOMRef,
+				Dependencies: &allRefs,
+			},
+		}
+		return
+	}
+
+	bom.Dependencies = dependencyGraphToCycloneDX(bom.Metadata.Component.BOMRef, comps, keys, graph)
+}
+
+// dependencyGraphToCycloneDX creates the nested dependencies section from the graph. Only packages found
+// by the scanner are included and packages not reachable from the root are added as direct dependencies
+// of the root so that no component is left unreferenced.
+func dependencyGraphToCycloneDX(rootRef string, comps []cyclonedx.Component, keys []depgraph.Key, graph *depgraph.Graph) *[]cyclonedx.Dependency {
+	refs := map[depgraph.Key]string{}
+	for i, comp := range comps {
+		if _, found := refs[keys[i]]; !found {
+			refs[keys[i]] = comp.BOMRef
+		}
+	}
+
+	resolve := func(keys []depgraph.Key) []string {
+		var resolved []string
+		for _, key := range keys {
+			if ref, found := refs[key]; found && !slices.Contains(resolved, ref) {
+				resolved = append(resolved, ref)
+			}
+		}
+		return resolved
+	}
+
+	rootDependencies := resolve(graph.Direct)
+
+	edges := map[string][]string{}
+	var dependencies []cyclonedx.Dependency
+	for i, comp := range comps {
+		if _, found := edges[comp.BOMRef]; found {
+			continue
+		}
+
+		dependsOn := resolve(graph.Packages[keys[i]])
+		edges[comp.BOMRef] = dependsOn
+
+		dependency := cyclonedx.Dependency{
+			Ref: comp.BOMRef,
+		}
+		if len(dependsOn) > 0 {
+			dependency.Dependencies = &dependsOn
+		}
+		dependencies = append(dependencies, dependency)
+	}
+
+	// find components not reachable from the root
+	reachable := map[string]bool{}
+	visit := func(refs ...string) {
+		queue := slices.Clone(refs)
+		for len(queue) > 0 {
+			ref := queue[0]
+			queue = queue[1:]
+			if reachable[ref] {
+				continue
+			}
+			reachable[ref] = true
+			queue = append(queue, edges[ref]...)
+		}
+	}
+
+	visit(rootDependencies...)
+
 	for _, comp := range comps {
-		allRefs = append(allRefs, comp.BOMRef)
+		if !reachable[comp.BOMRef] {
+			rootDependencies = append(rootDependencies, comp.BOMRef)
+			visit(comp.BOMRef)
+		}
 	}
 
-	// add a top level dependency section
-	bom.Dependencies = &[]cyclonedx.Dependency{
+	result := append([]cyclonedx.Dependency{
 		{
-			Ref:          bom.Metadata.Component.BOMRef,
-			Dependencies: &allRefs,
+			Ref:          rootRef,
+			Dependencies: &rootDependencies,
 		},
+	}, dependencies...)
+
+	return &result
+}
+
+// networkPlugins returns the names of the plugins that are only available with network access
+func networkPlugins(usePlugins []plugin.Plugin, capabilities *plugin.Capabilities) []string {
+	online := *capabilities
+	online.Network = plugin.NetworkOnline
+
+	available := map[string]bool{}
+	for _, p := range plugin.FilterByCapabilities(usePlugins, capabilities) {
+		available[p.Name()] = true
+	}
+
+	var names []string
+	for _, p := range plugin.FilterByCapabilities(usePlugins, &online) {
+		if !available[p.Name()] {
+			names = append(names, p.Name())
+		}
+	}
+
+	return names
+}
+
+// depGroups returns the dependency groups (i.e. "dev", "optional") reported by the extractor for the package
+func depGroups(i *extractor.Package) []string {
+	if m, ok := i.Metadata.(osv.DepGroups); ok {
+		return m.DepGroups()
+	}
+	return nil
+}
+
+// isDevGroup returns true for dependency groups that are only used during development and testing
+func isDevGroup(group string) bool {
+	switch strings.ToLower(group) {
+	case "dev", "test":
+		return true
 	}
+	return false
 }
 
 func toPURL(i *extractor.Package) *purl.PackageURL {
@@ -221,3 +449,28 @@ func extractCPEs(i *extractor.Package) []string {
 	}
 	return nil
 }
+
+// scalibrProvenance describes how scalibr found a package from the plugin (extractor) that reported it
+func scalibrProvenance(pluginNames []string) Provenance {
+	if len(pluginNames) == 0 {
+		return Provenance{Source: "scalibr", Technique: cyclonedx.EvidenceIdentityTechniqueOther, Confidence: confidenceSBOM}
+	}
+
+	name := pluginNames[0]
+	provenance := Provenance{
+		Source:     "scalibr/" + name,
+		Technique:  cyclonedx.EvidenceIdentityTechniqueManifestAnalysis,
+		Confidence: confidenceManifest,
+	}
+
+	switch {
+	case strings.HasPrefix(name, "sbom/"):
+		provenance.Technique = cyclonedx.EvidenceIdentityTechniqueOther
+		provenance.Confidence = confidenceSBOM
+	case strings.Contains(name, "binary") || slices.Contains([]string{archive.Name, cargoauditable.Name, dotnetpe.Name}, name):
+		provenance.Technique = cyclonedx.EvidenceIdentityTechniqueBinaryAnalysis
+		provenance.Confidence = confidenceBinary
+	}
+
+	return provenance
+}
@@ -0,0 +1,140 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/google/osv-scalibr/plugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testPlugin struct {
+	name    string
+	network plugin.Network
+}
+
+func (p testPlugin) Name() string { return p.name }
+func (p testPlugin) Version() int { return 0 }
+func (p testPlugin) Requirements() *plugin.Capabilities {
+	return &plugin.Capabilities{Network: p.network}
+}
+
+func TestNetworkPlugins(t *testing.T) {
+	usePlugins := []plugin.Plugin{
+		testPlugin{name: "any", network: plugin.NetworkAny},
+		testPlugin{name: "online", network: plugin.NetworkOnline},
+		testPlugin{name: "offline", network: plugin.NetworkOffline},
+	}
+
+	// only the plugins that need network access are skipped when offline
+	assert.Equal(t, []string{"online"}, networkPlugins(usePlugins, &plugin.Capabilities{Network: plugin.NetworkOffline}))
+	assert.Empty(t, networkPlugins(usePlugins, &plugin.Capabilities{Network: plugin.NetworkOnline}))
+}
+
+// scanScalibr scans the npm lockfile in path with the default scalibr scanner
+func scanScalibr(t *testing.T, path string, includeDevDependencies bool) *cdx.BOM {
+	target := &ScanTarget{
+		Path:  path,
+		Files: map[string]Ecosystem{"package.json": EcosystemNpm, "package-lock.json": EcosystemNpm},
+	}
+	target.Config.IncludeDevDependencies = &includeDevDependencies
+
+	require.NoError(t, NewDefaultScalibrRepoScanner().Scan(context.Background(), target))
+	require.Len(t, target.Results, 1)
+	return target.Results[0]
+}
+
+// dependsOn returns the refs that ref depends on
+func dependsOn(bom *cdx.BOM, ref string) []string {
+	for _, dependency := range *bom.Dependencies {
+		if dependency.Ref == ref && dependency.Dependencies != nil {
+			return *dependency.Dependencies
+		}
+	}
+	return nil
+}
+
+func componentProperty(component cdx.Component, name string) string {
+	if component.Properties != nil {
+		for _, property := range *component.Properties {
+			if property.Name == name {
+				return property.Value
+			}
+		}
+	}
+	return ""
+}
+
+func TestScalibrRepoScanner_DependencyGraph(t *testing.T) {
+	bom := scanScalibr(t, "../../testdata/npm-app", false)
+
+	components := map[string]cdx.Component{}
+	for _, component := range *bom.Components {
+		components[component.Name] = component
+	}
+
+	bcrypt, leftpad, nodePreGyp := components["bcrypt"], components["leftpad"], components["@mapbox/node-pre-gyp"]
+	require.NotEmpty(t, bcrypt.BOMRef)
+	require.NotEmpty(t, leftpad.BOMRef)
+	require.NotEmpty(t, nodePreGyp.BOMRef)
+
+	// only the direct dependencies hang off the root, the other packages are nested
+	assert.ElementsMatch(t, []string{bcrypt.BOMRef, leftpad.BOMRef}, dependsOn(bom, bom.Metadata.Component.BOMRef))
+	assert.Contains(t, dependsOn(bom, bcrypt.BOMRef), nodePreGyp.BOMRef)
+
+	assert.Equal(t, "direct", componentProperty(bcrypt, "observer:dependency:relationship"))
+	assert.Equal(t, "direct", componentProperty(leftpad, "observer:dependency:relationship"))
+	assert.Equal(t, "transitive", componentProperty(nodePreGyp, "observer:dependency:relationship"))
+}
+
+func TestScalibrRepoScanner_DevDependencies(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{
+  "name": "app",
+  "version": "1.0.0",
+  "dependencies": {"leftpad": "0.0.1"},
+  "devDependencies": {"mocha": "10.0.0"}
+}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "package-lock.json"), []byte(`{
+  "name": "app",
+  "version": "1.0.0",
+  "lockfileVersion": 3,
+  "requires": true,
+  "packages": {
+    "": {
+      "name": "app",
+      "version": "1.0.0",
+      "dependencies": {"leftpad": "0.0.1"},
+      "devDependencies": {"mocha": "10.0.0"}
+    },
+    "node_modules/leftpad": {"version": "0.0.1"},
+    "node_modules/mocha": {"version": "10.0.0", "dev": true}
+  }
+}`), 0644))
+
+	componentNames := func(bom *cdx.BOM) map[string]cdx.Component {
+		components := map[string]cdx.Component{}
+		for _, component := range *bom.Components {
+			components[component.Name] = component
+		}
+		return components
+	}
+
+	// dropped by default
+	components := componentNames(scanScalibr(t, dir, false))
+	assert.Contains(t, components, "leftpad")
+	assert.NotContains(t, components, "mocha")
+
+	// included with the scope "excluded" and the dependency group
+	components = componentNames(scanScalibr(t, dir, true))
+	require.Contains(t, components, "mocha")
+	assert.Equal(t, cdx.ScopeExcluded, components["mocha"].Scope)
+	assert.Equal(t, "dev", componentProperty(components["mocha"], "observer:dependency:group"))
+
+	assert.Empty(t, components["leftpad"].Scope)
+	assert.Empty(t, componentProperty(components["leftpad"], "observer:dependency:group"))
+}
@@ -4,21 +4,50 @@
 package scanner
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"slices"
+	"strings"
+	"sync"
 	"time"
 
+	cdx "github.com/CycloneDX/cyclonedx-go"
 	"github.com/sbom-observer/observer-cli/pkg/cdxutil"
 	"github.com/sbom-observer/observer-cli/pkg/execx"
 	"github.com/sbom-observer/observer-cli/pkg/ids"
 	"github.com/sbom-observer/observer-cli/pkg/log"
+	"github.com/sbom-observer/observer-cli/pkg/types"
+	"golang.org/x/exp/maps"
 )
 
 type TrivyScanner struct{}
 
+func init() {
+	RegisterScanner(ScannerRegistration{
+		Name: "trivy",
+		New:  func() RepoScanner { return &TrivyScanner{} },
+		Ecosystems: []Ecosystem{
+			EcosystemNpm,
+			EcosystemNuget,
+			EcosystemJava,
+			EcosystemRuby,
+			EcosystemPhp,
+			EcosystemRust,
+			EcosystemConan,
+			EcosystemElixir,
+			EcosystemDart,
+			EcosystemSwift,
+		},
+		Fallback: "scalibr",
+		// Trivy doesn't mark dev dependencies in the CycloneDX output
+		SkipsDevDependencies: true,
+	})
+}
+
 func (s *TrivyScanner) Id() string {
 	return "trivy"
 }
@@ -45,30 +74,64 @@ func (s *TrivyScanner) Priority() int {
 	return 1000
 }
 
-func (s *TrivyScanner) Scan(target *ScanTarget) error {
-	// create args
-	output := filepath.Join(os.TempDir(), fmt.Sprintf("sbom-%s-%s.cdx.json", ids.NextUUID(), time.Now().Format("20060102-150405")))
-	args := []string{"fs", "--skip-db-update", "--skip-java-db-update", "--format", "cyclonedx", "--output", output}
-
-	// skip subdirectories
-	subs, err := subDirectories(target.Path)
+var trivyVersion = sync.OnceValue(func() string {
+	output, err := execx.Trivy("--version")
 	if err != nil {
-		return fmt.Errorf("failed to read subdirectories in %s: %w", target.Path, err)
+		log.Debug("failed to get Trivy version", "err", err)
+		return ""
 	}
 
-	// Do not skip node_modules directory, so filter it out from subs
-	subs = slices.DeleteFunc(subs, func(sub string) bool {
-		return filepath.Base(sub) == "node_modules"
+	// Version: 0.58.1
+	// Vulnerability DB: ...
+	version, _, _ := strings.Cut(output, "\n")
+	return strings.TrimSpace(version)
+})
+
+func (s *TrivyScanner) Version() string {
+	return trivyVersion()
+}
+
+// InputFiles returns the files read by Trivy: all files in the target directory and in node_modules (the other
+// subdirectories are skipped, see Scan)
+func (s *TrivyScanner) InputFiles(target *ScanTarget) ([]string, error) {
+	var inputs []string
+	err := filepath.WalkDir(target.Path, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relative, err := filepath.Rel(target.Path, path)
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			if relative != "." && strings.Split(filepath.ToSlash(relative), "/")[0] != "node_modules" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if entry.Type().IsRegular() {
+			inputs = append(inputs, relative)
+		}
+		return nil
 	})
 
-	for _, sub := range subs {
-		args = append(args, "--skip-dirs", sub)
-	}
+	return inputs, err
+}
 
-	// add path to scan
-	args = append(args, target.Path)
+func (s *TrivyScanner) Scan(ctx context.Context, target *ScanTarget) error {
+	// create args
+	output := filepath.Join(os.TempDir(), fmt.Sprintf("sbom-%s-%s.cdx.json", ids.NextUUID(), time.Now().Format("20060102-150405")))
+	defer os.Remove(output)
 
-	_, err = execx.Trivy(args...)
+	args, err := trivyArgs(target, output)
+	if err != nil {
+		return err
+	}
+
+	_, err = execx.TrivyContext(ctx, args...)
 	if err != nil {
 		var extCmdErr *execx.ExternalCommandError
 		if errors.As(err, &extCmdErr) {
@@ -93,17 +156,91 @@ func (s *TrivyScanner) Scan(target *ScanTarget) error {
 		bom.Metadata.Component.Properties = nil
 	}
 
-	// remove all properties from components
+	// remove all properties from components (after recording the package type that identified them)
 	if bom.Components != nil {
 		for i := range *bom.Components {
-			(*bom.Components)[i].Properties = nil
+			component := &(*bom.Components)[i]
+			addProvenance(component, trivyProvenance(component))
+			component.Properties = nil
 		}
 	}
 
+	if types.Offline {
+		target.OfflineSkipped = append(target.OfflineSkipped, trivyOfflineSkipped(target)...)
+	}
+
 	target.Results = append(target.Results, bom)
 	return nil
 }
 
+// trivyOfflineSkipped returns an entry (i.e. "java/trivy") for each ecosystem in the target that Trivy scans
+// with --offline-scan, where dependencies that can't be identified locally are not looked up
+func trivyOfflineSkipped(target *ScanTarget) []string {
+	var skipped []string
+	for _, ecosystem := range scannerRegistry["trivy"].Ecosystems {
+		if slices.Contains(maps.Values(target.Files), ecosystem) {
+			skipped = append(skipped, fmt.Sprintf("%s/trivy", ecosystem))
+		}
+	}
+	slices.Sort(skipped)
+	return skipped
+}
+
+// trivyProvenance describes how Trivy found a component from the package type reported by Trivy
+func trivyProvenance(component *cdx.Component) Provenance {
+	provenance := Provenance{
+		Source:     "trivy",
+		Technique:  cdx.EvidenceIdentityTechniqueManifestAnalysis,
+		Confidence: confidenceManifest,
+	}
+
+	if component.Properties != nil {
+		for _, property := range *component.Properties {
+			if property.Name == "aquasecurity:trivy:PkgType" && property.Value != "" {
+				provenance.Source = "trivy/" + property.Value
+			}
+		}
+	}
+
+	switch provenance.Source {
+	case "trivy/jar", "trivy/gobinary", "trivy/rustbinary", "trivy/dotnet-core":
+		provenance.Technique = cdx.EvidenceIdentityTechniqueBinaryAnalysis
+		provenance.Confidence = confidenceBinary
+	}
+
+	return provenance
+}
+
+// trivyArgs returns the arguments to scan the target with Trivy, writing the SBOM to output
+func trivyArgs(target *ScanTarget, output string) ([]string, error) {
+	args := []string{"fs", "--skip-db-update", "--skip-java-db-update", "--format", "cyclonedx", "--output", output}
+
+	// don't issue API requests to identify dependencies (i.e. jar files)
+	if types.Offline {
+		args = append(args, "--offline-scan")
+	}
+
+	// skip subdirectories
+	subs, err := subDirectories(target.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read subdirectories in %s: %w", target.Path, err)
+	}
+
+	// Do not skip node_modules directory, so filter it out from subs
+	subs = slices.DeleteFunc(subs, func(sub string) bool {
+		return filepath.Base(sub) == "node_modules"
+	})
+
+	for _, sub := range subs {
+		args = append(args, "--skip-dirs", sub)
+	}
+
+	// add path to scan
+	args = append(args, target.Path)
+
+	return args, nil
+}
+
 func subDirectories(path string) ([]string, error) {
 	var dirs []string
 	entries, err := os.ReadDir(path)
@@ -0,0 +1,71 @@
+//go:build !trivylink
+// +build !trivylink
+
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sbom-observer/observer-cli/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrivyScanner_InputFiles(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"package.json", "package-lock.json", "observer.yml", "node_modules/left-pad/package.json", "node_modules/.bin/left-pad", "packages/web/package.json"} {
+		filename := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+		require.NoError(t, os.WriteFile(filename, []byte("{}"), 0644))
+	}
+
+	target := &ScanTarget{Path: root, Files: map[string]Ecosystem{"package-lock.json": EcosystemNpm}}
+
+	inputs, err := (&TrivyScanner{}).InputFiles(target)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"package.json",
+		"package-lock.json",
+		"observer.yml",
+		filepath.Join("node_modules", "left-pad", "package.json"),
+		filepath.Join("node_modules", ".bin", "left-pad"),
+	}, inputs)
+}
+
+func TestTrivyArgs(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "node_modules"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "packages"), 0755))
+
+	target := &ScanTarget{Path: root}
+
+	args, err := trivyArgs(target, "out.cdx.json")
+	require.NoError(t, err)
+	assert.NotContains(t, args, "--offline-scan")
+	assert.Equal(t, root, args[len(args)-1])
+
+	// subdirectories other than node_modules are skipped
+	assert.Contains(t, args, filepath.Join(root, "packages"))
+	assert.NotContains(t, args, filepath.Join(root, "node_modules"))
+
+	types.Offline = true
+	t.Cleanup(func() { types.Offline = false })
+
+	args, err = trivyArgs(target, "out.cdx.json")
+	require.NoError(t, err)
+	assert.Contains(t, args, "--offline-scan")
+}
+
+func TestTrivyOfflineSkipped(t *testing.T) {
+	target := &ScanTarget{Files: map[string]Ecosystem{
+		"package-lock.json": EcosystemNpm,
+		"pom.xml":           EcosystemJava,
+		"go.mod":            EcosystemGo,
+		"observer.yml":      EcosystemObserver,
+	}}
+
+	// only the ecosystems scanned by trivy
+	assert.Equal(t, []string{"java/trivy", "npm/trivy"}, trivyOfflineSkipped(target))
+}
@@ -1,6 +1,8 @@
 package scanner
 
 import (
+	"context"
+	"fmt"
 	"path/filepath"
 	"time"
 
@@ -8,11 +10,20 @@ import (
 	"github.com/sbom-observer/observer-cli/pkg/files"
 	"github.com/sbom-observer/observer-cli/pkg/ids"
 	"github.com/sbom-observer/observer-cli/pkg/log"
+	"github.com/sbom-observer/observer-cli/pkg/mergex"
 	"github.com/sbom-observer/observer-cli/pkg/scanner/windows"
 )
 
 type WindowsBinaryScanner struct{}
 
+func init() {
+	RegisterScanner(ScannerRegistration{
+		Name:       "windows-binary",
+		New:        func() RepoScanner { return &WindowsBinaryScanner{} },
+		Ecosystems: []Ecosystem{EcosystemWindowsBinary},
+	})
+}
+
 func (s *WindowsBinaryScanner) Id() string {
 	return "windows-binary"
 }
@@ -25,7 +36,7 @@ func (s *WindowsBinaryScanner) Priority() int {
 	return 200
 }
 
-func (s *WindowsBinaryScanner) Scan(target *ScanTarget) error {
+func (s *WindowsBinaryScanner) Scan(ctx context.Context, target *ScanTarget) error {
 	bom := cdx.NewBOM()
 
 	bom.Metadata = &cdx.Metadata{
@@ -37,8 +48,8 @@ func (s *WindowsBinaryScanner) Scan(target *ScanTarget) error {
 			Properties: &[]cdx.Property{
 				{
 					// hint to merge that the contents of the BOM should be inlined
-					Name:  "observer:internal:merge",
-					Value: "inline",
+					Name:  mergex.MergeDirectiveProperty,
+					Value: string(mergex.DirectiveInline),
 				},
 			},
 		},
@@ -47,6 +58,10 @@ func (s *WindowsBinaryScanner) Scan(target *ScanTarget) error {
 	var components []cdx.Component
 
 	for filename, ecosystem := range target.Files {
+		if ctx.Err() != nil {
+			return fmt.Errorf("scan was stopped: %w", ctx.Err())
+		}
+
 		log.Debug("scanning windows binary", "filename", filename, "ecosystem", ecosystem)
 
 		absolutePath := filepath.Join(target.Path, filename)
@@ -58,6 +73,11 @@ func (s *WindowsBinaryScanner) Scan(target *ScanTarget) error {
 		}
 
 		component := toComponent(inventory)
+		addProvenance(&component, Provenance{
+			Source:     "windows-binary",
+			Technique:  cdx.EvidenceIdentityTechniqueBinaryAnalysis,
+			Confidence: confidenceBinary,
+		})
 
 		components = append(components, component)
 	}
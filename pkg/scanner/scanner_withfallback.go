@@ -1,6 +1,10 @@
 package scanner
 
-import "github.com/sbom-observer/observer-cli/pkg/log"
+import (
+	"context"
+
+	"github.com/sbom-observer/observer-cli/pkg/log"
+)
 
 type withFallbackScanner struct {
 	SelectedScanner RepoScanner
@@ -38,6 +42,25 @@ func (s *withFallbackScanner) Priority() int {
 	return s.SelectedScanner.Priority()
 }
 
-func (s *withFallbackScanner) Scan(target *ScanTarget) error {
-	return s.SelectedScanner.Scan(target)
+func (s *withFallbackScanner) Version() string {
+	if versioned, ok := s.SelectedScanner.(VersionedScanner); ok {
+		return versioned.Version()
+	}
+	return ""
+}
+
+func (s *withFallbackScanner) InputFiles(target *ScanTarget) ([]string, error) {
+	if inputs, ok := s.SelectedScanner.(InputFilesScanner); ok {
+		return inputs.InputFiles(target)
+	}
+
+	var files []string
+	for filename := range target.Files {
+		files = append(files, filename)
+	}
+	return files, nil
+}
+
+func (s *withFallbackScanner) Scan(ctx context.Context, target *ScanTarget) error {
+	return s.SelectedScanner.Scan(ctx, target)
 }
@@ -0,0 +1,399 @@
+package spdxutil
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/sbom-observer/observer-cli/pkg/ids"
+	"github.com/spdx/tools-golang/spdx/v2/common"
+	"github.com/spdx/tools-golang/spdx/v2/v2_3"
+)
+
+const (
+	NoAssertion = "NOASSERTION"
+
+	// NamespacePrefix is used for the documentNamespace of converted documents
+	NamespacePrefix = "https://sbom.observer/spdx/"
+)
+
+var invalidIdCharacters = regexp.MustCompile(`[^a-zA-Z0-9.-]+`)
+
+// FromCycloneDX converts a CycloneDX BOM to an SPDX 2.3 document. The root component (metadata.component) is described
+// by the document, components (including nested components) become packages, dependencies become DEPENDS_ON
+// (or DEV_DEPENDENCY_OF for components with scope excluded) relationships and nested components are CONTAINED by their parent.
+func FromCycloneDX(bom *cdx.BOM) (*v2_3.Document, error) {
+	if bom == nil {
+		return nil, fmt.Errorf("no BOM to convert")
+	}
+
+	c := &converter{
+		doc: &v2_3.Document{
+			SPDXVersion:    v2_3.Version,
+			DataLicense:    v2_3.DataLicense,
+			SPDXIdentifier: "DOCUMENT",
+		},
+		refs:          map[string]common.ElementID{},
+		usedIds:       map[common.ElementID]bool{},
+		otherLicenses: map[string]bool{},
+		excluded:      map[string]bool{},
+	}
+
+	var root *cdx.Component
+	var timestamp string
+	if bom.Metadata != nil {
+		root = bom.Metadata.Component
+		timestamp = bom.Metadata.Timestamp
+	}
+
+	c.doc.CreationInfo = &v2_3.CreationInfo{
+		Created:  formatCreated(timestamp),
+		Creators: creators(bom.Metadata),
+	}
+
+	// name and namespace
+	name := "sbom"
+	if root != nil && root.Name != "" {
+		name = strings.TrimSpace(strings.Join([]string{root.Group, root.Name, root.Version}, " "))
+	}
+	c.doc.DocumentName = name
+
+	serial := strings.TrimPrefix(bom.SerialNumber, "urn:uuid:")
+	if serial == "" {
+		serial = ids.NextUUID()
+	}
+	c.doc.DocumentNamespace = NamespacePrefix + invalidIdCharacters.ReplaceAllString(name, "-") + "-" + serial
+
+	// packages
+	if root != nil {
+		rootId := c.addComponent(*root, "")
+		c.relate(common.ElementID("DOCUMENT"), rootId, common.TypeRelationshipDescribe)
+	}
+
+	if bom.Components != nil {
+		for _, component := range *bom.Components {
+			id := c.addComponent(component, "")
+			if root == nil {
+				c.relate(common.ElementID("DOCUMENT"), id, common.TypeRelationshipDescribe)
+			}
+		}
+	}
+
+	// relationships
+	if bom.Dependencies != nil {
+		for _, dependency := range *bom.Dependencies {
+			from, found := c.refs[dependency.Ref]
+			if !found || dependency.Dependencies == nil {
+				continue
+			}
+
+			for _, ref := range *dependency.Dependencies {
+				to, found := c.refs[ref]
+				if !found {
+					continue
+				}
+
+				if c.excluded[ref] {
+					c.relate(to, from, common.TypeRelationshipDevDependencyOf)
+				} else {
+					c.relate(from, to, common.TypeRelationshipDependsOn)
+				}
+			}
+		}
+	}
+
+	return c.doc, nil
+}
+
+type converter struct {
+	doc *v2_3.Document
+	// refs maps CycloneDX BOMRefs to SPDX element ids
+	refs          map[string]common.ElementID
+	usedIds       map[common.ElementID]bool
+	otherLicenses map[string]bool
+	// excluded are BOMRefs of components with scope excluded (dev dependencies)
+	excluded map[string]bool
+}
+
+// addComponent adds the component and its nested components as packages and returns the package id
+func (c *converter) addComponent(component cdx.Component, parent common.ElementID) common.ElementID {
+	// the same component can occur more than once (e.g. the root component in components)
+	if id, found := c.refs[component.BOMRef]; found && component.BOMRef != "" {
+		return id
+	}
+
+	id := c.elementId(component)
+	if component.BOMRef != "" {
+		c.refs[component.BOMRef] = id
+	}
+	if component.Scope == cdx.ScopeExcluded && component.BOMRef != "" {
+		c.excluded[component.BOMRef] = true
+	}
+
+	pkg := &v2_3.Package{
+		PackageName:               component.Name,
+		PackageSPDXIdentifier:     id,
+		PackageVersion:            component.Version,
+		PackageDownloadLocation:   NoAssertion,
+		FilesAnalyzed:             false,
+		IsFilesAnalyzedTagPresent: true,
+		PackageLicenseConcluded:   NoAssertion,
+		PackageLicenseDeclared:    c.licenseExpression(component.Licenses),
+		PackageCopyrightText:      NoAssertion,
+		PackageDescription:        component.Description,
+		PrimaryPackagePurpose:     purpose(component.Type),
+	}
+
+	if component.Copyright != "" {
+		pkg.PackageCopyrightText = component.Copyright
+	}
+
+	if component.Supplier != nil && component.Supplier.Name != "" {
+		pkg.PackageSupplier = &common.Supplier{Supplier: component.Supplier.Name, SupplierType: "Organization"}
+	}
+
+	if component.Author != "" {
+		pkg.PackageOriginator = &common.Originator{Originator: component.Author, OriginatorType: "Person"}
+	}
+
+	if component.Hashes != nil {
+		for _, hash := range *component.Hashes {
+			if algorithm, found := checksumAlgorithms[hash.Algorithm]; found {
+				pkg.PackageChecksums = append(pkg.PackageChecksums, common.Checksum{Algorithm: algorithm, Value: hash.Value})
+			}
+		}
+	}
+
+	if component.PackageURL != "" {
+		pkg.PackageExternalReferences = append(pkg.PackageExternalReferences, &v2_3.PackageExternalReference{
+			Category: common.CategoryPackageManager,
+			RefType:  common.TypePackageManagerPURL,
+			Locator:  component.PackageURL,
+		})
+	}
+
+	if component.CPE != "" {
+		refType := common.TypeSecurityCPE22Type
+		if strings.HasPrefix(component.CPE, "cpe:2.3:") {
+			refType = common.TypeSecurityCPE23Type
+		}
+		pkg.PackageExternalReferences = append(pkg.PackageExternalReferences, &v2_3.PackageExternalReference{
+			Category: common.CategorySecurity,
+			RefType:  refType,
+			Locator:  component.CPE,
+		})
+	}
+
+	if component.ExternalReferences != nil {
+		for _, ref := range *component.ExternalReferences {
+			switch ref.Type {
+			case cdx.ERTypeWebsite:
+				if pkg.PackageHomePage == "" {
+					pkg.PackageHomePage = ref.URL
+				}
+			case cdx.ERTypeDistribution, cdx.ERTypeVCS:
+				if pkg.PackageDownloadLocation == NoAssertion {
+					pkg.PackageDownloadLocation = ref.URL
+				}
+			}
+		}
+	}
+
+	c.doc.Packages = append(c.doc.Packages, pkg)
+
+	if parent != "" {
+		c.relate(parent, id, common.TypeRelationshipContains)
+	}
+
+	if component.Components != nil {
+		for _, nested := range *component.Components {
+			c.addComponent(nested, id)
+		}
+	}
+
+	return id
+}
+
+// elementId creates a unique SPDX id from the BOMRef (or name and version) of the component
+func (c *converter) elementId(component cdx.Component) common.ElementID {
+	base := component.BOMRef
+	if base == "" {
+		base = component.Name + "-" + component.Version
+	}
+
+	base = "Package-" + strings.Trim(invalidIdCharacters.ReplaceAllString(base, "-"), "-")
+
+	id := common.ElementID(base)
+	for i := 2; c.usedIds[id]; i++ {
+		id = common.ElementID(fmt.Sprintf("%s-%d", base, i))
+	}
+	c.usedIds[id] = true
+
+	return id
+}
+
+func (c *converter) relate(from common.ElementID, to common.ElementID, relationship string) {
+	c.doc.Relationships = append(c.doc.Relationships, &v2_3.Relationship{
+		RefA:         common.MakeDocElementID("", string(from)),
+		RefB:         common.MakeDocElementID("", string(to)),
+		Relationship: relationship,
+	})
+}
+
+// licenseExpression converts CycloneDX licenses to an SPDX license expression. Licenses that are not SPDX
+// license ids are added as LicenseRef-* to the document.
+func (c *converter) licenseExpression(licenses *cdx.Licenses) string {
+	if licenses == nil || len(*licenses) == 0 {
+		return NoAssertion
+	}
+
+	var parts []string
+	for _, choice := range *licenses {
+		switch {
+		case choice.Expression != "":
+			parts = append(parts, choice.Expression)
+		case choice.License != nil && choice.License.ID != "":
+			parts = append(parts, choice.License.ID)
+		case choice.License != nil && choice.License.Name != "":
+			parts = append(parts, c.otherLicense(choice.License.Name))
+		}
+	}
+
+	if len(parts) == 0 {
+		return NoAssertion
+	}
+
+	if len(parts) > 1 {
+		for i, part := range parts {
+			if strings.Contains(part, " ") {
+				parts[i] = "(" + part + ")"
+			}
+		}
+	}
+
+	return strings.Join(parts, " AND ")
+}
+
+func (c *converter) otherLicense(name string) string {
+	id := "LicenseRef-" + strings.Trim(invalidIdCharacters.ReplaceAllString(name, "-"), "-")
+
+	if !c.otherLicenses[id] {
+		c.otherLicenses[id] = true
+		c.doc.OtherLicenses = append(c.doc.OtherLicenses, &v2_3.OtherLicense{
+			LicenseIdentifier: id,
+			LicenseName:       name,
+			ExtractedText:     name,
+		})
+	}
+
+	return id
+}
+
+// creators returns the tools and authors from the metadata (SPDX requires at least one creator)
+func creators(metadata *cdx.Metadata) []common.Creator {
+	var result []common.Creator
+
+	if metadata != nil && metadata.Tools != nil {
+		if metadata.Tools.Components != nil {
+			for _, tool := range *metadata.Tools.Components {
+				result = append(result, common.Creator{CreatorType: "Tool", Creator: toolName(tool.Name, tool.Version)})
+			}
+		}
+
+		if metadata.Tools.Services != nil {
+			for _, tool := range *metadata.Tools.Services {
+				result = append(result, common.Creator{CreatorType: "Tool", Creator: toolName(tool.Name, tool.Version)})
+			}
+		}
+
+		//nolint:staticcheck // legacy (CycloneDX < 1.5) tools
+		if metadata.Tools.Tools != nil {
+			for _, tool := range *metadata.Tools.Tools {
+				result = append(result, common.Creator{CreatorType: "Tool", Creator: toolName(tool.Name, tool.Version)})
+			}
+		}
+	}
+
+	if metadata != nil && metadata.Authors != nil {
+		for _, author := range *metadata.Authors {
+			if author.Name != "" {
+				result = append(result, common.Creator{CreatorType: "Person", Creator: author.Name})
+			}
+		}
+	}
+
+	if metadata != nil && metadata.Manufacturer != nil && metadata.Manufacturer.Name != "" {
+		result = append(result, common.Creator{CreatorType: "Organization", Creator: metadata.Manufacturer.Name})
+	}
+
+	if len(result) == 0 {
+		result = append(result, common.Creator{CreatorType: "Tool", Creator: "observer"})
+	}
+
+	return result
+}
+
+func toolName(name string, version string) string {
+	if version == "" {
+		return name
+	}
+	return name + "-" + version
+}
+
+// formatCreated converts a CycloneDX timestamp to the SPDX created format (UTC, second precision). BOMs without
+// a (valid) timestamp get the Unix epoch, so that the output only depends on the BOM (i.e. reproducible BOMs
+// without a source date).
+func formatCreated(timestamp string) string {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		t = time.Unix(0, 0)
+	}
+	return t.UTC().Format("2006-01-02T15:04:05Z")
+}
+
+var checksumAlgorithms = map[cdx.HashAlgorithm]common.ChecksumAlgorithm{
+	cdx.HashAlgoMD5:         common.MD5,
+	cdx.HashAlgoSHA1:        common.SHA1,
+	cdx.HashAlgoSHA256:      common.SHA256,
+	cdx.HashAlgoSHA384:      common.SHA384,
+	cdx.HashAlgoSHA512:      common.SHA512,
+	cdx.HashAlgoSHA3_256:    common.SHA3_256,
+	cdx.HashAlgoSHA3_384:    common.SHA3_384,
+	cdx.HashAlgoSHA3_512:    common.SHA3_512,
+	cdx.HashAlgoBlake2b_256: common.BLAKE2b_256,
+	cdx.HashAlgoBlake2b_384: common.BLAKE2b_384,
+	cdx.HashAlgoBlake2b_512: common.BLAKE2b_512,
+	cdx.HashAlgoBlake3:      common.BLAKE3,
+}
+
+var purposes = map[cdx.ComponentType]string{
+	cdx.ComponentTypeApplication: "APPLICATION",
+	cdx.ComponentTypeContainer:   "CONTAINER",
+	cdx.ComponentTypeDevice:      "DEVICE",
+	cdx.ComponentTypeFile:        "FILE",
+	cdx.ComponentTypeFirmware:    "FIRMWARE",
+	cdx.ComponentTypeFramework:   "FRAMEWORK",
+	cdx.ComponentTypeLibrary:     "LIBRARY",
+	cdx.ComponentTypeOS:          "OPERATING-SYSTEM",
+	cdx.ComponentTypePlatform:    "OTHER",
+}
+
+func purpose(componentType cdx.ComponentType) string {
+	return purposes[componentType]
+}
+
+// componentType returns the CycloneDX component type for an SPDX primary package purpose (default library)
+func componentType(purpose string) cdx.ComponentType {
+	for componentType, p := range purposes {
+		if p == purpose && componentType != cdx.ComponentTypePlatform {
+			return componentType
+		}
+	}
+	if slices.Contains([]string{"SOURCE", "ARCHIVE", "INSTALL"}, purpose) {
+		return cdx.ComponentTypeFile
+	}
+	return cdx.ComponentTypeLibrary
+}
@@ -0,0 +1,100 @@
+package spdxutil_test
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/sbom-observer/observer-cli/pkg/spdxutil"
+	"github.com/sbom-observer/observer-cli/pkg/tasks"
+	spdxjson "github.com/spdx/tools-golang/json"
+	"github.com/spdx/tools-golang/spdx"
+	"github.com/spdx/tools-golang/tagvalue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRoundTrip_Testdata scans the testdata projects, converts the SBOMs to SPDX and back and checks that
+// components, purls, licenses, hashes and dependencies are kept
+func TestRoundTrip_Testdata(t *testing.T) {
+	for _, project := range []string{"npm-app", "npm-with-configuration", "multi-app"} {
+		t.Run(project, func(t *testing.T) {
+			boms, _, err := tasks.CreateFilesystemSBOM(context.Background(), []string{filepath.Join("..", "..", "testdata", project)}, nil, tasks.FilesystemOptions{
+				Depth:    1,
+				Merge:    true,
+				NoCache:  true,
+				Scanners: map[string][]string{"npm": {"scalibr"}},
+			})
+			require.NoError(t, err)
+			require.Len(t, boms, 1)
+
+			bom := boms[0]
+			require.NotNil(t, bom.Components)
+			require.NotEmpty(t, *bom.Components)
+
+			var jsonBuf, tvBuf bytes.Buffer
+			require.NoError(t, spdxutil.EncodeJSON(&jsonBuf, bom, false))
+			require.NoError(t, spdxutil.EncodeTagValue(&tvBuf, bom))
+
+			fromJSON, err := spdxjson.Read(&jsonBuf)
+			require.NoError(t, err)
+			fromTagValue, err := tagvalue.Read(&tvBuf)
+			require.NoError(t, err)
+
+			for name, doc := range map[string]*spdx.Document{"json": fromJSON, "tag-value": fromTagValue} {
+				actual, err := spdxutil.ToCycloneDX(doc)
+				require.NoError(t, err, name)
+
+				assert.Equal(t, bom.Metadata.Component.Name, actual.Metadata.Component.Name, name)
+				assert.Equal(t, bom.Metadata.Component.Version, actual.Metadata.Component.Version, name)
+				assert.Equal(t, componentSummaries(bom), componentSummaries(actual), name)
+				assert.ElementsMatch(t, dependencyEdges(bom), dependencyEdges(actual), name)
+			}
+		})
+	}
+}
+
+func componentSummaries(bom *cdx.BOM) map[string]string {
+	result := map[string]string{}
+	for _, c := range *bom.Components {
+		summary := c.Group + "|" + c.Name + "|" + c.Version + "|" + string(c.Scope)
+		if c.Licenses != nil {
+			for _, l := range *c.Licenses {
+				if l.License != nil {
+					summary += "|" + l.License.ID + l.License.Name
+				}
+				summary += l.Expression
+			}
+		}
+		if c.Hashes != nil {
+			for _, h := range *c.Hashes {
+				summary += "|" + string(h.Algorithm) + ":" + h.Value
+			}
+		}
+		result[c.PackageURL+"|"+c.Name] = summary
+	}
+	return result
+}
+
+func dependencyEdges(bom *cdx.BOM) []string {
+	names := map[string]string{bom.Metadata.Component.BOMRef: "root"}
+	for _, c := range *bom.Components {
+		names[c.BOMRef] = c.PackageURL + "|" + c.Name
+	}
+
+	var result []string
+	if bom.Dependencies == nil {
+		return result
+	}
+	for _, d := range *bom.Dependencies {
+		if d.Dependencies == nil {
+			continue
+		}
+		for _, ref := range *d.Dependencies {
+			result = append(result, names[d.Ref]+" -> "+names[ref])
+		}
+	}
+	return result
+}
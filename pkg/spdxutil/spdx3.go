@@ -0,0 +1,575 @@
+package spdxutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/sbom-observer/observer-cli/pkg/ids"
+)
+
+const (
+	SPDX3Version = "3.0.1"
+	SPDX3Context = "https://spdx.org/rdf/3.0.1/spdx-context.jsonld"
+
+	// BuildObserverBuildType is the build_buildType of builds observed with build-observer
+	BuildObserverBuildType = "https://github.com/sbom-observer/build-observer"
+
+	creationInfoId = "_:creationinfo"
+)
+
+// SPDX3Document is an SPDX 3.0 JSON-LD document
+type SPDX3Document struct {
+	Context string `json:"@context"`
+	Graph   []any  `json:"@graph"`
+}
+
+type spdx3CreationInfo struct {
+	Type         string   `json:"type"`
+	Id           string   `json:"@id"`
+	SpecVersion  string   `json:"specVersion"`
+	Created      string   `json:"created"`
+	CreatedBy    []string `json:"createdBy"`
+	CreatedUsing []string `json:"createdUsing,omitempty"`
+}
+
+// spdx3Element holds the properties of the element classes (Core, Software, SimpleLicensing and Build profiles) used by observer
+type spdx3Element struct {
+	Type         string `json:"type"`
+	SpdxId       string `json:"spdxId"`
+	CreationInfo string `json:"creationInfo"`
+	Name         string `json:"name,omitempty"`
+	Description  string `json:"description,omitempty"`
+
+	VerifiedUsing      []spdx3Hash               `json:"verifiedUsing,omitempty"`
+	ExternalIdentifier []spdx3ExternalIdentifier `json:"externalIdentifier,omitempty"`
+
+	// SpdxDocument and software_Sbom
+	RootElement        []string `json:"rootElement,omitempty"`
+	Element            []string `json:"element,omitempty"`
+	ProfileConformance []string `json:"profileConformance,omitempty"`
+	SbomType           []string `json:"software_sbomType,omitempty"`
+
+	// software_Package and software_File
+	PackageVersion   string   `json:"software_packageVersion,omitempty"`
+	PackageUrl       string   `json:"software_packageUrl,omitempty"`
+	DownloadLocation string   `json:"software_downloadLocation,omitempty"`
+	HomePage         string   `json:"software_homePage,omitempty"`
+	CopyrightText    string   `json:"software_copyrightText,omitempty"`
+	PrimaryPurpose   string   `json:"software_primaryPurpose,omitempty"`
+	SuppliedBy       string   `json:"suppliedBy,omitempty"`
+	OriginatedBy     []string `json:"originatedBy,omitempty"`
+
+	// Relationship and LifecycleScopedRelationship
+	From             string   `json:"from,omitempty"`
+	To               []string `json:"to,omitempty"`
+	RelationshipType string   `json:"relationshipType,omitempty"`
+	Scope            string   `json:"scope,omitempty"`
+
+	// simplelicensing_LicenseExpression
+	LicenseExpression string `json:"simplelicensing_licenseExpression,omitempty"`
+
+	// build_Build
+	BuildType      string `json:"build_buildType,omitempty"`
+	BuildStartTime string `json:"build_buildStartTime,omitempty"`
+}
+
+type spdx3Hash struct {
+	Type      string `json:"type"`
+	Algorithm string `json:"algorithm"`
+	HashValue string `json:"hashValue"`
+}
+
+type spdx3ExternalIdentifier struct {
+	Type                   string `json:"type"`
+	ExternalIdentifierType string `json:"externalIdentifierType"`
+	Identifier             string `json:"identifier"`
+}
+
+// EncodeSPDX3JSON converts the BOM to SPDX 3.0 and writes it as JSON-LD
+func EncodeSPDX3JSON(w io.Writer, bom *cdx.BOM, pretty bool) error {
+	doc, err := FromCycloneDXSPDX3(bom)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	if pretty {
+		encoder.SetIndent("", "  ")
+	}
+
+	return encoder.Encode(doc)
+}
+
+// FromCycloneDXSPDX3 converts a CycloneDX BOM to an SPDX 3.0 JSON-LD document using the Core, Software,
+// SimpleLicensing and Build profiles. BOMs created from build observations are described by a build_Build
+// element: tools are linked with usesTool, code dependencies with hasInput and the root component with hasOutput.
+func FromCycloneDXSPDX3(bom *cdx.BOM) (*SPDX3Document, error) {
+	if bom == nil {
+		return nil, fmt.Errorf("no BOM to convert")
+	}
+
+	var root *cdx.Component
+	var timestamp string
+	if bom.Metadata != nil {
+		root = bom.Metadata.Component
+		timestamp = bom.Metadata.Timestamp
+	}
+
+	name := "sbom"
+	if root != nil && root.Name != "" {
+		name = strings.TrimSpace(strings.Join([]string{root.Group, root.Name, root.Version}, " "))
+	}
+
+	serial := strings.TrimPrefix(bom.SerialNumber, "urn:uuid:")
+	if serial == "" {
+		serial = ids.NextUUID()
+	}
+
+	c := &spdx3Converter{
+		namespace: NamespacePrefix + invalidIdCharacters.ReplaceAllString(name, "-") + "-" + serial + "#",
+		refs:      map[string]string{},
+		usedIds:   map[string]bool{},
+		agents:    map[string]string{},
+	}
+
+	creationInfo := &spdx3CreationInfo{
+		Type:        "CreationInfo",
+		Id:          creationInfoId,
+		SpecVersion: SPDX3Version,
+		Created:     formatCreated(timestamp),
+	}
+	c.graph = append(c.graph, creationInfo)
+
+	// creators
+	if bom.Metadata != nil {
+		if bom.Metadata.Authors != nil {
+			for _, author := range *bom.Metadata.Authors {
+				if author.Name != "" {
+					creationInfo.CreatedBy = append(creationInfo.CreatedBy, c.agent("Person", author.Name))
+				}
+			}
+		}
+
+		if bom.Metadata.Manufacturer != nil && bom.Metadata.Manufacturer.Name != "" {
+			creationInfo.CreatedBy = append(creationInfo.CreatedBy, c.agent("Organization", bom.Metadata.Manufacturer.Name))
+		}
+
+		if bom.Metadata.Tools != nil && bom.Metadata.Tools.Components != nil {
+			for _, tool := range *bom.Metadata.Tools.Components {
+				creationInfo.CreatedUsing = append(creationInfo.CreatedUsing, c.agent("Tool", toolName(tool.Name, tool.Version)))
+			}
+		}
+	}
+
+	// createdBy is required
+	if len(creationInfo.CreatedBy) == 0 {
+		creationInfo.CreatedBy = append(creationInfo.CreatedBy, c.agent("SoftwareAgent", "observer"))
+	}
+
+	// packages
+	var rootId string
+	if root != nil {
+		rootId = c.addComponent(*root, "")
+	}
+
+	var topLevel []string
+	if bom.Components != nil {
+		for _, component := range *bom.Components {
+			topLevel = append(topLevel, c.addComponent(component, ""))
+		}
+	}
+
+	// dependencies
+	var rootDependencies []string
+	if bom.Dependencies != nil {
+		for _, dependency := range *bom.Dependencies {
+			from, found := c.refs[dependency.Ref]
+			if !found || dependency.Dependencies == nil {
+				continue
+			}
+
+			var to, devTo []string
+			for _, ref := range *dependency.Dependencies {
+				id, found := c.refs[ref]
+				if !found {
+					continue
+				}
+
+				if c.isExcluded(ref, bom) {
+					devTo = append(devTo, id)
+				} else {
+					to = append(to, id)
+				}
+
+				if root != nil && dependency.Ref == root.BOMRef && !c.tools[ref] {
+					rootDependencies = append(rootDependencies, id)
+				}
+			}
+
+			if len(to) > 0 {
+				c.relate("Relationship", from, "dependsOn", to, "")
+			}
+			if len(devTo) > 0 {
+				c.relate("LifecycleScopedRelationship", from, "dependsOn", devTo, "development")
+			}
+		}
+	}
+
+	// build profile
+	profiles := []string{"core", "software", "simpleLicensing"}
+	sbomType := "analyzed"
+	if isBuildObservation(bom) {
+		profiles = append(profiles, "build")
+		sbomType = "build"
+
+		build := &spdx3Element{
+			Type:         "build_Build",
+			SpdxId:       c.id("Build-" + name),
+			CreationInfo: creationInfoId,
+			BuildType:    BuildObserverBuildType,
+		}
+		if timestamp != "" {
+			build.BuildStartTime = formatCreated(timestamp)
+		}
+		c.add(build)
+
+		var toolIds []string
+		for _, ref := range c.toolRefs {
+			toolIds = append(toolIds, c.refs[ref])
+		}
+
+		if len(toolIds) > 0 {
+			c.relate("Relationship", build.SpdxId, "usesTool", toolIds, "")
+		}
+		if len(rootDependencies) > 0 {
+			c.relate("Relationship", build.SpdxId, "hasInput", rootDependencies, "")
+		}
+		if rootId != "" {
+			c.relate("Relationship", build.SpdxId, "hasOutput", []string{rootId}, "")
+		}
+	}
+
+	rootElements := topLevel
+	if rootId != "" {
+		rootElements = []string{rootId}
+	}
+
+	sbom := &spdx3Element{
+		Type:         "software_Sbom",
+		SpdxId:       c.id("Sbom"),
+		CreationInfo: creationInfoId,
+		RootElement:  rootElements,
+		Element:      c.elements,
+		SbomType:     []string{sbomType},
+	}
+
+	document := &spdx3Element{
+		Type:               "SpdxDocument",
+		SpdxId:             c.id("Document"),
+		CreationInfo:       creationInfoId,
+		Name:               name,
+		RootElement:        []string{sbom.SpdxId},
+		Element:            append([]string{sbom.SpdxId}, c.elements...),
+		ProfileConformance: profiles,
+	}
+
+	graph := append(c.graph, sbom, document)
+
+	return &SPDX3Document{Context: SPDX3Context, Graph: graph}, nil
+}
+
+type spdx3Converter struct {
+	namespace string
+	graph     []any
+	// elements are the ids of all elements except the creation info, documents and agents
+	elements []string
+	// refs maps CycloneDX BOMRefs to SPDX ids
+	refs    map[string]string
+	usedIds map[string]bool
+	// agents maps type and name to SPDX ids
+	agents map[string]string
+	// tools are the BOMRefs of tools used by an observed build
+	tools    map[string]bool
+	toolRefs []string
+	excluded map[string]bool
+}
+
+func (c *spdx3Converter) id(base string) string {
+	base = "SPDXRef-" + strings.Trim(invalidIdCharacters.ReplaceAllString(base, "-"), "-")
+
+	id := base
+	for i := 2; c.usedIds[id]; i++ {
+		id = fmt.Sprintf("%s-%d", base, i)
+	}
+	c.usedIds[id] = true
+
+	return c.namespace + id
+}
+
+func (c *spdx3Converter) add(element *spdx3Element) {
+	c.graph = append(c.graph, element)
+	c.elements = append(c.elements, element.SpdxId)
+}
+
+func (c *spdx3Converter) agent(agentType string, name string) string {
+	key := agentType + ":" + name
+	if id, found := c.agents[key]; found {
+		return id
+	}
+
+	id := c.id(agentType + "-" + name)
+	c.agents[key] = id
+	c.graph = append(c.graph, &spdx3Element{
+		Type:         agentType,
+		SpdxId:       id,
+		CreationInfo: creationInfoId,
+		Name:         name,
+	})
+
+	return id
+}
+
+func (c *spdx3Converter) relate(relationshipClass string, from string, relationshipType string, to []string, scope string) {
+	c.add(&spdx3Element{
+		Type:             relationshipClass,
+		SpdxId:           c.id("Relationship"),
+		CreationInfo:     creationInfoId,
+		From:             from,
+		To:               to,
+		RelationshipType: relationshipType,
+		Scope:            scope,
+	})
+}
+
+// addComponent adds the component (and nested components) as software_Package or software_File elements
+func (c *spdx3Converter) addComponent(component cdx.Component, parent string) string {
+	if id, found := c.refs[component.BOMRef]; found && component.BOMRef != "" {
+		return id
+	}
+
+	base := component.BOMRef
+	if base == "" {
+		base = component.Name + "-" + component.Version
+	}
+
+	elementType := "software_Package"
+	if component.Type == cdx.ComponentTypeFile && component.PackageURL == "" {
+		elementType = "software_File"
+		base = "File-" + base
+	} else {
+		base = "Package-" + base
+	}
+
+	element := &spdx3Element{
+		Type:           elementType,
+		SpdxId:         c.id(base),
+		CreationInfo:   creationInfoId,
+		Name:           component.Name,
+		Description:    component.Description,
+		PackageVersion: component.Version,
+		PackageUrl:     component.PackageURL,
+		CopyrightText:  component.Copyright,
+		PrimaryPurpose: spdx3Purpose(component.Type),
+	}
+
+	if elementType == "software_File" {
+		element.PackageVersion = ""
+	}
+
+	if component.BOMRef != "" {
+		c.refs[component.BOMRef] = element.SpdxId
+	}
+
+	if isBuildTool(component) && component.BOMRef != "" {
+		if c.tools == nil {
+			c.tools = map[string]bool{}
+		}
+		c.tools[component.BOMRef] = true
+		c.toolRefs = append(c.toolRefs, component.BOMRef)
+	}
+
+	if component.Supplier != nil && component.Supplier.Name != "" {
+		element.SuppliedBy = c.agent("Organization", component.Supplier.Name)
+	}
+
+	if component.Author != "" {
+		element.OriginatedBy = append(element.OriginatedBy, c.agent("Person", component.Author))
+	}
+
+	if component.Hashes != nil {
+		for _, hash := range *component.Hashes {
+			if algorithm, found := spdx3HashAlgorithms[hash.Algorithm]; found {
+				element.VerifiedUsing = append(element.VerifiedUsing, spdx3Hash{Type: "Hash", Algorithm: algorithm, HashValue: hash.Value})
+			}
+		}
+	}
+
+	if component.PackageURL != "" {
+		element.ExternalIdentifier = append(element.ExternalIdentifier, spdx3ExternalIdentifier{Type: "ExternalIdentifier", ExternalIdentifierType: "packageUrl", Identifier: component.PackageURL})
+	}
+
+	if component.CPE != "" {
+		identifierType := "cpe22"
+		if strings.HasPrefix(component.CPE, "cpe:2.3:") {
+			identifierType = "cpe23"
+		}
+		element.ExternalIdentifier = append(element.ExternalIdentifier, spdx3ExternalIdentifier{Type: "ExternalIdentifier", ExternalIdentifierType: identifierType, Identifier: component.CPE})
+	}
+
+	if component.ExternalReferences != nil {
+		for _, ref := range *component.ExternalReferences {
+			switch ref.Type {
+			case cdx.ERTypeWebsite:
+				if element.HomePage == "" {
+					element.HomePage = ref.URL
+				}
+			case cdx.ERTypeDistribution, cdx.ERTypeVCS:
+				if element.DownloadLocation == "" {
+					element.DownloadLocation = ref.URL
+				}
+			}
+		}
+	}
+
+	c.add(element)
+
+	if expression := spdx3LicenseExpression(component.Licenses); expression != "" {
+		license := &spdx3Element{
+			Type:              "simplelicensing_LicenseExpression",
+			SpdxId:            c.id("License-" + expression),
+			CreationInfo:      creationInfoId,
+			LicenseExpression: expression,
+		}
+		c.add(license)
+		c.relate("Relationship", element.SpdxId, "hasDeclaredLicense", []string{license.SpdxId}, "")
+	}
+
+	if parent != "" {
+		c.relate("Relationship", parent, "contains", []string{element.SpdxId}, "")
+	}
+
+	if component.Components != nil {
+		for _, nested := range *component.Components {
+			c.addComponent(nested, element.SpdxId)
+		}
+	}
+
+	return element.SpdxId
+}
+
+// isExcluded returns true if the component with the BOMRef has scope excluded (development dependencies and build tools)
+func (c *spdx3Converter) isExcluded(ref string, bom *cdx.BOM) bool {
+	if c.excluded == nil {
+		c.excluded = map[string]bool{}
+		if bom.Components != nil {
+			for _, component := range *bom.Components {
+				if component.Scope == cdx.ScopeExcluded && component.BOMRef != "" {
+					c.excluded[component.BOMRef] = true
+				}
+			}
+		}
+	}
+
+	return c.excluded[ref]
+}
+
+// isBuildObservation returns true if the BOM was created from build observations (build-observer)
+func isBuildObservation(bom *cdx.BOM) bool {
+	if bom.Metadata != nil && bom.Metadata.Tools != nil && bom.Metadata.Tools.Components != nil {
+		for _, tool := range *bom.Metadata.Tools.Components {
+			if tool.Name == "build-observer" {
+				return true
+			}
+		}
+	}
+
+	if bom.Components != nil {
+		for _, component := range *bom.Components {
+			if isBuildTool(component) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// isBuildTool returns true for tools used by an observed build
+func isBuildTool(component cdx.Component) bool {
+	if component.Properties == nil {
+		return false
+	}
+
+	for _, property := range *component.Properties {
+		if property.Name == "observer:build:role" && property.Value == "tool" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// spdx3LicenseExpression converts CycloneDX licenses to a license expression (names that are not SPDX ids are
+// converted to LicenseRef-*)
+func spdx3LicenseExpression(licenses *cdx.Licenses) string {
+	if licenses == nil {
+		return ""
+	}
+
+	var parts []string
+	for _, choice := range *licenses {
+		switch {
+		case choice.Expression != "":
+			parts = append(parts, choice.Expression)
+		case choice.License != nil && choice.License.ID != "":
+			parts = append(parts, choice.License.ID)
+		case choice.License != nil && choice.License.Name != "":
+			parts = append(parts, "LicenseRef-"+strings.Trim(invalidIdCharacters.ReplaceAllString(choice.License.Name, "-"), "-"))
+		}
+	}
+
+	if len(parts) > 1 {
+		for i, part := range parts {
+			if strings.Contains(part, " ") {
+				parts[i] = "(" + part + ")"
+			}
+		}
+	}
+
+	return strings.Join(parts, " AND ")
+}
+
+var spdx3HashAlgorithms = map[cdx.HashAlgorithm]string{
+	cdx.HashAlgoMD5:         "md5",
+	cdx.HashAlgoSHA1:        "sha1",
+	cdx.HashAlgoSHA256:      "sha256",
+	cdx.HashAlgoSHA384:      "sha384",
+	cdx.HashAlgoSHA512:      "sha512",
+	cdx.HashAlgoSHA3_256:    "sha3_256",
+	cdx.HashAlgoSHA3_384:    "sha3_384",
+	cdx.HashAlgoSHA3_512:    "sha3_512",
+	cdx.HashAlgoBlake2b_256: "blake2b256",
+	cdx.HashAlgoBlake2b_384: "blake2b384",
+	cdx.HashAlgoBlake2b_512: "blake2b512",
+	cdx.HashAlgoBlake3:      "blake3",
+}
+
+var spdx3Purposes = map[cdx.ComponentType]string{
+	cdx.ComponentTypeApplication: "application",
+	cdx.ComponentTypeContainer:   "container",
+	cdx.ComponentTypeDevice:      "device",
+	cdx.ComponentTypeFile:        "file",
+	cdx.ComponentTypeFirmware:    "firmware",
+	cdx.ComponentTypeFramework:   "framework",
+	cdx.ComponentTypeLibrary:     "library",
+	cdx.ComponentTypeOS:          "operatingSystem",
+	cdx.ComponentTypePlatform:    "other",
+}
+
+func spdx3Purpose(componentType cdx.ComponentType) string {
+	return spdx3Purposes[componentType]
+}
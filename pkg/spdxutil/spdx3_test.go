@@ -0,0 +1,218 @@
+package spdxutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// relationshipsSPDX3 returns the relationships of the graph as "from type to" using element names
+func relationshipsSPDX3(doc *SPDX3Document) []string {
+	names := map[string]string{}
+	for _, node := range doc.Graph {
+		if element, ok := node.(*spdx3Element); ok {
+			name := element.Name
+			if name == "" {
+				name = element.Type
+			}
+			if element.LicenseExpression != "" {
+				name = element.LicenseExpression
+			}
+			names[element.SpdxId] = name
+		}
+	}
+
+	var result []string
+	for _, node := range doc.Graph {
+		if element, ok := node.(*spdx3Element); ok && element.RelationshipType != "" {
+			var to []string
+			for _, id := range element.To {
+				to = append(to, names[id])
+			}
+			relationship := names[element.From] + " " + element.RelationshipType + " " + strings.Join(to, ",")
+			if element.Scope != "" {
+				relationship += " (" + element.Scope + ")"
+			}
+			result = append(result, relationship)
+		}
+	}
+	return result
+}
+
+func TestFromCycloneDXSPDX3(t *testing.T) {
+	doc, err := FromCycloneDXSPDX3(testBOM())
+	require.NoError(t, err)
+
+	assert.Equal(t, SPDX3Context, doc.Context)
+
+	creationInfo := doc.Graph[0].(*spdx3CreationInfo)
+	assert.Equal(t, "3.0.1", creationInfo.SpecVersion)
+	assert.Equal(t, "2025-01-02T03:04:05Z", creationInfo.Created)
+	require.Len(t, creationInfo.CreatedUsing, 1)
+	require.Len(t, creationInfo.CreatedBy, 1)
+
+	var commons *spdx3Element
+	var document *spdx3Element
+	for _, node := range doc.Graph {
+		element, ok := node.(*spdx3Element)
+		if !ok {
+			continue
+		}
+		switch {
+		case element.Name == "commons-lang3":
+			commons = element
+		case element.Type == "SpdxDocument":
+			document = element
+		}
+		assert.True(t, strings.HasPrefix(element.SpdxId, "https://sbom.observer/spdx/app-2.0.0-3e671687-395b-41f5-a30f-a58921a69b79#SPDXRef-"), element.SpdxId)
+	}
+
+	require.NotNil(t, commons)
+	assert.Equal(t, "software_Package", commons.Type)
+	assert.Equal(t, "3.12.0", commons.PackageVersion)
+	assert.Equal(t, "pkg:maven/org.apache.commons/commons-lang3@3.12.0", commons.PackageUrl)
+	assert.Equal(t, "library", commons.PrimaryPurpose)
+	assert.Equal(t, "https://commons.apache.org/proper/commons-lang/", commons.HomePage)
+	assert.Equal(t, []spdx3Hash{{Type: "Hash", Algorithm: "sha256", HashValue: "d919d904486c037f8d193412da0c92e22a9fa24230b9d67a57855c5c31c7e94e"}}, commons.VerifiedUsing)
+	require.Len(t, commons.ExternalIdentifier, 2)
+	assert.Equal(t, "cpe23", commons.ExternalIdentifier[1].ExternalIdentifierType)
+
+	require.NotNil(t, document)
+	assert.Equal(t, []string{"core", "software", "simpleLicensing"}, document.ProfileConformance)
+
+	assert.Equal(t, []string{
+		"commons-lang3 hasDeclaredLicense Apache-2.0",
+		"left-pad hasDeclaredLicense MIT OR WTFPL",
+		"jest hasDeclaredLicense LicenseRef-Custom-License",
+		"app dependsOn commons-lang3,left-pad",
+		"app dependsOn jest (development)",
+		"left-pad dependsOn commons-lang3",
+	}, relationshipsSPDX3(doc))
+}
+
+func TestFromCycloneDXSPDX3_Build(t *testing.T) {
+	bom := cdx.NewBOM()
+	bom.SerialNumber = "urn:uuid:9b0e3c8c-1f7a-4d0e-9a55-2a9f9d1b6e11"
+	bom.Metadata = &cdx.Metadata{
+		Timestamp: "2025-01-02T03:04:05+00:00",
+		Tools: &cdx.ToolsChoice{
+			Components: &[]cdx.Component{
+				{Type: cdx.ComponentTypeApplication, Name: "observer", Version: "1.0.0"},
+				{Type: cdx.ComponentTypeApplication, Name: "build-observer", Version: "0.1"},
+			},
+		},
+		Lifecycles: &[]cdx.Lifecycle{{Phase: cdx.LifecyclePhaseBuild}},
+		Component:  &cdx.Component{BOMRef: "root", Type: cdx.ComponentTypeApplication, Name: "firmware", Version: "1.0"},
+	}
+	bom.Components = &[]cdx.Component{
+		{
+			BOMRef:     "pkg:deb/debian/zlib1g-dev@1.2.13",
+			Type:       cdx.ComponentTypeLibrary,
+			Name:       "zlib1g-dev",
+			Version:    "1.2.13",
+			PackageURL: "pkg:deb/debian/zlib1g-dev@1.2.13",
+		},
+		{
+			BOMRef:     "pkg:deb/debian/gcc-12@12.2.0",
+			Type:       cdx.ComponentTypeApplication,
+			Name:       "gcc-12",
+			Version:    "12.2.0",
+			PackageURL: "pkg:deb/debian/gcc-12@12.2.0",
+			Scope:      cdx.ScopeExcluded,
+			Properties: &[]cdx.Property{{Name: "observer:build:role", Value: "tool"}},
+			Components: &[]cdx.Component{
+				{
+					Type:   cdx.ComponentTypeFile,
+					Name:   "/usr/bin/gcc-12",
+					Hashes: &[]cdx.Hash{{Algorithm: cdx.HashAlgoSHA256, Value: "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"}},
+				},
+			},
+		},
+	}
+	bom.Dependencies = &[]cdx.Dependency{
+		{Ref: "root", Dependencies: &[]string{"pkg:deb/debian/zlib1g-dev@1.2.13", "pkg:deb/debian/gcc-12@12.2.0"}},
+	}
+
+	doc, err := FromCycloneDXSPDX3(bom)
+	require.NoError(t, err)
+
+	var build, file, sbom, document *spdx3Element
+	for _, node := range doc.Graph {
+		if element, ok := node.(*spdx3Element); ok {
+			switch element.Type {
+			case "build_Build":
+				build = element
+			case "software_File":
+				file = element
+			case "software_Sbom":
+				sbom = element
+			case "SpdxDocument":
+				document = element
+			}
+		}
+	}
+
+	require.NotNil(t, build)
+	assert.Equal(t, BuildObserverBuildType, build.BuildType)
+	assert.Equal(t, "2025-01-02T03:04:05Z", build.BuildStartTime)
+
+	require.NotNil(t, file)
+	assert.Equal(t, "/usr/bin/gcc-12", file.Name)
+	assert.Equal(t, "file", file.PrimaryPurpose)
+
+	require.NotNil(t, sbom)
+	assert.Equal(t, []string{"build"}, sbom.SbomType)
+
+	require.NotNil(t, document)
+	assert.Contains(t, document.ProfileConformance, "build")
+
+	assert.Equal(t, []string{
+		"gcc-12 contains /usr/bin/gcc-12",
+		"firmware dependsOn zlib1g-dev",
+		"firmware dependsOn gcc-12 (development)",
+		"build_Build usesTool gcc-12",
+		"build_Build hasInput zlib1g-dev",
+		"build_Build hasOutput firmware",
+	}, relationshipsSPDX3(doc))
+}
+
+func TestEncodeSPDX3JSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, EncodeSPDX3JSON(&buf, testBOM(), true))
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, SPDX3Context, decoded["@context"])
+
+	graph := decoded["@graph"].([]any)
+	ids := map[string]bool{}
+	for _, node := range graph {
+		element := node.(map[string]any)
+		require.NotEmpty(t, element["type"])
+
+		if element["type"] == "CreationInfo" {
+			continue
+		}
+
+		id := element["spdxId"].(string)
+		assert.False(t, ids[id], "duplicate spdxId %s", id)
+		ids[id] = true
+		assert.Equal(t, "_:creationinfo", element["creationInfo"])
+	}
+
+	// all references point to elements in the graph
+	for _, node := range graph {
+		element := node.(map[string]any)
+		if from, ok := element["from"].(string); ok {
+			assert.True(t, ids[from], from)
+			for _, to := range element["to"].([]any) {
+				assert.True(t, ids[to.(string)], to)
+			}
+		}
+	}
+}
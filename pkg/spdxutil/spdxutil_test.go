@@ -0,0 +1,187 @@
+package spdxutil
+
+import (
+	"bytes"
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	spdxjson "github.com/spdx/tools-golang/json"
+	"github.com/spdx/tools-golang/spdx/v2/common"
+	"github.com/spdx/tools-golang/tagvalue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testBOM() *cdx.BOM {
+	bom := cdx.NewBOM()
+	bom.SerialNumber = "urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79"
+	bom.Metadata = &cdx.Metadata{
+		Timestamp: "2025-01-02T03:04:05Z",
+		Tools: &cdx.ToolsChoice{
+			Components: &[]cdx.Component{{Type: cdx.ComponentTypeApplication, Name: "observer", Version: "1.0.0"}},
+		},
+		Component: &cdx.Component{
+			BOMRef:   "root",
+			Type:     cdx.ComponentTypeApplication,
+			Name:     "app",
+			Version:  "2.0.0",
+			Supplier: &cdx.OrganizationalEntity{Name: "Acme"},
+		},
+	}
+	bom.Components = &[]cdx.Component{
+		{
+			BOMRef:     "pkg:maven/org.apache.commons/commons-lang3@3.12.0",
+			Type:       cdx.ComponentTypeLibrary,
+			Group:      "org.apache.commons",
+			Name:       "commons-lang3",
+			Version:    "3.12.0",
+			PackageURL: "pkg:maven/org.apache.commons/commons-lang3@3.12.0",
+			CPE:        "cpe:2.3:a:apache:commons_lang:3.12.0:*:*:*:*:*:*:*",
+			Licenses:   &cdx.Licenses{{License: &cdx.License{ID: "Apache-2.0"}}},
+			Hashes:     &[]cdx.Hash{{Algorithm: cdx.HashAlgoSHA256, Value: "d919d904486c037f8d193412da0c92e22a9fa24230b9d67a57855c5c31c7e94e"}},
+			ExternalReferences: &[]cdx.ExternalReference{
+				{Type: cdx.ERTypeWebsite, URL: "https://commons.apache.org/proper/commons-lang/"},
+			},
+		},
+		{
+			BOMRef:     "pkg:npm/left-pad@1.3.0",
+			Type:       cdx.ComponentTypeLibrary,
+			Name:       "left-pad",
+			Version:    "1.3.0",
+			PackageURL: "pkg:npm/left-pad@1.3.0",
+			Licenses:   &cdx.Licenses{{Expression: "MIT OR WTFPL"}},
+		},
+		{
+			BOMRef:     "pkg:npm/jest@29.0.0",
+			Type:       cdx.ComponentTypeLibrary,
+			Name:       "jest",
+			Version:    "29.0.0",
+			PackageURL: "pkg:npm/jest@29.0.0",
+			Scope:      cdx.ScopeExcluded,
+			Licenses:   &cdx.Licenses{{License: &cdx.License{Name: "Custom License"}}},
+		},
+	}
+	bom.Dependencies = &[]cdx.Dependency{
+		{Ref: "root", Dependencies: &[]string{"pkg:maven/org.apache.commons/commons-lang3@3.12.0", "pkg:npm/left-pad@1.3.0", "pkg:npm/jest@29.0.0"}},
+		{Ref: "pkg:npm/left-pad@1.3.0", Dependencies: &[]string{"pkg:maven/org.apache.commons/commons-lang3@3.12.0"}},
+	}
+
+	return bom
+}
+
+func TestFromCycloneDX(t *testing.T) {
+	doc, err := FromCycloneDX(testBOM())
+	require.NoError(t, err)
+
+	assert.Equal(t, "SPDX-2.3", doc.SPDXVersion)
+	assert.Equal(t, "app 2.0.0", doc.DocumentName)
+	assert.Equal(t, "https://sbom.observer/spdx/app-2.0.0-3e671687-395b-41f5-a30f-a58921a69b79", doc.DocumentNamespace)
+	assert.Equal(t, "2025-01-02T03:04:05Z", doc.CreationInfo.Created)
+	assert.Equal(t, []common.Creator{{CreatorType: "Tool", Creator: "observer-1.0.0"}}, doc.CreationInfo.Creators)
+
+	require.Len(t, doc.Packages, 4)
+
+	commons := doc.Packages[1]
+	assert.Equal(t, common.ElementID("Package-pkg-maven-org.apache.commons-commons-lang3-3.12.0"), commons.PackageSPDXIdentifier)
+	assert.Equal(t, "Apache-2.0", commons.PackageLicenseDeclared)
+	assert.Equal(t, []common.Checksum{{Algorithm: common.SHA256, Value: "d919d904486c037f8d193412da0c92e22a9fa24230b9d67a57855c5c31c7e94e"}}, commons.PackageChecksums)
+	assert.Equal(t, "https://commons.apache.org/proper/commons-lang/", commons.PackageHomePage)
+	require.Len(t, commons.PackageExternalReferences, 2)
+	assert.Equal(t, "purl", commons.PackageExternalReferences[0].RefType)
+	assert.Equal(t, "cpe23Type", commons.PackageExternalReferences[1].RefType)
+
+	assert.Equal(t, "MIT OR WTFPL", doc.Packages[2].PackageLicenseDeclared)
+	assert.Equal(t, "LicenseRef-Custom-License", doc.Packages[3].PackageLicenseDeclared)
+	require.Len(t, doc.OtherLicenses, 1)
+
+	var relationships []string
+	for _, r := range doc.Relationships {
+		relationships = append(relationships, common.RenderDocElementID(r.RefA)+" "+r.Relationship+" "+common.RenderDocElementID(r.RefB))
+	}
+	assert.Equal(t, []string{
+		"SPDXRef-DOCUMENT DESCRIBES SPDXRef-Package-root",
+		"SPDXRef-Package-root DEPENDS_ON SPDXRef-Package-pkg-maven-org.apache.commons-commons-lang3-3.12.0",
+		"SPDXRef-Package-root DEPENDS_ON SPDXRef-Package-pkg-npm-left-pad-1.3.0",
+		"SPDXRef-Package-pkg-npm-jest-29.0.0 DEV_DEPENDENCY_OF SPDXRef-Package-root",
+		"SPDXRef-Package-pkg-npm-left-pad-1.3.0 DEPENDS_ON SPDXRef-Package-pkg-maven-org.apache.commons-commons-lang3-3.12.0",
+	}, relationships)
+}
+
+func TestRoundTrip(t *testing.T) {
+	bom := testBOM()
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, EncodeJSON(&buf, bom, true))
+
+		doc, err := spdxjson.Read(&buf)
+		require.NoError(t, err)
+
+		converted, err := ToCycloneDX(doc)
+		require.NoError(t, err)
+		assertEquivalent(t, bom, converted)
+	})
+
+	t.Run("tag-value", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, EncodeTagValue(&buf, bom))
+
+		doc, err := tagvalue.Read(&buf)
+		require.NoError(t, err)
+
+		converted, err := ToCycloneDX(doc)
+		require.NoError(t, err)
+		assertEquivalent(t, bom, converted)
+	})
+}
+
+// assertEquivalent compares the parts of the BOMs that are kept when converting to and from SPDX
+func assertEquivalent(t *testing.T, expected *cdx.BOM, actual *cdx.BOM) {
+	t.Helper()
+
+	require.NotNil(t, actual.Metadata.Component)
+	assert.Equal(t, expected.Metadata.Component.Name, actual.Metadata.Component.Name)
+	assert.Equal(t, expected.Metadata.Component.Version, actual.Metadata.Component.Version)
+
+	type summary struct {
+		Name, Group, Version, PackageURL, CPE string
+		Scope                                 cdx.Scope
+		Licenses                              cdx.Licenses
+		Hashes                                []cdx.Hash
+	}
+
+	summarize := func(bom *cdx.BOM) map[string]summary {
+		result := map[string]summary{}
+		for _, c := range *bom.Components {
+			s := summary{Name: c.Name, Group: c.Group, Version: c.Version, PackageURL: c.PackageURL, CPE: c.CPE, Scope: c.Scope}
+			if c.Licenses != nil {
+				s.Licenses = *c.Licenses
+			}
+			if c.Hashes != nil {
+				s.Hashes = *c.Hashes
+			}
+			result[c.PackageURL] = s
+		}
+		return result
+	}
+
+	assert.Equal(t, summarize(expected), summarize(actual))
+
+	// dependencies by purl (the root component is referenced by name)
+	edges := func(bom *cdx.BOM) []string {
+		names := map[string]string{bom.Metadata.Component.BOMRef: "root"}
+		for _, c := range *bom.Components {
+			names[c.BOMRef] = c.PackageURL
+		}
+
+		var result []string
+		for _, d := range *bom.Dependencies {
+			for _, ref := range *d.Dependencies {
+				result = append(result, names[d.Ref]+" -> "+names[ref])
+			}
+		}
+		return result
+	}
+
+	assert.ElementsMatch(t, edges(expected), edges(actual))
+}
@@ -0,0 +1,238 @@
+package spdxutil
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/package-url/packageurl-go"
+	"github.com/sbom-observer/observer-cli/pkg/ids"
+	"github.com/spdx/tools-golang/spdx/v2/common"
+	"github.com/spdx/tools-golang/spdx/v2/v2_3"
+)
+
+// ToCycloneDX converts an SPDX 2.3 document to a CycloneDX BOM. The package described by the document becomes the
+// root component, other packages become (flat) components and DEPENDS_ON/DEPENDENCY_OF/DEV_DEPENDENCY_OF relationships
+// become dependencies. Packages are referenced by purl if they have one, otherwise by their SPDX id.
+func ToCycloneDX(doc *v2_3.Document) (*cdx.BOM, error) {
+	if doc == nil {
+		return nil, fmt.Errorf("no SPDX document to convert")
+	}
+
+	bom := cdx.NewBOM()
+	bom.SerialNumber = "urn:uuid:" + ids.NextUUID()
+	bom.Metadata = &cdx.Metadata{}
+
+	if doc.CreationInfo != nil {
+		bom.Metadata.Timestamp = doc.CreationInfo.Created
+
+		var tools []cdx.Component
+		var authors []cdx.OrganizationalContact
+		for _, creator := range doc.CreationInfo.Creators {
+			switch creator.CreatorType {
+			case "Tool":
+				tools = append(tools, cdx.Component{Type: cdx.ComponentTypeApplication, Name: creator.Creator})
+			case "Person":
+				authors = append(authors, cdx.OrganizationalContact{Name: creator.Creator})
+			case "Organization":
+				bom.Metadata.Manufacturer = &cdx.OrganizationalEntity{Name: creator.Creator}
+			}
+		}
+
+		if len(tools) > 0 {
+			bom.Metadata.Tools = &cdx.ToolsChoice{Components: &tools}
+		}
+		if len(authors) > 0 {
+			bom.Metadata.Authors = &authors
+		}
+	}
+
+	otherLicenses := map[string]string{}
+	for _, license := range doc.OtherLicenses {
+		name := license.LicenseName
+		if name == "" || name == NoAssertion {
+			name = license.ExtractedText
+		}
+		otherLicenses[license.LicenseIdentifier] = name
+	}
+
+	// packages
+	refs := map[common.ElementID]string{}
+	usedRefs := map[string]bool{}
+	components := map[common.ElementID]cdx.Component{}
+	for _, pkg := range doc.Packages {
+		component := toComponent(pkg, otherLicenses)
+
+		component.BOMRef = component.PackageURL
+		if component.BOMRef == "" || usedRefs[component.BOMRef] {
+			component.BOMRef = string(pkg.PackageSPDXIdentifier)
+		}
+		usedRefs[component.BOMRef] = true
+
+		refs[pkg.PackageSPDXIdentifier] = component.BOMRef
+		components[pkg.PackageSPDXIdentifier] = component
+	}
+
+	// relationships
+	var root common.ElementID
+	dependencies := map[string][]string{}
+	var order []string
+
+	addDependency := func(from common.DocElementID, to common.DocElementID) {
+		fromRef, fromFound := refs[from.ElementRefID]
+		toRef, toFound := refs[to.ElementRefID]
+		if !fromFound || !toFound || from.DocumentRefID != "" || to.DocumentRefID != "" {
+			return
+		}
+
+		if _, found := dependencies[fromRef]; !found {
+			order = append(order, fromRef)
+		}
+		if !slices.Contains(dependencies[fromRef], toRef) {
+			dependencies[fromRef] = append(dependencies[fromRef], toRef)
+		}
+	}
+
+	for _, relationship := range doc.Relationships {
+		switch relationship.Relationship {
+		case common.TypeRelationshipDescribe:
+			if relationship.RefA.ElementRefID == doc.SPDXIdentifier && root == "" {
+				root = relationship.RefB.ElementRefID
+			}
+		case common.TypeRelationshipDescribeBy:
+			if relationship.RefB.ElementRefID == doc.SPDXIdentifier && root == "" {
+				root = relationship.RefA.ElementRefID
+			}
+		case common.TypeRelationshipDependsOn:
+			addDependency(relationship.RefA, relationship.RefB)
+		case common.TypeRelationshipDependencyOf:
+			addDependency(relationship.RefB, relationship.RefA)
+		case common.TypeRelationshipDevDependencyOf:
+			addDependency(relationship.RefB, relationship.RefA)
+
+			if component, found := components[relationship.RefA.ElementRefID]; found {
+				component.Scope = cdx.ScopeExcluded
+				components[relationship.RefA.ElementRefID] = component
+			}
+		}
+	}
+
+	if component, found := components[root]; found {
+		bom.Metadata.Component = &component
+	}
+
+	var result []cdx.Component
+	for _, pkg := range doc.Packages {
+		if pkg.PackageSPDXIdentifier != root {
+			result = append(result, components[pkg.PackageSPDXIdentifier])
+		}
+	}
+	bom.Components = &result
+
+	var deps []cdx.Dependency
+	for _, ref := range order {
+		dependsOn := dependencies[ref]
+		deps = append(deps, cdx.Dependency{Ref: ref, Dependencies: &dependsOn})
+	}
+	if len(deps) > 0 {
+		bom.Dependencies = &deps
+	}
+
+	return bom, nil
+}
+
+func toComponent(pkg *v2_3.Package, otherLicenses map[string]string) cdx.Component {
+	component := cdx.Component{
+		Type:        componentType(pkg.PrimaryPackagePurpose),
+		Name:        pkg.PackageName,
+		Version:     pkg.PackageVersion,
+		Description: pkg.PackageDescription,
+	}
+
+	if isAssertion(pkg.PackageCopyrightText) {
+		component.Copyright = pkg.PackageCopyrightText
+	}
+
+	if pkg.PackageSupplier != nil && isAssertion(pkg.PackageSupplier.Supplier) {
+		component.Supplier = &cdx.OrganizationalEntity{Name: pkg.PackageSupplier.Supplier}
+	}
+
+	if pkg.PackageOriginator != nil && isAssertion(pkg.PackageOriginator.Originator) {
+		component.Author = pkg.PackageOriginator.Originator
+	}
+
+	var hashes []cdx.Hash
+	for _, checksum := range pkg.PackageChecksums {
+		for algorithm, spdxAlgorithm := range checksumAlgorithms {
+			if spdxAlgorithm == checksum.Algorithm {
+				hashes = append(hashes, cdx.Hash{Algorithm: algorithm, Value: checksum.Value})
+			}
+		}
+	}
+	if len(hashes) > 0 {
+		component.Hashes = &hashes
+	}
+
+	for _, ref := range pkg.PackageExternalReferences {
+		switch {
+		case ref.RefType == common.TypePackageManagerPURL && component.PackageURL == "":
+			component.PackageURL = ref.Locator
+
+			// SPDX doesn't have a group, restore it from the purl
+			if purl, err := packageurl.FromString(ref.Locator); err == nil && purl.Namespace != "" {
+				component.Group = purl.Namespace
+			}
+		case (ref.RefType == common.TypeSecurityCPE23Type || ref.RefType == common.TypeSecurityCPE22Type) && component.CPE == "":
+			component.CPE = ref.Locator
+		}
+	}
+
+	var externalReferences []cdx.ExternalReference
+	if pkg.PackageHomePage != "" && isAssertion(pkg.PackageHomePage) {
+		externalReferences = append(externalReferences, cdx.ExternalReference{Type: cdx.ERTypeWebsite, URL: pkg.PackageHomePage})
+	}
+	if isAssertion(pkg.PackageDownloadLocation) {
+		externalReferences = append(externalReferences, cdx.ExternalReference{Type: cdx.ERTypeDistribution, URL: pkg.PackageDownloadLocation})
+	}
+	if len(externalReferences) > 0 {
+		component.ExternalReferences = &externalReferences
+	}
+
+	expression := pkg.PackageLicenseDeclared
+	if !isAssertion(expression) {
+		expression = pkg.PackageLicenseConcluded
+	}
+	if isAssertion(expression) {
+		component.Licenses = toLicenses(expression, otherLicenses)
+	}
+
+	return component
+}
+
+// toLicenses converts an SPDX license expression to CycloneDX licenses. Simple expressions (ids joined with AND)
+// are converted to a list of licenses, anything else is kept as an expression.
+func toLicenses(expression string, otherLicenses map[string]string) *cdx.Licenses {
+	var licenses cdx.Licenses
+
+	for _, part := range strings.Split(expression, " AND ") {
+		part = strings.TrimSpace(part)
+
+		if strings.ContainsAny(part, " ()") {
+			return &cdx.Licenses{{Expression: expression}}
+		}
+
+		if name, found := otherLicenses[part]; found {
+			licenses = append(licenses, cdx.LicenseChoice{License: &cdx.License{Name: name}})
+		} else {
+			licenses = append(licenses, cdx.LicenseChoice{License: &cdx.License{ID: part}})
+		}
+	}
+
+	return &licenses
+}
+
+// isAssertion returns false for empty, NOASSERTION and NONE values
+func isAssertion(value string) bool {
+	return value != "" && value != NoAssertion && value != "NONE"
+}
@@ -0,0 +1,35 @@
+package spdxutil
+
+import (
+	"io"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	spdxjson "github.com/spdx/tools-golang/json"
+	"github.com/spdx/tools-golang/tagvalue"
+)
+
+// EncodeJSON converts the BOM to SPDX 2.3 and writes it as JSON
+func EncodeJSON(w io.Writer, bom *cdx.BOM, pretty bool) error {
+	doc, err := FromCycloneDX(bom)
+	if err != nil {
+		return err
+	}
+
+	var options []spdxjson.WriteOption
+	if pretty {
+		options = append(options, spdxjson.Indent("  "))
+	}
+	options = append(options, spdxjson.EscapeHTML(false))
+
+	return spdxjson.Write(doc, w, options...)
+}
+
+// EncodeTagValue converts the BOM to SPDX 2.3 and writes it in the tag-value format
+func EncodeTagValue(w io.Writer, bom *cdx.BOM) error {
+	doc, err := FromCycloneDX(bom)
+	if err != nil {
+		return err
+	}
+
+	return tagvalue.Write(doc, w)
+}
@@ -1,14 +1,20 @@
 package tasks
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
 	"slices"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/sbom-observer/observer-cli/pkg/cache"
 	"github.com/sbom-observer/observer-cli/pkg/files"
 	"github.com/sbom-observer/observer-cli/pkg/ids"
 	"github.com/sbom-observer/observer-cli/pkg/log"
@@ -17,11 +23,59 @@ import (
 	"github.com/sbom-observer/observer-cli/pkg/types"
 )
 
-func CreateFilesystemSBOM(paths []string, vendorPaths []string, flagDepth uint, flagMerge bool, flagArtifacts []string) ([]*cdx.BOM, error) {
+// FilesystemOptions controls how CreateFilesystemSBOM finds, scans and merges targets
+type FilesystemOptions struct {
+	Depth     uint
+	Merge     bool
+	Artifacts []string
+	// Parallel is the maximum number of targets scanned at the same time (values < 1 means 1)
+	Parallel int
+	// IncludeDev keeps development and test dependencies (can be overridden per target in observer.yml)
+	IncludeDev bool
+	// KeepGoing continues scanning when a scanner fails and returns partial results together with ErrPartialResults
+	KeepGoing bool
+	// Scanners overrides the scanner chain per ecosystem (takes precedence over observer.yml)
+	Scanners map[string][]string
+	// NoCache disables reusing (and storing) scan results in the user cache directory
+	NoCache bool
+	// ScannerTimeout limits how long a single scanner can run for a target (0 means no limit)
+	ScannerTimeout time.Duration
+	// ScannerTimeouts overrides ScannerTimeout per scanner id
+	ScannerTimeouts map[string]time.Duration
+	// Reproducible makes the output depend only on the scanned content (see cdxutil.MakeReproducible)
+	Reproducible bool
+	// Super nests each merged target under the root component instead of adding it as a dependency (see mergex.MergeBomAsSubtree)
+	Super bool
+	// Link returns the root target as a product BOM with BOM-Links to the BOMs of the other targets instead of merging them
+	Link bool
+	// AllowExternalScanners runs the external scanners from observer.yml in the scanned paths (but not in their
+	// subdirectories or the vendor paths) and ExternalScanners. External scanners run arbitrary commands.
+	AllowExternalScanners bool
+	// ExternalScanners are external scanners from a config file given on the command line (requires AllowExternalScanners)
+	ExternalScanners []types.ExternalScannerConfig
+}
+
+// scannerTimeout returns the timeout for a scanner (0 means no limit)
+func (o FilesystemOptions) scannerTimeout(id string) time.Duration {
+	if timeout, found := o.ScannerTimeouts[id]; found {
+		return timeout
+	}
+	return o.ScannerTimeout
+}
+
+// ErrPartialResults is returned (wrapped) together with the results when one or more scanners failed in keep-going mode
+var ErrPartialResults = errors.New("one or more scan targets failed")
+
+// ErrScannerTimeout is returned (wrapped) when a scanner exceeds its timeout. The target is reported as incomplete.
+var ErrScannerTimeout = errors.New("scanner timed out")
+
+func CreateFilesystemSBOM(ctx context.Context, paths []string, vendorPaths []string, options FilesystemOptions) ([]*cdx.BOM, *ScanReport, error) {
 	if len(paths) < 1 {
 		log.Fatal("the path to a source repository is required as an argument")
 	}
 
+	startedAt := time.Now()
+
 	// find targets
 	var targets []*scanner.ScanTarget
 	{
@@ -33,15 +87,29 @@ func CreateFilesystemSBOM(paths []string, vendorPaths []string, flagDepth uint,
 
 		pathToTarget := map[string]*scanner.ScanTarget{}
 		for _, arg := range paths {
-			depth := flagDepth
+			depth := options.Depth
 
 			// scan vendor paths recursively - with depth 2 (./vendored/openssl/observer.yml etc.)
 			if vendorPathsSet.Contains(arg) {
 				depth = 2
 			}
 
+			// only external scanners from trusted config files (see scanner.ReadExternalScanners), the command
+			// line takes precedence over observer.yml (the first scanner with a name is used)
+			var externalScanners []types.ExternalScannerConfig
+			if options.AllowExternalScanners {
+				externalScanners = append(externalScanners, options.ExternalScanners...)
+				if !vendorPathsSet.Contains(arg) {
+					root, err := scanner.ReadRootExternalScanners(arg)
+					if err != nil {
+						log.Fatal("failed to read external scanners", "path", arg, "err", err)
+					}
+					externalScanners = append(externalScanners, root...)
+				}
+			}
+
 			log.Debugf("finding scan targets in %s (depth %d)", arg, depth)
-			ts, err := scanner.FindScanTargets(arg, depth)
+			ts, err := scanner.FindScanTargets(arg, depth, externalScanners)
 			if err != nil {
 				log.Fatal("failed to find scan targets", "err", err)
 			}
@@ -56,6 +124,11 @@ func CreateFilesystemSBOM(paths []string, vendorPaths []string, flagDepth uint,
 					for file, ecosystem := range existingTarget.Files {
 						target.Files[file] = ecosystem
 					}
+					for _, external := range existingTarget.ExternalScanners {
+						if !slices.ContainsFunc(target.ExternalScanners, func(c types.ExternalScannerConfig) bool { return c.Name == external.Name }) {
+							target.ExternalScanners = append(target.ExternalScanners, external)
+						}
+					}
 				}
 
 				pathToTarget[path] = target
@@ -63,13 +136,15 @@ func CreateFilesystemSBOM(paths []string, vendorPaths []string, flagDepth uint,
 		}
 
 		for _, target := range pathToTarget {
+			// a new value for every target, observer.yml is loaded into target.Config
+			includeDev := options.IncludeDev
+			target.Config.IncludeDevDependencies = &includeDev
+			target.Config.Scanners = maps.Clone(options.Scanners)
 			targets = append(targets, target)
 		}
 
 		// sort targets to make scanning be deterministic
-		sort.Slice(targets, func(i, j int) bool {
-			return len(targets[i].Path) < len(targets[j].Path)
-		})
+		sortTargets(targets)
 	}
 
 	// TODO: remove
@@ -82,48 +157,32 @@ func CreateFilesystemSBOM(paths []string, vendorPaths []string, flagDepth uint,
 	}
 
 	// scan targets
-	for _, target := range targets {
-		log.Infof("Generating SBOM for '%s'", target.Path)
-		log.Debug("Generating SBOM", "path", target.Path, "target", target.Files)
-
-		for _, filesystemScanner := range scanner.ScannersForTarget(*target) {
-			log.Debug("running scanner", "id", filesystemScanner.Id())
-			err := filesystemScanner.Scan(target)
-			if err != nil {
-				log.Fatal("failed to create SBOM for repository", "path", target.Path, "err", err)
-			}
-		}
-
-		// fallback to directory name if no name is set
-		if target.Config.Component.Name == "" {
-			target.Config.Component.Name = filepath.Base(target.Path)
-		}
-
-		// merge results and add metadata
-		target.Merged = mergex.MergeBoms(target.Results)
-
-		// a target should always produce a BOM
-		if target.Merged == nil {
-			target.Merged = cdx.NewBOM()
-			target.Merged.Metadata = &cdx.Metadata{
-				Component: &cdx.Component{
-					BOMRef: ids.NextUUID(),
-					Type:   cdx.ComponentTypeApplication,
-				},
-			}
-			target.Merged.Components = &[]cdx.Component{}
+	// reuse results for targets that haven't changed since the last scan
+	var scanCache *cache.Cache
+	if !options.NoCache {
+		dir, err := cache.DefaultDir()
+		if err != nil {
+			log.Warn("scan cache disabled, failed to find the user cache directory", "err", err)
+		} else {
+			log.Debug("using scan cache", "dir", dir)
+			scanCache = cache.New(dir)
 		}
+	}
 
-		// apply any overrides from config
-		applyConfiguration(target.Config, target.Merged)
+	scanErr := scanTargets(ctx, targets, scanCache, options)
+	report := NewScanReport(paths, targets, startedAt)
+	if scanErr != nil && !errors.Is(scanErr, ErrPartialResults) {
+		return nil, report, scanErr
+	}
 
-		log.Debugf("merged %d BOMs for target %s -> %s@%s", len(target.Results), target.Path, target.Merged.Metadata.Component.Name, target.Merged.Metadata.Component.Version)
+	if types.Offline {
+		logOfflineSummary(targets)
 	}
 
-	// TODO: implement --merge flag (and --merge-with-ref or --super)
+	var results []*cdx.BOM
 
 	// merge to single file
-	if flagMerge {
+	if options.Merge {
 		var rootPath string
 		var targetsToMerge []*scanner.ScanTarget
 
@@ -137,9 +196,7 @@ func CreateFilesystemSBOM(paths []string, vendorPaths []string, flagDepth uint,
 		}
 
 		// sort targets by path length
-		sort.Slice(targetsToMerge, func(i, j int) bool {
-			return len(targetsToMerge[i].Path) < len(targetsToMerge[j].Path)
-		})
+		sortTargets(targetsToMerge)
 
 		var boms []*cdx.BOM
 		for _, target := range targetsToMerge {
@@ -147,7 +204,16 @@ func CreateFilesystemSBOM(paths []string, vendorPaths []string, flagDepth uint,
 		}
 
 		// merge the BOMs for all targets
-		merged := mergex.MergeBomsAsDependency(boms)
+		var merged *cdx.BOM
+		switch {
+		case options.Link:
+			// the other targets are linked from the root target below
+			merged = mergex.MergeBoms(boms[:1])
+		case options.Super:
+			merged = mergex.MergeBomsAsSubtree(boms)
+		default:
+			merged = mergex.MergeBomsAsDependency(boms)
+		}
 
 		// apply any overrides from the target config
 		applyConfiguration(targetsToMerge[0].Config, merged)
@@ -155,8 +221,8 @@ func CreateFilesystemSBOM(paths []string, vendorPaths []string, flagDepth uint,
 		log.Debugf("merged %d BOMs to %s %s", len(boms), merged.Metadata.Component.Name, merged.Metadata.Component.Version)
 
 		// add artifacts to the merged BOM
-		if len(flagArtifacts) > 0 {
-			artifacts, err := scanArtifacts(flagArtifacts)
+		if len(options.Artifacts) > 0 {
+			artifacts, err := scanArtifacts(options.Artifacts)
 			if err != nil {
 				log.Fatal("failed to scan artifacts", "err", err)
 			}
@@ -177,20 +243,297 @@ func CreateFilesystemSBOM(paths []string, vendorPaths []string, flagDepth uint,
 			}
 		}
 
-		return []*cdx.BOM{merged}, nil
+		if options.Link {
+			results, err := linkTargets(paths[0], rootPath, merged, targetsToMerge[1:], options.Reproducible)
+			if err != nil {
+				return nil, report, err
+			}
+			return results, report, scanErr
+		}
+
+		results = []*cdx.BOM{merged}
+	} else {
+		// return all results
+		for _, target := range targets {
+			if target.Merged != nil {
+				results = append(results, target.Merged)
+			} else {
+				results = append(results, target.Results...)
+			}
+		}
 	}
 
-	// return all results
-	var results []*cdx.BOM
+	if options.Reproducible {
+		if err := makeReproducible(paths[0], results); err != nil {
+			return nil, report, err
+		}
+	}
+
+	return results, report, scanErr
+}
+
+// scanTargets runs the scanners for each target using a bounded pool of workers. Each target is
+// scanned and merged independently and the results are stored on the target itself, so the
+// content of the output does not depend on the order in which the workers finish. Random BOMRefs,
+// serial numbers and timestamps still differ between runs, see makeReproducible.
+// In keep-going mode failed targets still produce a (partial) BOM and the returned error wraps ErrPartialResults,
+// unless no target produced any results at all. Targets where scanners timed out are always reported as partial results.
+func scanTargets(ctx context.Context, targets []*scanner.ScanTarget, scanCache *cache.Cache, options FilesystemOptions) error {
+	parallel := options.Parallel
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	if parallel > len(targets) {
+		parallel = len(targets)
+	}
+
+	log.Debugf("scanning %d targets with %d workers", len(targets), parallel)
+
+	errs := make([]error, len(targets))
+	work := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < parallel; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				errs[i] = scanTarget(ctx, targets[i], scanCache, options)
+			}
+		}()
+	}
+
+	for i := range targets {
+		work <- i
+	}
+	close(work)
+
+	wg.Wait()
+
+	// cancelled (i.e. SIGINT) or the global timeout was exceeded
+	if ctx.Err() != nil {
+		return fmt.Errorf("scan was stopped: %w", ctx.Err())
+	}
+
+	// report failures in target order
+	var failures []error
+	hasResults := false
+	for i, err := range errs {
+		if err != nil {
+			log.Error("failed to create SBOM for repository", "path", targets[i].Path, "err", err)
+			failures = append(failures, fmt.Errorf("%s: %w", targets[i].Path, err))
+		}
+		if len(targets[i].Results) > 0 {
+			hasResults = true
+		}
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+
+	joined := errors.Join(failures...)
+	if (options.KeepGoing && hasResults) || isTimeout(joined) {
+		return fmt.Errorf("%w: %w", ErrPartialResults, joined)
+	}
+
+	return joined
+}
+
+// isTimeout returns true if err only contains scanner timeouts
+func isTimeout(err error) bool {
+	if err == ErrScannerTimeout {
+		return true
+	}
+
+	switch e := err.(type) {
+	case interface{ Unwrap() []error }:
+		for _, inner := range e.Unwrap() {
+			if !isTimeout(inner) {
+				return false
+			}
+		}
+		return len(e.Unwrap()) > 0
+	case interface{ Unwrap() error }:
+		return isTimeout(e.Unwrap())
+	}
+
+	return false
+}
+
+// scanTarget runs all scanners for a single target and merges the results into target.Merged.
+// In keep-going mode the remaining scanners are run after a failure and the gap is recorded
+// in the compositions of the merged BOM. Scanners that time out are always treated this way.
+func scanTarget(ctx context.Context, target *scanner.ScanTarget, scanCache *cache.Cache, options FilesystemOptions) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	keepGoing := options.KeepGoing
+
+	log.Infof("Generating SBOM for '%s'", target.Path)
+	log.Debug("Generating SBOM", "path", target.Path, "target", target.Files)
+
+	var scanErrs []error
+
+	scanners, err := scanner.ScannersForTarget(*target)
+	if err != nil {
+		err = fmt.Errorf("failed to select scanners: %w", err)
+		if !keepGoing {
+			return err
+		}
+
+		log.Warn("failed to select scanners, continuing with partial results", "path", target.Path, "err", err)
+		scanErrs = append(scanErrs, err)
+	}
+
+	for _, filesystemScanner := range scanners {
+		log.Debug("running scanner", "id", filesystemScanner.Id(), "path", target.Path)
+		err := runScannerWithTimeout(ctx, filesystemScanner, target, scanCache, options.scannerTimeout(filesystemScanner.Id()))
+		if err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+
+			timedOut := errors.Is(err, ErrScannerTimeout)
+			if !timedOut {
+				err = fmt.Errorf("scanner '%s' failed: %w", filesystemScanner.Id(), err)
+			}
+
+			if !keepGoing && !timedOut {
+				return err
+			}
+
+			log.Warn("scanner failed, continuing with partial results", "path", target.Path, "scanner", filesystemScanner.Id(), "err", err)
+			scanErrs = append(scanErrs, err)
+		}
+	}
+
+	// fallback to directory name if no name is set
+	if target.Config.Component.Name == "" {
+		target.Config.Component.Name = filepath.Base(target.Path)
+	}
+
+	// merge results and add metadata
+	target.Merged = mergex.MergeBoms(target.Results)
+
+	// a target should always produce a BOM, with a root component (a wrapper that should be inlined is dissolved into
+	// it, and external scanners don't always set one)
+	if target.Merged == nil || target.Merged.Metadata == nil || target.Merged.Metadata.Component == nil || mergex.IsInline(target.Merged) {
+		root := cdx.NewBOM()
+		root.Metadata = &cdx.Metadata{
+			Component: &cdx.Component{
+				BOMRef: ids.NextUUID(),
+				Type:   cdx.ComponentTypeApplication,
+			},
+		}
+		root.Components = &[]cdx.Component{}
+		target.Merged = mergex.MergeBom(root, target.Merged)
+	}
+	mergex.StripDirectives(target.Merged)
+
+	// apply any overrides from config
+	applyConfiguration(target.Config, target.Merged)
+
+	// record that the BOM for the target is not complete
+	if len(scanErrs) > 0 {
+		addIncompleteComposition(target.Merged, len(target.Results) > 0)
+	}
+
+	log.Debugf("merged %d BOMs for target %s -> %s@%s", len(target.Results), target.Path, target.Merged.Metadata.Component.Name, target.Merged.Metadata.Component.Version)
+
+	return errors.Join(scanErrs...)
+}
+
+// runScannerWithTimeout runs a scanner, stops it after timeout (0 means no limit) and records the run on the target
+func runScannerWithTimeout(ctx context.Context, s scanner.RepoScanner, target *scanner.ScanTarget, scanCache *cache.Cache, timeout time.Duration) error {
+	scannerCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		scannerCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	cached, err := runScanner(scannerCtx, s, target, scanCache)
+
+	run := scanner.ScannerRun{
+		Id:       s.Id(),
+		Duration: time.Since(start),
+		Cached:   cached,
+		Err:      err,
+	}
+
+	if err != nil && ctx.Err() == nil && errors.Is(scannerCtx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("scanner '%s' timed out after %s: %w", s.Id(), timeout, ErrScannerTimeout)
+		run.Err = err
+		run.TimedOut = true
+	}
+
+	target.Runs = append(target.Runs, run)
+
+	return err
+}
+
+// addIncompleteComposition marks the root component of the BOM as incomplete (some scanners failed)
+// or unknown (no scanner produced any results)
+func addIncompleteComposition(bom *cdx.BOM, hasResults bool) {
+	if bom.Metadata == nil || bom.Metadata.Component == nil || bom.Metadata.Component.BOMRef == "" {
+		return
+	}
+
+	aggregate := cdx.CompositionAggregateIncomplete
+	if !hasResults {
+		aggregate = cdx.CompositionAggregateUnknown
+	}
+
+	rootRef := cdx.BOMReference(bom.Metadata.Component.BOMRef)
+
+	if bom.Compositions == nil {
+		bom.Compositions = &[]cdx.Composition{}
+	}
+
+	*bom.Compositions = append(*bom.Compositions, cdx.Composition{
+		BOMRef:       ids.NextUUID(),
+		Aggregate:    aggregate,
+		Assemblies:   &[]cdx.BOMReference{rootRef},
+		Dependencies: &[]cdx.BOMReference{rootRef},
+	})
+}
+
+// logOfflineSummary reports the ecosystems that were scanned with reduced accuracy because scanner plugins
+// (or Trivy's dependency lookups) that require network access were skipped
+func logOfflineSummary(targets []*scanner.ScanTarget) {
+	ecosystems := map[string][]string{}
 	for _, target := range targets {
-		if target.Merged != nil {
-			results = append(results, target.Merged)
-		} else {
-			results = append(results, target.Results...)
+		for _, name := range target.OfflineSkipped {
+			// plugin names are prefixed with the ecosystem (i.e. "java/pomxmlnet" or "java/trivy")
+			ecosystem, _, _ := strings.Cut(name, "/")
+			if !slices.Contains(ecosystems[ecosystem], name) {
+				ecosystems[ecosystem] = append(ecosystems[ecosystem], name)
+			}
 		}
 	}
 
-	return results, nil
+	if len(ecosystems) == 0 {
+		log.Debug("offline mode: no scanner plugins were skipped")
+		return
+	}
+
+	for _, ecosystem := range slices.Sorted(maps.Keys(ecosystems)) {
+		log.Warn("offline mode: reduced accuracy (scanner plugins and lookups requiring network access were skipped)", "ecosystem", ecosystem, "plugins", strings.Join(ecosystems[ecosystem], ","))
+	}
+}
+
+// sortTargets sorts targets by path length (i.e. parents before children) and then by path
+func sortTargets(targets []*scanner.ScanTarget) {
+	sort.Slice(targets, func(i, j int) bool {
+		if len(targets[i].Path) != len(targets[j].Path) {
+			return len(targets[i].Path) < len(targets[j].Path)
+		}
+		return targets[i].Path < targets[j].Path
+	})
 }
 
 func applyConfiguration(config types.ScanConfig, merged *cdx.BOM) {
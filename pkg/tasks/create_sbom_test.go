@@ -1,11 +1,18 @@
 package tasks
 
 import (
+	"bytes"
+	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/sbom-observer/observer-cli/pkg/cdxutil"
+	"github.com/sbom-observer/observer-cli/pkg/scanner"
+	"github.com/sbom-observer/observer-cli/pkg/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -141,3 +148,223 @@ func TestScanArtifacts(t *testing.T) {
 		})
 	}
 }
+
+func TestScanTargets(t *testing.T) {
+	tempDir := t.TempDir()
+
+	var paths []string
+	for _, name := range []string{"service-b", "service-a", "service-c", "service-d", "lib"} {
+		path := filepath.Join(tempDir, name)
+		require.NoError(t, os.Mkdir(path, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(path, "observer.yml"), []byte("component:\n  name: "+name+"\n"), 0644))
+		paths = append(paths, path)
+	}
+
+	brokenPath := filepath.Join(tempDir, "broken")
+	require.NoError(t, os.Mkdir(brokenPath, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(brokenPath, "observer.yml"), []byte("component: [\n"), 0644))
+
+	newTargets := func(paths ...string) []*scanner.ScanTarget {
+		var targets []*scanner.ScanTarget
+		for _, path := range paths {
+			targets = append(targets, &scanner.ScanTarget{
+				Path:  path,
+				Files: map[string]scanner.Ecosystem{"observer.yml": scanner.EcosystemObserver},
+			})
+		}
+		sortTargets(targets)
+		return targets
+	}
+
+	names := func(targets []*scanner.ScanTarget) []string {
+		var names []string
+		for _, target := range targets {
+			names = append(names, target.Merged.Metadata.Component.Name)
+		}
+		return names
+	}
+
+	t.Run("parallel results match sequential results", func(t *testing.T) {
+		sequential := newTargets(paths...)
+		require.NoError(t, scanTargets(context.Background(), sequential, nil, FilesystemOptions{Parallel: 1}))
+
+		parallel := newTargets(paths...)
+		require.NoError(t, scanTargets(context.Background(), parallel, nil, FilesystemOptions{Parallel: 4}))
+
+		assert.Equal(t, []string{"lib", "service-a", "service-b", "service-c", "service-d"}, names(sequential))
+		assert.Equal(t, names(sequential), names(parallel))
+	})
+
+	t.Run("failure is reported against the target", func(t *testing.T) {
+		targets := newTargets(append(paths, brokenPath)...)
+
+		err := scanTargets(context.Background(), targets, nil, FilesystemOptions{Parallel: 3})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), brokenPath)
+
+		// other targets are still scanned
+		for _, target := range targets {
+			if target.Path != brokenPath {
+				assert.NotNil(t, target.Merged, target.Path)
+			}
+		}
+	})
+}
+
+func TestScanTargets_ParallelPackages(t *testing.T) {
+	tempDir := t.TempDir()
+
+	var paths []string
+	for _, dependency := range []string{"github.com/google/uuid v1.6.0", "github.com/spf13/cobra v1.9.1", "gopkg.in/yaml.v3 v3.0.1", "github.com/stretchr/testify v1.10.0"} {
+		module, _, _ := strings.Cut(dependency, " ")
+		path := filepath.Join(tempDir, filepath.Base(module))
+		require.NoError(t, os.Mkdir(path, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(path, "go.mod"), []byte("module example.com/"+filepath.Base(path)+"\n\ngo 1.22\n\nrequire "+dependency+"\n"), 0644))
+		paths = append(paths, path)
+	}
+
+	// scans the targets and returns the reproducible output of every target
+	scan := func(parallel int) []string {
+		var targets []*scanner.ScanTarget
+		for _, path := range paths {
+			targets = append(targets, &scanner.ScanTarget{Path: path, Files: map[string]scanner.Ecosystem{"go.mod": scanner.EcosystemGo}})
+		}
+		sortTargets(targets)
+
+		require.NoError(t, scanTargets(context.Background(), targets, nil, FilesystemOptions{Parallel: parallel}))
+
+		var outputs []string
+		for _, target := range targets {
+			require.NotNil(t, target.Merged, target.Path)
+			require.NotNil(t, target.Merged.Components, target.Path)
+			assert.NotEmpty(t, *target.Merged.Components, target.Path)
+
+			require.NoError(t, cdxutil.MakeReproducible(target.Merged, time.Unix(1700000000, 0)))
+
+			var buf bytes.Buffer
+			require.NoError(t, cdx.NewBOMEncoder(&buf, cdx.BOMFileFormatJSON).Encode(target.Merged))
+			outputs = append(outputs, buf.String())
+		}
+		return outputs
+	}
+
+	sequential := scan(1)
+	assert.Equal(t, sequential, scan(4))
+	assert.Equal(t, sequential, scan(2))
+}
+
+func TestScanTargets_KeepGoing(t *testing.T) {
+	tempDir := t.TempDir()
+
+	goPath := filepath.Join(tempDir, "go-app")
+	require.NoError(t, os.Mkdir(goPath, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(goPath, "go.mod"), []byte("module example.com/app\n\ngo 1.22\n\nrequire github.com/google/uuid v1.6.0\n"), 0644))
+
+	brokenPath := filepath.Join(tempDir, "broken")
+	require.NoError(t, os.Mkdir(brokenPath, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(brokenPath, "observer.yml"), []byte("component: [\n"), 0644))
+
+	targets := []*scanner.ScanTarget{
+		{Path: goPath, Files: map[string]scanner.Ecosystem{"go.mod": scanner.EcosystemGo}},
+		{Path: brokenPath, Files: map[string]scanner.Ecosystem{"observer.yml": scanner.EcosystemObserver}},
+	}
+	sortTargets(targets)
+
+	err := scanTargets(context.Background(), targets, nil, FilesystemOptions{Parallel: 2, KeepGoing: true})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrPartialResults)
+	assert.Contains(t, err.Error(), brokenPath)
+
+	for _, target := range targets {
+		require.NotNil(t, target.Merged, target.Path)
+
+		if target.Path == goPath {
+			assert.Nil(t, target.Merged.Compositions)
+			continue
+		}
+
+		require.NotNil(t, target.Merged.Compositions)
+		require.Len(t, *target.Merged.Compositions, 1)
+
+		composition := (*target.Merged.Compositions)[0]
+		assert.Equal(t, cdx.CompositionAggregateUnknown, composition.Aggregate)
+		assert.Equal(t, []cdx.BOMReference{cdx.BOMReference(target.Merged.Metadata.Component.BOMRef)}, *composition.Assemblies)
+	}
+}
+
+func TestScanTargets_Timeout(t *testing.T) {
+	newTarget := func() *scanner.ScanTarget {
+		return &scanner.ScanTarget{
+			Path:  t.TempDir(),
+			Files: map[string]scanner.Ecosystem{"build.acme": scanner.EcosystemExternal},
+			ExternalScanners: []types.ExternalScannerConfig{
+				{Name: "slow", Command: []string{"sleep", "30"}, Files: []string{"*.acme"}},
+			},
+		}
+	}
+
+	t.Run("scanner timeout", func(t *testing.T) {
+		targets := []*scanner.ScanTarget{newTarget()}
+
+		start := time.Now()
+		err := scanTargets(context.Background(), targets, nil, FilesystemOptions{ScannerTimeouts: map[string]time.Duration{"external:slow": 100 * time.Millisecond}})
+		assert.Less(t, time.Since(start), 10*time.Second)
+
+		// timed out targets are reported as incomplete, even without keep-going
+		assert.ErrorIs(t, err, ErrPartialResults)
+		assert.ErrorIs(t, err, ErrScannerTimeout)
+
+		require.NotNil(t, targets[0].Merged)
+		require.NotNil(t, targets[0].Merged.Compositions)
+		assert.Equal(t, cdx.CompositionAggregateUnknown, (*targets[0].Merged.Compositions)[0].Aggregate)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		targets := []*scanner.ScanTarget{newTarget(), newTarget()}
+
+		start := time.Now()
+		err := scanTargets(ctx, targets, nil, FilesystemOptions{ScannerTimeout: time.Minute})
+		assert.Less(t, time.Since(start), 10*time.Second)
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.NotErrorIs(t, err, ErrPartialResults)
+	})
+}
+
+func TestScanTargets_ExternalWithoutRoot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "acme-app")
+	require.NoError(t, os.Mkdir(path, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(path, "build.acme"), []byte(`{"bomFormat": "CycloneDX", "specVersion": "1.6", "version": 1, "components": [{"bom-ref": "pkg:generic/acme-lib@1.0.0", "type": "library", "name": "acme-lib", "version": "1.0.0"}]}`), 0644))
+
+	targets := []*scanner.ScanTarget{{
+		Path:  path,
+		Files: map[string]scanner.Ecosystem{"build.acme": scanner.EcosystemExternal},
+		ExternalScanners: []types.ExternalScannerConfig{
+			{Name: "acme", Command: []string{"cat", "{{.Path}}/build.acme"}, Files: []string{"*.acme"}},
+		},
+	}}
+
+	require.NoError(t, scanTargets(context.Background(), targets, nil, FilesystemOptions{NoCache: true}))
+
+	// the SBOM from the external scanner has no root component, the target gets one
+	merged := targets[0].Merged
+	require.NotNil(t, merged.Metadata.Component)
+	assert.Equal(t, "acme-app", merged.Metadata.Component.Name)
+	require.Len(t, *merged.Components, 1)
+	assert.Equal(t, "acme-lib", (*merged.Components)[0].Name)
+}
+
+func TestAddIncompleteComposition(t *testing.T) {
+	bom := cdx.NewBOM()
+	bom.Metadata = &cdx.Metadata{Component: &cdx.Component{BOMRef: "root"}}
+
+	addIncompleteComposition(bom, true)
+
+	require.NotNil(t, bom.Compositions)
+	require.Len(t, *bom.Compositions, 1)
+	assert.Equal(t, cdx.CompositionAggregateIncomplete, (*bom.Compositions)[0].Aggregate)
+	assert.Equal(t, []cdx.BOMReference{"root"}, *(*bom.Compositions)[0].Dependencies)
+}
@@ -0,0 +1,55 @@
+package tasks
+
+import (
+	"fmt"
+	"path/filepath"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/sbom-observer/observer-cli/pkg/cdxutil"
+	"github.com/sbom-observer/observer-cli/pkg/log"
+	"github.com/sbom-observer/observer-cli/pkg/mergex"
+	"github.com/sbom-observer/observer-cli/pkg/scanner"
+)
+
+// linkTargets returns the product BOM (the BOM of the root target) with BOM-Links to the BOMs of the other targets,
+// followed by the BOMs of the targets. The serial numbers are derived from the name of the product and the path of
+// the target (relative to rootPath), so the links stay the same between scans.
+func linkTargets(sourcePath string, rootPath string, product *cdx.BOM, targets []*scanner.ScanTarget, reproducible bool) ([]*cdx.BOM, error) {
+	boms := []*cdx.BOM{product}
+	for _, target := range targets {
+		boms = append(boms, target.Merged)
+	}
+
+	// BOMRefs are final before they are linked
+	if reproducible {
+		if err := makeReproducible(sourcePath, boms); err != nil {
+			return nil, err
+		}
+	}
+
+	productName := product.Metadata.Component.Name
+	product.SerialNumber = cdxutil.StableSerialNumber(productName + ":.")
+
+	for i, target := range targets {
+		bom := boms[i+1]
+
+		relativePath, err := filepath.Rel(rootPath, target.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get relative path of target %s: %w", target.Path, err)
+		}
+
+		bom.SerialNumber = cdxutil.StableSerialNumber(productName + ":" + filepath.ToSlash(relativePath))
+		if bom.Version < 1 {
+			bom.Version = 1
+		}
+
+		product, err = mergex.LinkBom(product, bom)
+		if err != nil {
+			return nil, fmt.Errorf("failed to link target %s: %w", target.Path, err)
+		}
+
+		log.Debugf("linked %s to %s", relativePath, bom.SerialNumber)
+	}
+
+	return append([]*cdx.BOM{product}, boms[1:]...), nil
+}
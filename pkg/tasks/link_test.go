@@ -0,0 +1,48 @@
+package tasks
+
+import (
+	"path/filepath"
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/sbom-observer/observer-cli/pkg/cdxutil"
+	"github.com/sbom-observer/observer-cli/pkg/ids"
+	"github.com/sbom-observer/observer-cli/pkg/scanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkTargets_StableLinks(t *testing.T) {
+	root := t.TempDir()
+
+	newBOM := func(name string) *cdx.BOM {
+		bom := cdx.NewBOM()
+		bom.Metadata = &cdx.Metadata{Component: &cdx.Component{BOMRef: ids.NextUUID(), Type: cdx.ComponentTypeApplication, Name: name}}
+		return bom
+	}
+
+	// links of a scan, the root components get new random refs in every scan
+	links := func() []string {
+		targets := []*scanner.ScanTarget{
+			{Path: filepath.Join(root, "a", "api"), Merged: newBOM("api")},
+			{Path: filepath.Join(root, "b", "api"), Merged: newBOM("api")},
+		}
+
+		results, err := linkTargets(root, root, newBOM("product"), targets, false)
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+
+		var links []string
+		for _, component := range *results[0].Components {
+			for _, link := range cdxutil.BOMLinks(component) {
+				links = append(links, link.String())
+			}
+		}
+		return links
+	}
+
+	first := links()
+	require.Len(t, first, 2)
+	assert.NotEqual(t, first[0], first[1])
+	assert.Equal(t, first, links())
+}
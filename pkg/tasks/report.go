@@ -0,0 +1,225 @@
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/sbom-observer/observer-cli/pkg/scanner"
+	"github.com/sbom-observer/observer-cli/pkg/types"
+	"golang.org/x/exp/maps"
+)
+
+const (
+	ReportStatusComplete   = "complete"
+	ReportStatusIncomplete = "incomplete"
+	ReportStatusFailed     = "failed"
+	ReportStatusNotScanned = "not-scanned"
+
+	ReportScannerStatusOk      = "ok"
+	ReportScannerStatusCached  = "cached"
+	ReportScannerStatusFailed  = "failed"
+	ReportScannerStatusTimeout = "timeout"
+)
+
+// ScanReport describes a filesystem scan: which targets were found, which files identified them,
+// which scanners ran (and how long they took) and what could not be resolved
+type ScanReport struct {
+	Version    string         `json:"version,omitempty"`
+	StartedAt  time.Time      `json:"startedAt"`
+	DurationMs int64          `json:"durationMs"`
+	Paths      []string       `json:"paths"`
+	Offline    bool           `json:"offline,omitempty"`
+	Targets    []TargetReport `json:"targets"`
+}
+
+type TargetReport struct {
+	Path string `json:"path"`
+	// Files maps the files that identified the target to their ecosystem
+	Files           map[string]string `json:"files"`
+	Component       string            `json:"component,omitempty"`
+	Components      int               `json:"components"`
+	Status          string            `json:"status"`
+	Scanners        []ScannerReport   `json:"scanners"`
+	UnresolvedFiles []string          `json:"unresolvedFiles,omitempty"`
+	OfflineSkipped  []string          `json:"offlineSkipped,omitempty"`
+}
+
+type ScannerReport struct {
+	Id         string `json:"id"`
+	Status     string `json:"status"`
+	DurationMs int64  `json:"durationMs"`
+	Error      string `json:"error,omitempty"`
+}
+
+// NewScanReport creates a report from scanned targets
+func NewScanReport(paths []string, targets []*scanner.ScanTarget, startedAt time.Time) *ScanReport {
+	report := &ScanReport{
+		Version:    types.Version,
+		StartedAt:  startedAt.UTC(),
+		DurationMs: time.Since(startedAt).Milliseconds(),
+		Paths:      paths,
+		Offline:    types.Offline,
+		Targets:    []TargetReport{},
+	}
+
+	for _, target := range targets {
+		report.Targets = append(report.Targets, newTargetReport(target))
+	}
+
+	return report
+}
+
+func newTargetReport(target *scanner.ScanTarget) TargetReport {
+	report := TargetReport{
+		Path:            target.Path,
+		Files:           map[string]string{},
+		Status:          ReportStatusComplete,
+		Scanners:        []ScannerReport{},
+		UnresolvedFiles: target.UnresolvedFiles,
+		OfflineSkipped:  target.OfflineSkipped,
+	}
+
+	for filename, ecosystem := range target.Files {
+		report.Files[filename] = string(ecosystem)
+	}
+
+	if len(target.Runs) == 0 {
+		report.Status = ReportStatusNotScanned
+	}
+
+	for _, run := range target.Runs {
+		scannerReport := ScannerReport{
+			Id:         run.Id,
+			Status:     ReportScannerStatusOk,
+			DurationMs: run.Duration.Milliseconds(),
+		}
+
+		switch {
+		case run.TimedOut:
+			scannerReport.Status = ReportScannerStatusTimeout
+		case run.Err != nil:
+			scannerReport.Status = ReportScannerStatusFailed
+		case run.Cached:
+			scannerReport.Status = ReportScannerStatusCached
+		}
+
+		if run.Err != nil {
+			scannerReport.Error = run.Err.Error()
+			report.Status = ReportStatusIncomplete
+		}
+
+		report.Scanners = append(report.Scanners, scannerReport)
+	}
+
+	if target.Merged == nil {
+		if len(target.Runs) > 0 {
+			report.Status = ReportStatusFailed
+		}
+		return report
+	}
+
+	if target.Merged.Metadata != nil && target.Merged.Metadata.Component != nil {
+		report.Component = target.Merged.Metadata.Component.Name
+		if target.Merged.Metadata.Component.Version != "" {
+			report.Component += "@" + target.Merged.Metadata.Component.Version
+		}
+	}
+
+	if target.Merged.Components != nil {
+		report.Components = len(*target.Merged.Components)
+	}
+
+	return report
+}
+
+// WriteJSON writes the report to a file
+func (r *ScanReport) WriteJSON(filename string) error {
+	bs, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filename, bs, 0644)
+}
+
+// WriteSummary writes a human-readable summary table of the report
+func (r *ScanReport) WriteSummary(w io.Writer) error {
+	root := commonRoot(r.Paths)
+
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Target", "Files", "Scanners", "Components", "Status"})
+
+	components := 0
+	var unresolved []string
+	for _, target := range r.Targets {
+		path := target.Path
+		if rel, err := filepath.Rel(root, target.Path); err == nil && root != "" {
+			path = rel
+		}
+
+		filenames := maps.Keys(target.Files)
+		slices.Sort(filenames)
+
+		var scanners []string
+		for _, s := range target.Scanners {
+			if s.Status == ReportScannerStatusOk {
+				scanners = append(scanners, fmt.Sprintf("%s (%s)", s.Id, formatDuration(s.DurationMs)))
+			} else {
+				scanners = append(scanners, fmt.Sprintf("%s (%s, %s)", s.Id, s.Status, formatDuration(s.DurationMs)))
+			}
+		}
+		for _, name := range target.OfflineSkipped {
+			scanners = append(scanners, fmt.Sprintf("%s (skipped, offline)", name))
+		}
+
+		t.AppendRow(table.Row{path, strings.Join(filenames, "\n"), strings.Join(scanners, "\n"), target.Components, target.Status})
+
+		components += target.Components
+		for _, file := range target.UnresolvedFiles {
+			unresolved = append(unresolved, fmt.Sprintf("%s: %s", path, file))
+		}
+	}
+
+	t.AppendFooter(table.Row{fmt.Sprintf("%d targets", len(r.Targets)), "", formatDuration(r.DurationMs), components, ""})
+	t.Style().Format.Footer = text.FormatDefault
+
+	if _, err := fmt.Fprintln(w, t.Render()); err != nil {
+		return err
+	}
+
+	if len(unresolved) > 0 {
+		if _, err := fmt.Fprintf(w, "\n%d unresolved file(s) in build observations:\n", len(unresolved)); err != nil {
+			return err
+		}
+		for _, file := range unresolved {
+			if _, err := fmt.Fprintf(w, "  %s\n", file); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func formatDuration(ms int64) string {
+	return (time.Duration(ms) * time.Millisecond).Round(time.Millisecond).String()
+}
+
+// commonRoot returns the shortest of the paths (targets are found in or below the scanned paths)
+func commonRoot(paths []string) string {
+	root := ""
+	for _, path := range paths {
+		if root == "" || len(path) < len(root) {
+			root = path
+		}
+	}
+	return root
+}
@@ -0,0 +1,88 @@
+package tasks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/sbom-observer/observer-cli/pkg/scanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanReport(t *testing.T) {
+	merged := cdx.NewBOM()
+	merged.Metadata = &cdx.Metadata{Component: &cdx.Component{Name: "app", Version: "1.0.0"}}
+	merged.Components = &[]cdx.Component{{Name: "left-pad"}, {Name: "lodash"}}
+
+	targets := []*scanner.ScanTarget{
+		{
+			Path:            "/src/app",
+			Files:           map[string]scanner.Ecosystem{"package-lock.json": scanner.EcosystemNpm, "build-observations.json": scanner.EcosystemBuildObserver},
+			UnresolvedFiles: []string{"/usr/lib/libfoo.so"},
+			OfflineSkipped:  []string{"npm/trivy"},
+			Runs: []scanner.ScannerRun{
+				{Id: "trivy", Duration: 1500 * time.Millisecond},
+				{Id: "build-observations", Duration: 10 * time.Millisecond, Cached: true},
+			},
+			Merged: merged,
+		},
+		{
+			Path:  "/src/app/tools",
+			Files: map[string]scanner.Ecosystem{"go.mod": scanner.EcosystemGo},
+			Runs: []scanner.ScannerRun{
+				{Id: "scalibr", Duration: time.Second, TimedOut: true, Err: errors.New("scanner timed out")},
+			},
+		},
+	}
+
+	report := NewScanReport([]string{"/src/app"}, targets, time.Now().Add(-3*time.Second))
+	require.Len(t, report.Targets, 2)
+
+	app := report.Targets[0]
+	assert.Equal(t, "app@1.0.0", app.Component)
+	assert.Equal(t, 2, app.Components)
+	assert.Equal(t, ReportStatusComplete, app.Status)
+	assert.Equal(t, "npm", app.Files["package-lock.json"])
+	assert.Equal(t, []string{"/usr/lib/libfoo.so"}, app.UnresolvedFiles)
+	assert.Equal(t, []string{"npm/trivy"}, app.OfflineSkipped)
+	assert.Equal(t, []ScannerReport{
+		{Id: "trivy", Status: ReportScannerStatusOk, DurationMs: 1500},
+		{Id: "build-observations", Status: ReportScannerStatusCached, DurationMs: 10},
+	}, app.Scanners)
+
+	tools := report.Targets[1]
+	assert.Equal(t, ReportStatusFailed, tools.Status)
+	assert.Equal(t, ReportScannerStatusTimeout, tools.Scanners[0].Status)
+	assert.Equal(t, "scanner timed out", tools.Scanners[0].Error)
+
+	t.Run("json", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "report.json")
+		require.NoError(t, report.WriteJSON(filename))
+
+		bs, err := os.ReadFile(filename)
+		require.NoError(t, err)
+
+		var decoded ScanReport
+		require.NoError(t, json.Unmarshal(bs, &decoded))
+		assert.Equal(t, report.Targets, decoded.Targets)
+	})
+
+	t.Run("summary", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, report.WriteSummary(&buf))
+
+		summary := buf.String()
+		assert.Contains(t, summary, "tools")
+		assert.Contains(t, summary, "trivy (1.5s)")
+		assert.Contains(t, summary, "scalibr (timeout, 1s)")
+		assert.Contains(t, summary, "npm/trivy (skipped, offline)")
+		assert.Contains(t, summary, "1 unresolved file(s)")
+		assert.Contains(t, summary, "/usr/lib/libfoo.so")
+	})
+}
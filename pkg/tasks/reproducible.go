@@ -0,0 +1,59 @@
+package tasks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/sbom-observer/observer-cli/pkg/cdxutil"
+	"github.com/sbom-observer/observer-cli/pkg/execx"
+	"github.com/sbom-observer/observer-cli/pkg/log"
+)
+
+// makeReproducible makes the BOMs reproducible, using the source date of path as the timestamp
+func makeReproducible(path string, boms []*cdx.BOM) error {
+	timestamp, err := SourceDate(path)
+	if err != nil {
+		log.Warn("reproducible: no source date found, omitting the timestamp (set SOURCE_DATE_EPOCH)", "err", err)
+	}
+
+	for _, bom := range boms {
+		if err := cdxutil.MakeReproducible(bom, timestamp); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SourceDate returns the time from SOURCE_DATE_EPOCH (https://reproducible-builds.org/specs/source-date-epoch/),
+// or the commit time of HEAD if path is in a git repository
+func SourceDate(path string) (time.Time, error) {
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		seconds, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH '%s': %w", epoch, err)
+		}
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		path = filepath.Dir(path)
+	}
+
+	output, err := execx.Exec("git", "-C", path, "log", "-1", "--format=%ct")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get the git commit time: %w", err)
+	}
+
+	seconds, err := strconv.ParseInt(strings.TrimSpace(output), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse the git commit time '%s': %w", strings.TrimSpace(output), err)
+	}
+
+	return time.Unix(seconds, 0).UTC(), nil
+}
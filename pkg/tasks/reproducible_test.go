@@ -0,0 +1,85 @@
+package tasks
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/sbom-observer/observer-cli/pkg/ids"
+	"github.com/sbom-observer/observer-cli/pkg/spdxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceDate(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+
+	timestamp, err := SourceDate(t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(1700000000, 0).UTC(), timestamp)
+
+	t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+	_, err = SourceDate(t.TempDir())
+	assert.Error(t, err)
+}
+
+// incompleteScan returns the same (incomplete) scan, with new random refs in every call
+func incompleteScan() *cdx.BOM {
+	rootRef, libRef, serviceRef := ids.NextUUID(), ids.NextUUID(), ids.NextUUID()
+
+	bom := cdx.NewBOM()
+	bom.Metadata = &cdx.Metadata{
+		Component: &cdx.Component{BOMRef: rootRef, Type: cdx.ComponentTypeApplication, Name: "app"},
+	}
+	bom.Components = &[]cdx.Component{{BOMRef: libRef, Type: cdx.ComponentTypeLibrary, Name: "lib", Version: "1.0"}}
+	bom.Services = &[]cdx.Service{{BOMRef: serviceRef, Name: "api"}}
+	bom.Dependencies = &[]cdx.Dependency{{Ref: rootRef, Dependencies: &[]string{libRef, serviceRef}}}
+	bom.Vulnerabilities = &[]cdx.Vulnerability{{BOMRef: ids.NextUUID(), ID: "CVE-2025-0001", Affects: &[]cdx.Affects{{Ref: libRef}}}}
+	addIncompleteComposition(bom, true)
+
+	return bom
+}
+
+func TestMakeReproducible_IncompleteComposition(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+
+	scan := func() string {
+		bom := incompleteScan()
+		require.NoError(t, makeReproducible(t.TempDir(), []*cdx.BOM{bom}))
+
+		composition := (*bom.Compositions)[0]
+		assert.Equal(t, []cdx.BOMReference{cdx.BOMReference(bom.Metadata.Component.BOMRef)}, *composition.Assemblies)
+		assert.Equal(t, []cdx.BOMReference{cdx.BOMReference(bom.Metadata.Component.BOMRef)}, *composition.Dependencies)
+		assert.Equal(t, (*bom.Components)[0].BOMRef, (*(*bom.Vulnerabilities)[0].Affects)[0].Ref)
+		assert.Contains(t, *(*bom.Dependencies)[0].Dependencies, (*bom.Services)[0].BOMRef)
+
+		var buf bytes.Buffer
+		require.NoError(t, cdx.NewBOMEncoder(&buf, cdx.BOMFileFormatJSON).Encode(bom))
+		return buf.String()
+	}
+
+	assert.Equal(t, scan(), scan())
+}
+
+func TestMakeReproducible_SPDX(t *testing.T) {
+	// no source date (the temporary directory is not a git repository), the timestamp is omitted
+	t.Setenv("SOURCE_DATE_EPOCH", "")
+
+	scan := func() (string, string) {
+		bom := incompleteScan()
+		require.NoError(t, makeReproducible(t.TempDir(), []*cdx.BOM{bom}))
+		assert.Empty(t, bom.Metadata.Timestamp)
+
+		var spdx, spdx3 bytes.Buffer
+		require.NoError(t, spdxutil.EncodeJSON(&spdx, bom, true))
+		require.NoError(t, spdxutil.EncodeSPDX3JSON(&spdx3, bom, true))
+		return spdx.String(), spdx3.String()
+	}
+
+	spdx, spdx3 := scan()
+	otherSpdx, otherSpdx3 := scan()
+	assert.Equal(t, spdx, otherSpdx)
+	assert.Equal(t, spdx3, otherSpdx3)
+	assert.Contains(t, spdx, `"created": "1970-01-01T00:00:00Z"`)
+}
@@ -0,0 +1,112 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/sbom-observer/observer-cli/pkg/cache"
+	"github.com/sbom-observer/observer-cli/pkg/files"
+	"github.com/sbom-observer/observer-cli/pkg/log"
+	"github.com/sbom-observer/observer-cli/pkg/scanner"
+	"github.com/sbom-observer/observer-cli/pkg/types"
+	"golang.org/x/exp/maps"
+)
+
+// cachedScan is the cached output of a single scanner for a single target
+type cachedScan struct {
+	Results         []*cdx.BOM `json:"results"`
+	OfflineSkipped  []string   `json:"offlineSkipped,omitempty"`
+	UnresolvedFiles []string   `json:"unresolvedFiles,omitempty"`
+}
+
+// runScanner runs a scanner for the target. Results from versioned scanners are cached and reused
+// as long as the scanner version, the scan config and the content of the target files are unchanged.
+// Returns true if the results were read from the cache.
+func runScanner(ctx context.Context, s scanner.RepoScanner, target *scanner.ScanTarget, scanCache *cache.Cache) (bool, error) {
+	versioned, ok := s.(scanner.VersionedScanner)
+	if scanCache == nil || !ok {
+		return false, s.Scan(ctx, target)
+	}
+
+	version := versioned.Version()
+	if version == "" {
+		return false, s.Scan(ctx, target)
+	}
+
+	key, err := scanCacheKey(s, version, target)
+	if err != nil {
+		log.Debug("not caching scan results", "scanner", s.Id(), "path", target.Path, "err", err)
+		return false, s.Scan(ctx, target)
+	}
+
+	var entry cachedScan
+	found, err := scanCache.Get(key, &entry)
+	if err != nil {
+		log.Debug("failed to read cached scan results", "scanner", s.Id(), "path", target.Path, "err", err)
+	}
+
+	if found {
+		log.Debug("using cached scan results", "scanner", s.Id(), "path", target.Path, "key", key)
+		target.Results = append(target.Results, entry.Results...)
+		target.OfflineSkipped = append(target.OfflineSkipped, entry.OfflineSkipped...)
+		target.UnresolvedFiles = append(target.UnresolvedFiles, entry.UnresolvedFiles...)
+		return true, nil
+	}
+
+	results, offlineSkipped, unresolvedFiles := len(target.Results), len(target.OfflineSkipped), len(target.UnresolvedFiles)
+
+	if err := s.Scan(ctx, target); err != nil {
+		return false, err
+	}
+
+	// store before the results are merged (merging can modify the BOMs)
+	entry = cachedScan{
+		Results:         target.Results[results:],
+		OfflineSkipped:  target.OfflineSkipped[offlineSkipped:],
+		UnresolvedFiles: target.UnresolvedFiles[unresolvedFiles:],
+	}
+
+	if err := scanCache.Put(key, entry); err != nil {
+		log.Warn("failed to cache scan results", "scanner", s.Id(), "path", target.Path, "err", err)
+	}
+
+	return false, nil
+}
+
+// scanCacheKey creates a cache key from the scanner, the settings that affect the scan results and the
+// content of the files read by the scanner (the files in the target, unless the scanner reports its input files)
+func scanCacheKey(s scanner.RepoScanner, scannerVersion string, target *scanner.ScanTarget) (string, error) {
+	parts := []string{
+		s.Id(),
+		scannerVersion,
+		types.Version,
+		types.Commit,
+		target.Path,
+		strconv.FormatBool(target.Config.IncludeDev()),
+		strconv.FormatBool(types.Offline),
+	}
+
+	filenames := maps.Keys(target.Files)
+	if inputs, ok := s.(scanner.InputFilesScanner); ok {
+		var err error
+		filenames, err = inputs.InputFiles(target)
+		if err != nil {
+			return "", fmt.Errorf("failed to list the input files: %w", err)
+		}
+	}
+	slices.Sort(filenames)
+
+	for _, filename := range filenames {
+		hash, err := files.HashFileSha256(filepath.Join(target.Path, filename))
+		if err != nil {
+			return "", fmt.Errorf("failed to hash %s: %w", filename, err)
+		}
+		parts = append(parts, filename, hash)
+	}
+
+	return cache.Key(parts...), nil
+}
@@ -0,0 +1,120 @@
+package tasks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/sbom-observer/observer-cli/pkg/cache"
+	"github.com/sbom-observer/observer-cli/pkg/scanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingScanner struct {
+	version string
+	scans   int
+}
+
+func (s *countingScanner) Id() string        { return "counting" }
+func (s *countingScanner) Priority() int     { return 1000 }
+func (s *countingScanner) IsAvailable() bool { return true }
+func (s *countingScanner) Version() string   { return s.version }
+
+func (s *countingScanner) Scan(ctx context.Context, target *scanner.ScanTarget) error {
+	s.scans++
+
+	bom := cdx.NewBOM()
+	bom.Components = &[]cdx.Component{
+		{BOMRef: "pkg:npm/left-pad@1.3.0", Type: cdx.ComponentTypeLibrary, Name: "left-pad", Version: "1.3.0", PackageURL: "pkg:npm/left-pad@1.3.0"},
+	}
+	target.Results = append(target.Results, bom)
+	return nil
+}
+
+func TestRunScanner_Cache(t *testing.T) {
+	dir := t.TempDir()
+	lockfile := filepath.Join(dir, "package-lock.json")
+	require.NoError(t, os.WriteFile(lockfile, []byte(`{"lockfileVersion": 3}`), 0644))
+
+	scanCache := cache.New(t.TempDir())
+	s := &countingScanner{version: "1.0.0"}
+
+	scan := func() *scanner.ScanTarget {
+		target := &scanner.ScanTarget{Path: dir, Files: map[string]scanner.Ecosystem{"package-lock.json": scanner.EcosystemNpm}}
+		_, err := runScanner(context.Background(), s, target, scanCache)
+		require.NoError(t, err)
+		require.Len(t, target.Results, 1)
+		require.NotNil(t, target.Results[0].Components)
+		assert.Equal(t, "left-pad", (*target.Results[0].Components)[0].Name)
+		return target
+	}
+
+	scan()
+	assert.Equal(t, 1, s.scans)
+
+	// unchanged inputs are read from the cache
+	scan()
+	assert.Equal(t, 1, s.scans)
+
+	// changed files invalidate the cache
+	require.NoError(t, os.WriteFile(lockfile, []byte(`{"lockfileVersion": 3, "packages": {}}`), 0644))
+	scan()
+	assert.Equal(t, 2, s.scans)
+
+	// a new scanner version invalidates the cache
+	s.version = "1.1.0"
+	scan()
+	assert.Equal(t, 3, s.scans)
+
+	// scanners without a version are never cached
+	s.version = ""
+	scan()
+	scan()
+	assert.Equal(t, 5, s.scans)
+
+	// no cache
+	s.version = "1.1.0"
+	target := &scanner.ScanTarget{Path: dir, Files: map[string]scanner.Ecosystem{"package-lock.json": scanner.EcosystemNpm}}
+	_, err := runScanner(context.Background(), s, target, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 6, s.scans)
+}
+
+// directoryScanner reads installed packages in addition to the target files
+type directoryScanner struct {
+	countingScanner
+}
+
+func (s *directoryScanner) InputFiles(target *scanner.ScanTarget) ([]string, error) {
+	return []string{"package-lock.json", "node_modules/left-pad/package.json"}, nil
+}
+
+func TestRunScanner_CacheInputFiles(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "node_modules", "left-pad", "package.json")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "package-lock.json"), []byte(`{"lockfileVersion": 3}`), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Dir(manifest), 0755))
+	require.NoError(t, os.WriteFile(manifest, []byte(`{"version": "1.3.0"}`), 0644))
+
+	scanCache := cache.New(t.TempDir())
+	s := &directoryScanner{countingScanner{version: "1.0.0"}}
+
+	scan := func() {
+		target := &scanner.ScanTarget{Path: dir, Files: map[string]scanner.Ecosystem{"package-lock.json": scanner.EcosystemNpm}}
+		_, err := runScanner(context.Background(), s, target, scanCache)
+		require.NoError(t, err)
+		require.Len(t, target.Results, 1)
+	}
+
+	scan()
+	scan()
+	assert.Equal(t, 1, s.scans)
+
+	// changes in files other than the target files invalidate the cache
+	require.NoError(t, os.WriteFile(manifest, []byte(`{"version": "1.3.1"}`), 0644))
+	scan()
+	assert.Equal(t, 2, s.scans)
+}
@@ -0,0 +1,5 @@
+package types
+
+// Offline disables all network access (package registries, container registries and remote APIs).
+// It's set from the global --offline flag.
+var Offline bool
@@ -14,6 +14,48 @@ type ScanConfig struct {
 	Author         cdx.OrganizationalContact `yaml:"author,omitempty"`
 	Supplier       cdx.OrganizationalEntity  `yaml:"supplier,omitempty"`
 	Manufacturer   cdx.OrganizationalEntity  `yaml:"manufacturer,omitempty"`
+	// IncludeDevDependencies keeps development and test dependencies (with scope "excluded") instead of dropping them
+	// (nil if not set, a value in observer.yml takes precedence over the command line)
+	IncludeDevDependencies *bool `yaml:"includeDev,omitempty"`
+	// Exclude contains gitignore-style rules (relative to the config file) for paths that should not be scanned
+	Exclude []string `yaml:"exclude,omitempty"`
+	// Scanners overrides the scanners that run for an ecosystem (i.e. npm: [scalibr])
+	Scanners map[string][]string `yaml:"scanners,omitempty"`
+	// ExternalScanners are commands that produce CycloneDX SBOMs for files in this directory (and subdirectories)
+	ExternalScanners []ExternalScannerConfig `yaml:"externalScanners,omitempty"`
+}
+
+// IncludeDev returns true if development and test dependencies should be included (the default is false)
+func (c ScanConfig) IncludeDev() bool {
+	return c.IncludeDevDependencies != nil && *c.IncludeDevDependencies
+}
+
+// ExternalScannerConfig configures a command that produces a CycloneDX SBOM for a scan target.
+// Command arguments and Output are Go templates with the fields .Path (the target directory),
+// .Name (the target directory name) and .Output (a temporary file for the SBOM).
+type ExternalScannerConfig struct {
+	Name    string   `yaml:"name"`
+	Command []string `yaml:"command"`
+	// Files are filename patterns (i.e. *.acme) that trigger the scanner
+	Files []string `yaml:"files"`
+	// Output is the file the command writes the SBOM to (default: stdout)
+	Output string `yaml:"output,omitempty"`
+}
+
+func (c ExternalScannerConfig) Validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("external scanner is missing a name")
+	}
+
+	if len(c.Command) == 0 {
+		return fmt.Errorf("external scanner '%s' is missing a command", c.Name)
+	}
+
+	if len(c.Files) == 0 {
+		return fmt.Errorf("external scanner '%s' is missing file patterns", c.Name)
+	}
+
+	return nil
 }
 
 func LoadConfig(config *ScanConfig, filename string) error {